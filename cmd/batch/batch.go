@@ -0,0 +1,62 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli"
+)
+
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+)
+
+var Command = cli.Command{
+	Name:  "batch",
+	Usage: "tools to inspect rollup batches",
+	Subcommands: []cli.Command{
+		decodeCmd,
+		buildCmd,
+	},
+}
+
+var (
+	formatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "the output format: json/table",
+		Value: formatJSON,
+	}
+	l1RPCFlag = cli.StringFlag{
+		Name:  "l1.rpc",
+		Usage: "address of the L1 JSON-RPC endpoint",
+	}
+	l2RPCFlag = cli.StringFlag{
+		Name:  "l2.eth",
+		Usage: "address of the L2 JSON-RPC endpoint",
+	}
+	blobDirFlag = cli.StringFlag{
+		Name:  "blob.dir",
+		Usage: "directory holding the blobs of blob batches, one <versioned hash>.blob file each",
+	}
+)
+
+// printer prints a value as JSON or as a table.
+type printer interface {
+	printTable(w io.Writer) error
+}
+
+func output(ctx *cli.Context, v printer) error {
+	switch format := ctx.String(formatFlag.Name); format {
+	case formatJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case formatTable:
+		return v.printTable(os.Stdout)
+	default:
+		return fmt.Errorf("unknown format %q. expected: %s/%s", format, formatJSON, formatTable)
+	}
+}
@@ -0,0 +1,131 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/big"
+	"text/tabwriter"
+
+	node "github.com/morph-l2/node/core"
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/ethclient"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/urfave/cli"
+)
+
+var (
+	fromFlag = cli.Uint64Flag{
+		Name:  "from",
+		Usage: "the first L2 block of the batch",
+	}
+	toFlag = cli.Uint64Flag{
+		Name:  "to",
+		Usage: "the last L2 block of the batch",
+	}
+	parentFlag = cli.StringFlag{
+		Name:  "parent",
+		Usage: "the hex encoded header of the parent batch, the genesis batch header by default when --from is 1",
+	}
+)
+
+var buildCmd = cli.Command{
+	Name:  "build",
+	Usage: "build the batch of the L2 blocks --from to --to, queried from --l2.eth",
+	Description: "Builds the batch the way the sequencers do, and prints the RollupBatch committed to L2 geth without its signatures. " +
+		"The global batchVersion, batchVersionHeight and chunk.* flags must be the ones of the sequencers.",
+	Action: build,
+	Flags:  []cli.Flag{fromFlag, toFlag, parentFlag, l2RPCFlag, formatFlag},
+}
+
+func build(ctx *cli.Context) error {
+	from, to := ctx.Uint64(fromFlag.Name), ctx.Uint64(toFlag.Name)
+	if from == 0 || to < from {
+		return fmt.Errorf("invalid block range [%d, %d], the batch starts from block 1 on", from, to)
+	}
+	if !ctx.IsSet(l2RPCFlag.Name) {
+		return fmt.Errorf("--%s is required", l2RPCFlag.Name)
+	}
+	config := node.DefaultConfig()
+	if err := config.SetBatchingCliContext(ctx); err != nil {
+		return err
+	}
+	batchVersions, err := types.NewBatchVersionSchedule(config.BatchVersionUpgrades...)
+	if err != nil {
+		return err
+	}
+	chunkLimits, err := types.NewChunkLimitsSchedule(config.ChunkLimitsUpgrades...)
+	if err != nil {
+		return err
+	}
+
+	l2Client, err := ethclient.Dial(ctx.String(l2RPCFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer l2Client.Close()
+	prevHeader, err := l2Client.HeaderByNumber(context.Background(), new(big.Int).SetUint64(from-1))
+	if err != nil {
+		return fmt.Errorf("get L2 block %d error: %w", from-1, err)
+	}
+	var parentBatchHeader types.BatchHeader
+	switch {
+	case ctx.IsSet(parentFlag.Name):
+		parentBytes, err := hexutil.Decode(ctx.String(parentFlag.Name))
+		if err != nil {
+			return fmt.Errorf("invalid parent batch header: %w", err)
+		}
+		if parentBatchHeader, err = types.DecodeBatchHeader(parentBytes); err != nil {
+			return err
+		}
+	case from == 1:
+		if parentBatchHeader, err = node.GenesisBatchHeader(prevHeader); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("--%s is required for a batch not following the genesis batch", parentFlag.Name)
+	}
+
+	blocks := make([]*eth.BlockWithRowConsumption, 0, to-from+1)
+	for number := from; number <= to; number++ {
+		block, err := l2Client.GetBlockByNumberOrHash(context.Background(), rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number)))
+		if err != nil {
+			return fmt.Errorf("get L2 block %d error: %w", number, err)
+		}
+		blocks = append(blocks, block)
+	}
+	batchHeader, rollupBatch, err := node.BuildBatch(batchVersions, chunkLimits, parentBatchHeader, prevHeader.Root, blocks)
+	if err != nil {
+		return err
+	}
+	return output(ctx, &BuiltBatch{
+		BatchHeader: newBatchHeader(batchHeader),
+		RollupBatch: rollupBatch,
+	})
+}
+
+// BuiltBatch is a batch built from L2 blocks.
+type BuiltBatch struct {
+	BatchHeader *BatchHeader     `json:"batchHeader"`
+	RollupBatch *eth.RollupBatch `json:"rollupBatch"`
+}
+
+func (b *BuiltBatch) printTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	printBatchHeader(tw, "batch header", b.BatchHeader)
+	fmt.Fprintf(tw, "rollup batch\n")
+	fmt.Fprintf(tw, "  index\t%d\n", b.RollupBatch.Index)
+	fmt.Fprintf(tw, "  hash\t%s\n", b.RollupBatch.Hash.Hex())
+	fmt.Fprintf(tw, "  version\t%d\n", b.RollupBatch.Version)
+	fmt.Fprintf(tw, "  parent batch header\t%s\n", hexutil.Bytes(b.RollupBatch.ParentBatchHeader))
+	for i, chunk := range b.RollupBatch.Chunks {
+		fmt.Fprintf(tw, "  chunk %d\t%d bytes\n", i, len(chunk))
+	}
+	fmt.Fprintf(tw, "  skipped l1 message bitmap\t%s\n", hexutil.Bytes(b.RollupBatch.SkippedL1MessageBitmap))
+	fmt.Fprintf(tw, "  prev state root\t%s\n", b.RollupBatch.PrevStateRoot.Hex())
+	fmt.Fprintf(tw, "  post state root\t%s\n", b.RollupBatch.PostStateRoot.Hex())
+	fmt.Fprintf(tw, "  withdraw root\t%s\n", b.RollupBatch.WithdrawRoot.Hex())
+	return tw.Flush()
+}
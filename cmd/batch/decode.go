@@ -0,0 +1,401 @@
+package batch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/morph-l2/node/derivation"
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	geth "github.com/scroll-tech/go-ethereum/eth"
+	"github.com/scroll-tech/go-ethereum/ethclient"
+	"github.com/urfave/cli"
+)
+
+var (
+	txFlag = cli.StringFlag{
+		Name:  "tx",
+		Usage: "hash of the L1 commitBatch transaction, fetched from --l1.rpc",
+	}
+	fileFlag = cli.StringFlag{
+		Name:  "file",
+		Usage: "file holding the hex encoded calldata of a commitBatch transaction",
+	}
+)
+
+var decodeCmd = cli.Command{
+	Name:  "decode",
+	Usage: "decode a commitBatch transaction, given by --tx or --file",
+	Description: "Prints the parent batch header, the chunks with their blocks and L2 transactions, the skipped L1 messages " +
+		"and the recomputed batch header. Recomputing the data hash of a batch including L1 messages requires --l2.eth, " +
+		"as the L1 messages are not in the calldata.",
+	Action: decode,
+	Flags:  []cli.Flag{txFlag, fileFlag, l1RPCFlag, l2RPCFlag, blobDirFlag, formatFlag},
+}
+
+func decode(ctx *cli.Context) error {
+	calldata, err := readCalldata(ctx)
+	if err != nil {
+		return err
+	}
+	batch, err := derivation.UnpackCommitBatch(calldata)
+	if err != nil {
+		return err
+	}
+	var blobs []*types.Blob
+	if batch.Version == uint(types.BatchVersionV2) {
+		if !ctx.IsSet(blobDirFlag.Name) {
+			return fmt.Errorf("batch version %d commits its chunks in blobs, --%s is required", batch.Version, blobDirFlag.Name)
+		}
+		source, err := derivation.NewFileBlobSource(ctx.String(blobDirFlag.Name))
+		if err != nil {
+			return err
+		}
+		if blobs, err = derivation.FetchBlobs(context.Background(), source, batch); err != nil {
+			return err
+		}
+	}
+	var blockTxHashes BlockTxHashesFunc
+	if ctx.IsSet(l2RPCFlag.Name) {
+		l2Client, err := ethclient.Dial(ctx.String(l2RPCFlag.Name))
+		if err != nil {
+			return err
+		}
+		defer l2Client.Close()
+		blockTxHashes = func(number uint64) ([]common.Hash, error) {
+			block, err := l2Client.BlockByNumber(context.Background(), new(big.Int).SetUint64(number))
+			if err != nil {
+				return nil, fmt.Errorf("get L2 block %d error: %w", number, err)
+			}
+			hashes := make([]common.Hash, len(block.Transactions()))
+			for i, tx := range block.Transactions() {
+				hashes[i] = tx.Hash()
+			}
+			return hashes, nil
+		}
+	}
+	decoded, err := DecodeBatch(batch, blobs, blockTxHashes)
+	if err != nil {
+		return err
+	}
+	return output(ctx, decoded)
+}
+
+// readCalldata reads the calldata of the commitBatch transaction from L1, or from the file.
+func readCalldata(ctx *cli.Context) ([]byte, error) {
+	switch {
+	case ctx.IsSet(txFlag.Name) && ctx.IsSet(fileFlag.Name):
+		return nil, fmt.Errorf("only one of --%s and --%s is expected", txFlag.Name, fileFlag.Name)
+	case ctx.IsSet(txFlag.Name):
+		if !ctx.IsSet(l1RPCFlag.Name) {
+			return nil, fmt.Errorf("--%s is required to fetch the transaction", l1RPCFlag.Name)
+		}
+		l1Client, err := ethclient.Dial(ctx.String(l1RPCFlag.Name))
+		if err != nil {
+			return nil, err
+		}
+		defer l1Client.Close()
+		tx, _, err := l1Client.TransactionByHash(context.Background(), common.HexToHash(ctx.String(txFlag.Name)))
+		if err != nil {
+			return nil, fmt.Errorf("get transaction error: %w", err)
+		}
+		return tx.Data(), nil
+	case ctx.IsSet(fileFlag.Name):
+		data, err := os.ReadFile(ctx.String(fileFlag.Name))
+		if err != nil {
+			return nil, err
+		}
+		hexData := strings.TrimSpace(string(data))
+		if !strings.HasPrefix(hexData, "0x") {
+			hexData = "0x" + hexData
+		}
+		return hexutil.Decode(hexData)
+	default:
+		return nil, fmt.Errorf("one of --%s and --%s is expected", txFlag.Name, fileFlag.Name)
+	}
+}
+
+// BlockTxHashesFunc returns the hashes of all the transactions of the L2 block, L1 messages included.
+type BlockTxHashesFunc func(number uint64) ([]common.Hash, error)
+
+type BatchHeader struct {
+	Version                uint8         `json:"version"`
+	BatchIndex             uint64        `json:"batchIndex"`
+	L1MessagePopped        uint64        `json:"l1MessagePopped"`
+	TotalL1MessagePopped   uint64        `json:"totalL1MessagePopped"`
+	DataHash               common.Hash   `json:"dataHash"`
+	ParentBatchHash        common.Hash   `json:"parentBatchHash"`
+	BlobVersionedHashes    []common.Hash `json:"blobVersionedHashes,omitempty"`
+	SkippedL1MessageBitmap hexutil.Bytes `json:"skippedL1MessageBitmap"`
+	Hash                   common.Hash   `json:"hash"`
+	// Encoded is the encoded header, as passed to batch build as --parent.
+	Encoded hexutil.Bytes `json:"encoded"`
+}
+
+func newBatchHeader(header *types.BatchHeader) *BatchHeader {
+	return &BatchHeader{
+		Version:                header.Version,
+		BatchIndex:             header.BatchIndex,
+		L1MessagePopped:        header.L1MessagePopped,
+		TotalL1MessagePopped:   header.TotalL1MessagePopped,
+		DataHash:               header.DataHash,
+		ParentBatchHash:        header.ParentBatchHash,
+		BlobVersionedHashes:    header.BlobVersionedHashes,
+		SkippedL1MessageBitmap: header.SkippedL1MessageBitmap,
+		Hash:                   header.Hash(),
+		Encoded:                header.Encode(),
+	}
+}
+
+type Transaction struct {
+	Hash  common.Hash     `json:"hash"`
+	Type  uint8           `json:"type"`
+	Nonce uint64          `json:"nonce"`
+	To    *common.Address `json:"to"`
+	Value *hexutil.Big    `json:"value"`
+	Gas   uint64          `json:"gas"`
+	Size  int             `json:"size"`
+}
+
+type Block struct {
+	Number        uint64        `json:"number"`
+	Timestamp     uint64        `json:"timestamp"`
+	BaseFee       *hexutil.Big  `json:"baseFee"`
+	GasLimit      uint64        `json:"gasLimit"`
+	NumTxs        uint16        `json:"numTxs"`
+	NumL1Messages uint16        `json:"numL1Messages"`
+	Transactions  []Transaction `json:"transactions"`
+}
+
+type Chunk struct {
+	// Hash is nil if the hashes of the L1 messages of the chunk are unknown.
+	Hash   *common.Hash `json:"hash"`
+	Blocks []Block      `json:"blocks"`
+}
+
+// DecodedBatch is a committed batch as decoded from the commitBatch calldata.
+type DecodedBatch struct {
+	ParentBatchHeader *BatchHeader `json:"parentBatchHeader"`
+	// BatchHeader is recomputed from the batch data, nil if the data hash can not be.
+	BatchHeader       *BatchHeader `json:"batchHeader"`
+	PrevStateRoot     common.Hash  `json:"prevStateRoot"`
+	PostStateRoot     common.Hash  `json:"postStateRoot"`
+	WithdrawRoot      common.Hash  `json:"withdrawRoot"`
+	SkippedL1Messages []uint64     `json:"skippedL1Messages"`
+	Chunks            []Chunk      `json:"chunks"`
+	Warnings          []string     `json:"warnings,omitempty"`
+}
+
+// DecodeBatch decodes the batch, blobs are the blobs of a blob batch.
+// blockTxHashes gives the hashes of the L1 messages needed to recompute the data hash, it may be nil.
+func DecodeBatch(batch geth.RPCRollupBatch, blobs []*types.Blob, blockTxHashes BlockTxHashesFunc) (*DecodedBatch, error) {
+	parentBatchHeader, err := types.DecodeBatchHeader(batch.ParentBatchHeader)
+	if err != nil {
+		return nil, fmt.Errorf("decode parent batch header error: %w", err)
+	}
+	var (
+		batchInfo *derivation.BatchInfo
+		rawChunks []*types.Chunk
+	)
+	if batch.Version == uint(types.BatchVersionV2) {
+		if batchInfo, err = derivation.ParseBlobBatch(batch, blobs); err != nil {
+			return nil, err
+		}
+		if rawChunks, err = types.DecodeBlobs(blobs); err != nil {
+			return nil, err
+		}
+	} else {
+		if batchInfo, err = derivation.ParseBatch(batch); err != nil {
+			return nil, err
+		}
+		for _, chunkBytes := range batch.Chunks {
+			chunk, err := types.DecodeChunk(uint8(batch.Version), chunkBytes)
+			if err != nil {
+				return nil, err
+			}
+			rawChunks = append(rawChunks, chunk)
+		}
+	}
+
+	decoded := &DecodedBatch{
+		ParentBatchHeader: newBatchHeader(&parentBatchHeader),
+		PrevStateRoot:     batch.PrevStateRoot,
+		PostStateRoot:     batch.PostStateRoot,
+		WithdrawRoot:      batch.WithdrawRoot,
+		SkippedL1Messages: []uint64{},
+	}
+	var (
+		l1MessagePopped uint64
+		chunkHashes     []byte
+		dataHashKnown   = true
+	)
+	for i, chunk := range batchInfo.Chunks() {
+		var (
+			decodedChunk = Chunk{Blocks: []Block{}}
+			txHashes     []common.Hash
+			hashKnown    = true
+		)
+		for _, blockContext := range chunk.BlockContexts() {
+			block := Block{
+				Number:        blockContext.Number,
+				Timestamp:     blockContext.Timestamp,
+				BaseFee:       (*hexutil.Big)(blockContext.BaseFee),
+				GasLimit:      blockContext.GasLimit,
+				NumTxs:        blockContext.TxsNum(),
+				NumL1Messages: blockContext.L1MsgNum(),
+				Transactions:  []Transaction{},
+			}
+			var l2TxHashes []common.Hash
+			for _, txBytes := range blockContext.SafeL2Data.Transactions {
+				var tx eth.Transaction
+				if err := tx.UnmarshalBinary(txBytes); err != nil {
+					return nil, fmt.Errorf("decode transaction of block %d error: %w", block.Number, err)
+				}
+				block.Transactions = append(block.Transactions, Transaction{
+					Hash:  tx.Hash(),
+					Type:  tx.Type(),
+					Nonce: tx.Nonce(),
+					To:    tx.To(),
+					Value: (*hexutil.Big)(tx.Value()),
+					Gas:   tx.Gas(),
+					Size:  len(txBytes),
+				})
+				l2TxHashes = append(l2TxHashes, tx.Hash())
+			}
+			l1MessagePopped += uint64(block.NumL1Messages)
+
+			switch {
+			case block.NumL1Messages == 0:
+				txHashes = append(txHashes, l2TxHashes...)
+			case blockTxHashes != nil:
+				hashes, err := blockTxHashes(block.Number)
+				if err != nil {
+					return nil, err
+				}
+				if err = checkBlockTxHashes(hashes, l2TxHashes, block.NumTxs); err != nil {
+					return nil, fmt.Errorf("L2 block %d does not match the batch: %w", block.Number, err)
+				}
+				txHashes = append(txHashes, hashes...)
+			default:
+				hashKnown = false
+			}
+			decodedChunk.Blocks = append(decodedChunk.Blocks, block)
+		}
+		if hashKnown {
+			ck := types.NewChunk(rawChunks[i].BlockContext(), nil, txHashes, nil)
+			ck.ResetBlockNum(rawChunks[i].BlockNum())
+			hash := ck.Hash()
+			decodedChunk.Hash = &hash
+			chunkHashes = append(chunkHashes, hash[:]...)
+		} else {
+			dataHashKnown = false
+		}
+		decoded.Chunks = append(decoded.Chunks, decodedChunk)
+	}
+
+	skippedBitmap := batchInfo.SkippedL1MessageBitmap()
+	for i := uint64(0); i < l1MessagePopped; i++ {
+		if skippedBitmap.Test(i) {
+			decoded.SkippedL1Messages = append(decoded.SkippedL1Messages, parentBatchHeader.TotalL1MessagePopped+i)
+		}
+	}
+
+	if !dataHashKnown {
+		decoded.Warnings = append(decoded.Warnings, "the batch includes L1 messages, pass --l2.eth to recompute its data hash and batch hash")
+		return decoded, nil
+	}
+	batchHeader := types.BatchHeader{
+		Version:                uint8(batch.Version),
+		BatchIndex:             parentBatchHeader.BatchIndex + 1,
+		L1MessagePopped:        l1MessagePopped,
+		TotalL1MessagePopped:   parentBatchHeader.TotalL1MessagePopped + l1MessagePopped,
+		DataHash:               crypto.Keccak256Hash(chunkHashes),
+		ParentBatchHash:        parentBatchHeader.Hash(),
+		BlobVersionedHashes:    batchInfo.BlobVersionedHashes(),
+		SkippedL1MessageBitmap: batch.SkippedL1MessageBitmap,
+	}
+	decoded.BatchHeader = newBatchHeader(&batchHeader)
+	return decoded, nil
+}
+
+// checkBlockTxHashes checks the transactions of an L2 block against the L2 transactions of the batch:
+// the L1 messages come first, followed by the L2 transactions.
+func checkBlockTxHashes(hashes, l2TxHashes []common.Hash, numTxs uint16) error {
+	if len(hashes) != int(numTxs) {
+		return fmt.Errorf("%d transactions, expected %d", len(hashes), numTxs)
+	}
+	l1MsgNum := len(hashes) - len(l2TxHashes)
+	for i, hash := range l2TxHashes {
+		if hashes[l1MsgNum+i] != hash {
+			return errors.New("different L2 transactions")
+		}
+	}
+	return nil
+}
+
+func (b *DecodedBatch) printTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	printBatchHeader(tw, "parent batch header", b.ParentBatchHeader)
+	if b.BatchHeader != nil {
+		printBatchHeader(tw, "batch header", b.BatchHeader)
+	}
+	fmt.Fprintf(tw, "prev state root\t%s\n", b.PrevStateRoot.Hex())
+	fmt.Fprintf(tw, "post state root\t%s\n", b.PostStateRoot.Hex())
+	fmt.Fprintf(tw, "withdraw root\t%s\n", b.WithdrawRoot.Hex())
+	fmt.Fprintf(tw, "skipped l1 messages\t%v\n", b.SkippedL1Messages)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for i, chunk := range b.Chunks {
+		hash := "unknown"
+		if chunk.Hash != nil {
+			hash = chunk.Hash.Hex()
+		}
+		fmt.Fprintf(w, "\nchunk %d, hash %s\n", i, hash)
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "BLOCK\tTIMESTAMP\tBASE FEE\tGAS LIMIT\tTXS\tL1 MSGS\tTX HASH\tTYPE\tNONCE\tTO\tVALUE\tGAS\tSIZE")
+		for _, block := range chunk.Blocks {
+			fmt.Fprintf(tw, "%d\t%d\t%s\t%d\t%d\t%d\t\t\t\t\t\t\t\n", block.Number, block.Timestamp, block.BaseFee.String(), block.GasLimit, block.NumTxs, block.NumL1Messages)
+			for _, tx := range block.Transactions {
+				to := "-"
+				if tx.To != nil {
+					to = tx.To.Hex()
+				}
+				fmt.Fprintf(tw, "\t\t\t\t\t\t%s\t%d\t%d\t%s\t%s\t%d\t%d\n", tx.Hash.Hex(), tx.Type, tx.Nonce, to, tx.Value.String(), tx.Gas, tx.Size)
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	for _, warning := range b.Warnings {
+		fmt.Fprintf(w, "\nwarning: %s\n", warning)
+	}
+	return nil
+}
+
+func printBatchHeader(tw *tabwriter.Writer, name string, h *BatchHeader) {
+	fmt.Fprintf(tw, "%s\n", name)
+	fmt.Fprintf(tw, "  version\t%d\n", h.Version)
+	fmt.Fprintf(tw, "  batch index\t%d\n", h.BatchIndex)
+	fmt.Fprintf(tw, "  l1 message popped\t%d\n", h.L1MessagePopped)
+	fmt.Fprintf(tw, "  total l1 message popped\t%d\n", h.TotalL1MessagePopped)
+	fmt.Fprintf(tw, "  data hash\t%s\n", h.DataHash.Hex())
+	fmt.Fprintf(tw, "  parent batch hash\t%s\n", h.ParentBatchHash.Hex())
+	for _, hash := range h.BlobVersionedHashes {
+		fmt.Fprintf(tw, "  blob versioned hash\t%s\n", hash.Hex())
+	}
+	fmt.Fprintf(tw, "  skipped l1 message bitmap\t%s\n", h.SkippedL1MessageBitmap)
+	fmt.Fprintf(tw, "  hash\t%s\n", h.Hash.Hex())
+	fmt.Fprintf(tw, "  encoded\t%s\n", h.Encoded)
+}
@@ -0,0 +1,120 @@
+package batch
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/morph-l2/bindings/bindings"
+	"github.com/morph-l2/node/derivation"
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func testBlock(t *testing.T, number uint64, l1MsgNum uint16, l2TxNum int) (blockContext, txsPayload []byte, l2TxHashes []common.Hash) {
+	blockContext = make([]byte, 60)
+	binary.BigEndian.PutUint64(blockContext[0:], number)
+	binary.BigEndian.PutUint64(blockContext[8:], 1700000000+number)
+	big.NewInt(1000).FillBytes(blockContext[16:48])
+	binary.BigEndian.PutUint64(blockContext[48:], 30_000_000)
+	binary.BigEndian.PutUint16(blockContext[56:], l1MsgNum+uint16(l2TxNum))
+	binary.BigEndian.PutUint16(blockContext[58:], l1MsgNum)
+	for i := 0; i < l2TxNum; i++ {
+		to := common.BigToAddress(big.NewInt(int64(i + 1)))
+		tx := eth.NewTx(&eth.LegacyTx{Nonce: number*10 + uint64(i), To: &to, Value: big.NewInt(1), Gas: 21000, GasPrice: big.NewInt(1)})
+		txBytes, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		var txLen [4]byte
+		binary.BigEndian.PutUint32(txLen[:], uint32(len(txBytes)))
+		txsPayload = append(txsPayload, txLen[:]...)
+		txsPayload = append(txsPayload, txBytes...)
+		l2TxHashes = append(l2TxHashes, tx.Hash())
+	}
+	return
+}
+
+func TestDecodeBatch(t *testing.T) {
+	parentBatchHeader := types.BatchHeader{Version: types.BatchVersionV1, BatchIndex: 7, TotalL1MessagePopped: 20, SkippedL1MessageBitmap: []byte{}}
+	l1MessageHashes := map[uint64][]common.Hash{
+		2: {common.HexToHash("0x1001"), common.HexToHash("0x1002"), common.HexToHash("0x1003")},
+	}
+	// queue index 21 is skipped, 20 and 22 are included
+	skippedBitmap := &types.SkippedBitmap{}
+	skippedBitmap.Set(1)
+	skippedBitmap.Extend(2)
+
+	chunks := types.NewChunks()
+	chunks.SetLimits(types.ChunkLimits{MaxBlocks: 2, MaxRowNumber: 1})
+	blockTxHashes := map[uint64][]common.Hash{}
+	for number := uint64(1); number <= 3; number++ {
+		l1Hashes := l1MessageHashes[number]
+		blockContext, txsPayload, l2TxHashes := testBlock(t, number, uint16(len(l1Hashes)), int(number))
+		blockTxHashes[number] = append(append([]common.Hash{}, l1Hashes...), l2TxHashes...)
+		chunks.Append(blockContext, txsPayload, blockTxHashes[number], nil)
+	}
+	require.Equal(t, 2, chunks.ChunkNum())
+	expectedBatchHeader := types.BatchHeader{
+		Version:                types.BatchVersionV1,
+		BatchIndex:             8,
+		L1MessagePopped:        3,
+		TotalL1MessagePopped:   23,
+		DataHash:               chunks.DataHash(),
+		ParentBatchHash:        parentBatchHeader.Hash(),
+		SkippedL1MessageBitmap: skippedBitmap.Encode(),
+	}
+
+	chunksBytes, err := chunks.EncodeWithVersion(types.BatchVersionV1)
+	require.NoError(t, err)
+	rollupABI, err := bindings.RollupMetaData.GetAbi()
+	require.NoError(t, err)
+	calldata, err := rollupABI.Pack("commitBatch", bindings.IRollupBatchData{
+		Version:                types.BatchVersionV1,
+		ParentBatchHeader:      parentBatchHeader.Encode(),
+		Chunks:                 chunksBytes,
+		SkippedL1MessageBitmap: skippedBitmap.Encode(),
+		PostStateRoot:          common.HexToHash("0x02"),
+		Signature:              bindings.IRollupBatchSignature{Version: big.NewInt(0)},
+	})
+	require.NoError(t, err)
+	batch, err := derivation.UnpackCommitBatch(calldata)
+	require.NoError(t, err)
+
+	decoded, err := DecodeBatch(batch, nil, func(number uint64) ([]common.Hash, error) {
+		return blockTxHashes[number], nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), decoded.ParentBatchHeader.BatchIndex)
+	require.Equal(t, common.HexToHash("0x02"), decoded.PostStateRoot)
+	require.Equal(t, []uint64{21}, decoded.SkippedL1Messages)
+	require.Len(t, decoded.Chunks, 2)
+	require.Len(t, decoded.Chunks[0].Blocks, 2)
+	block := decoded.Chunks[0].Blocks[1]
+	require.Equal(t, uint64(2), block.Number)
+	require.Equal(t, uint16(5), block.NumTxs)
+	require.Equal(t, uint16(3), block.NumL1Messages)
+	require.Len(t, block.Transactions, 2)
+	require.Equal(t, blockTxHashes[2][3], block.Transactions[0].Hash)
+	require.Equal(t, uint64(20), block.Transactions[0].Nonce)
+	require.Equal(t, big.NewInt(1000), block.BaseFee.ToInt())
+	require.Empty(t, decoded.Warnings)
+	require.NotNil(t, decoded.BatchHeader)
+	require.Equal(t, expectedBatchHeader.DataHash, decoded.BatchHeader.DataHash)
+	require.Equal(t, expectedBatchHeader.Hash(), decoded.BatchHeader.Hash)
+	require.Equal(t, uint64(23), decoded.BatchHeader.TotalL1MessagePopped)
+
+	// without the L2 blocks, the hashes of the L1 messages are unknown
+	decoded, err = DecodeBatch(batch, nil, nil)
+	require.NoError(t, err)
+	require.Nil(t, decoded.BatchHeader)
+	require.Nil(t, decoded.Chunks[0].Hash)
+	require.NotNil(t, decoded.Chunks[1].Hash)
+	require.NotEmpty(t, decoded.Warnings)
+
+	// the L2 blocks must match the batch
+	_, err = DecodeBatch(batch, nil, func(number uint64) ([]common.Hash, error) {
+		return blockTxHashes[number][:1], nil
+	})
+	require.Error(t, err)
+}
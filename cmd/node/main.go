@@ -9,19 +9,22 @@ import (
 	"syscall"
 
 	"github.com/morph-l2/bindings/bindings"
+	"github.com/morph-l2/node/cmd/batch"
 	"github.com/morph-l2/node/cmd/keyconverter"
+	nodecommon "github.com/morph-l2/node/common"
 	node "github.com/morph-l2/node/core"
 	"github.com/morph-l2/node/db"
 	"github.com/morph-l2/node/derivation"
 	"github.com/morph-l2/node/flags"
+	"github.com/morph-l2/node/noderpc"
 	"github.com/morph-l2/node/sequencer"
 	"github.com/morph-l2/node/sequencer/mock"
+	"github.com/morph-l2/node/sequencer/signer"
 	"github.com/morph-l2/node/sync"
 	"github.com/morph-l2/node/types"
 	"github.com/morph-l2/node/validator"
-	"github.com/scroll-tech/go-ethereum/ethclient"
+	"github.com/scroll-tech/go-ethereum/rpc"
 	tmnode "github.com/tendermint/tendermint/node"
-	"github.com/tendermint/tendermint/privval"
 	"github.com/urfave/cli"
 )
 
@@ -40,6 +43,7 @@ func main() {
 	app.Action = L2NodeMain
 	app.Commands = []cli.Command{
 		keyConverterCmd,
+		batch.Command,
 	}
 	err := app.Run(os.Args)
 	if err != nil {
@@ -55,7 +59,9 @@ func L2NodeMain(ctx *cli.Context) error {
 		syncer   *sync.Syncer
 		ms       *mock.Sequencer
 		tmNode   *tmnode.Node
+		tmSigner signer.Signer
 		dvNode   *derivation.Derivation
+		rpcSrv   *noderpc.Server
 
 		nodeConfig = node.DefaultConfig()
 	)
@@ -69,6 +75,12 @@ func L2NodeMain(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	if addr := ctx.GlobalString(flags.RPCAddr.Name); addr != "" {
+		rpcSrv = noderpc.NewServer(addr, nodeConfig.Logger)
+		if err = rpcSrv.Start(); err != nil {
+			return fmt.Errorf("failed to start rpc server, error: %v", err)
+		}
+	}
 
 	if isValidator {
 		// configure store
@@ -90,11 +102,14 @@ func L2NodeMain(ctx *cli.Context) error {
 		if err != nil {
 			return fmt.Errorf("failed to create syncer, error: %v", err)
 		}
+		if err = registerAPIs(rpcSrv, syncer.APIs()); err != nil {
+			return err
+		}
 		validatorCfg := validator.NewConfig()
 		if err := validatorCfg.SetCliContext(ctx); err != nil {
 			return fmt.Errorf("validator set cli context error: %v", err)
 		}
-		l1Client, err := ethclient.Dial(derivationCfg.L1.Addr)
+		l1Client, err := nodecommon.DialQuorumClient(derivationCfg.L1.Addrs, derivationCfg.L1.Quorum, nodeConfig.Logger)
 		if err != nil {
 			return fmt.Errorf("dial l1 node error:%v", err)
 		}
@@ -111,6 +126,7 @@ func L2NodeMain(ctx *cli.Context) error {
 		if err != nil {
 			return fmt.Errorf("new derivation client error: %v", err)
 		}
+		registerHealthCheck(rpcSrv, "l2", dvNode.L2Health)
 		dvNode.Start()
 		nodeConfig.Logger.Info("derivation node starting")
 	} else {
@@ -119,13 +135,36 @@ func L2NodeMain(ctx *cli.Context) error {
 		if err != nil {
 			return err
 		}
-		tmVal := privval.LoadOrGenFilePV(tmCfg.PrivValidatorKeyFile(), tmCfg.PrivValidatorStateFile())
-		pubKey, _ := tmVal.GetPubKey()
-		newSyncerFunc := func() (*sync.Syncer, error) { return node.NewSyncer(ctx, home, nodeConfig) }
-		executor, err = node.NewExecutor(newSyncerFunc, nodeConfig, pubKey)
+		tmSigner, err = signer.NewSigner(tmCfg, nodeConfig.Logger)
+		if err != nil {
+			return fmt.Errorf("failed to set up the signer, error: %v", err)
+		}
+		pubKey, err := tmSigner.GetPubKey()
+		if err != nil {
+			return fmt.Errorf("failed to get the public key from the signer, error: %v", err)
+		}
+		// the store keeps the L1 messages synced and the batches sealed
+		dbConfig := db.DefaultConfig()
+		dbConfig.SetCliContext(ctx)
+		store, err := db.NewStore(dbConfig, home)
 		if err != nil {
 			return err
 		}
+		newSyncerFunc := func(l1Client *nodecommon.QuorumClient) (*sync.Syncer, error) {
+			syncer, err := node.NewSyncer(ctx, store, nodeConfig, l1Client)
+			if err != nil {
+				return nil, err
+			}
+			return syncer, registerAPIs(rpcSrv, syncer.APIs())
+		}
+		executor, err = node.NewExecutor(newSyncerFunc, nodeConfig, pubKey, store)
+		if err != nil {
+			return err
+		}
+		if err = registerAPIs(rpcSrv, executor.APIs()); err != nil {
+			return err
+		}
+		registerHealthCheck(rpcSrv, "l2", executor.L2Client().Health)
 		if isMockSequencer {
 			ms, err = mock.NewSequencer(executor)
 			if err != nil {
@@ -133,7 +172,7 @@ func L2NodeMain(ctx *cli.Context) error {
 			}
 			go ms.Start()
 		} else {
-			if tmNode, err = sequencer.SetupNode(tmCfg, tmVal, executor, nodeConfig.Logger); err != nil {
+			if tmNode, err = sequencer.SetupNode(tmCfg, tmSigner, executor, nodeConfig.Logger); err != nil {
 				return fmt.Errorf("failed to setup consensus node, error: %v", err)
 			}
 			if err = tmNode.Start(); err != nil {
@@ -157,16 +196,38 @@ func L2NodeMain(ctx *cli.Context) error {
 	if tmNode != nil {
 		tmNode.Stop()
 	}
+	if tmSigner != nil {
+		tmSigner.Close()
+	}
 	if syncer != nil {
 		syncer.Stop()
 	}
+	if executor != nil {
+		executor.Stop()
+	}
 	if dvNode != nil {
 		dvNode.Stop()
 	}
+	rpcSrv.Stop()
 
 	return nil
 }
 
+// registerHealthCheck adds the check to the health served by the rpc server, if it is enabled.
+func registerHealthCheck(rpcSrv *noderpc.Server, name string, check noderpc.HealthCheck) {
+	if rpcSrv != nil {
+		rpcSrv.RegisterHealthCheck(name, check)
+	}
+}
+
+// registerAPIs exposes apis if the rpc server is enabled.
+func registerAPIs(rpcSrv *noderpc.Server, apis []rpc.API) error {
+	if rpcSrv == nil {
+		return nil
+	}
+	return rpcSrv.RegisterAPIs(apis)
+}
+
 func homeDir(ctx *cli.Context) (string, error) {
 	home := ctx.GlobalString(flags.Home.Name)
 	if home == "" {
@@ -0,0 +1,170 @@
+package common
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/scroll-tech/go-ethereum/rpc"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// DefaultRangeGrowAfter is the number of successful queries in a row after which an AdaptiveRange doubles.
+const DefaultRangeGrowAfter = 5
+
+// limitErrorMessages are fragments of the errors providers answer eth_getLogs with,
+// when a query covers too many blocks or matches too many logs.
+var limitErrorMessages = []string{
+	"too many results",
+	"returned more than",
+	"response size",
+	"block range too",
+	"block range is too",
+	"maximum block range",
+	"query timeout exceeded",
+}
+
+// limitExceededCode is the error code of "limit exceeded" in the EIP-1474 proposal.
+// Providers answer with it when the query is too large, but also when requests are rate limited.
+const limitExceededCode = -32005
+
+// IsLogsLimitError reports whether err means the eth_getLogs query was too large for the provider,
+// so that the same query with a smaller block range may succeed.
+// Only errors naming the block range or the result size count, see isAmbiguousLimitError for the others.
+func IsLogsLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusRequestEntityTooLarge {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, fragment := range limitErrorMessages {
+		if strings.Contains(msg, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAmbiguousLimitError reports whether err may mean the query was too large as well as rate limited.
+func isAmbiguousLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == limitExceededCode {
+		return true
+	}
+	return strings.Contains(strings.ToLower(err.Error()), "limit exceeded")
+}
+
+// AdaptiveRange is the block range of eth_getLogs queries, tuned to what the provider accepts.
+// It halves on limit errors and doubles again after DefaultRangeGrowAfter successful queries in a row,
+// never exceeding the configured range. The tuned range is persisted so that restarts start from it.
+type AdaptiveRange struct {
+	size      uint64
+	max       uint64
+	successes int
+
+	// saved is the size persisted last
+	saved   uint64
+	persist func(uint64)
+	logger  tmlog.Logger
+}
+
+// NewAdaptiveRange creates an AdaptiveRange bounded by max, starting from the persisted size if there is one.
+// persist is called with the new size when it changes, and may be nil.
+func NewAdaptiveRange(max uint64, persisted *uint64, persist func(uint64), logger tmlog.Logger) *AdaptiveRange {
+	size := max
+	if persisted != nil && *persisted > 0 && *persisted < max {
+		size = *persisted
+	}
+	if persist == nil {
+		persist = func(uint64) {}
+	}
+	if logger == nil {
+		logger = tmlog.NewNopLogger()
+	}
+	return &AdaptiveRange{
+		size:    size,
+		max:     max,
+		saved:   size,
+		persist: persist,
+		logger:  logger,
+	}
+}
+
+func (r *AdaptiveRange) Size() uint64 {
+	return r.size
+}
+
+// End returns the last block of the query starting at from, capped by to.
+func (r *AdaptiveRange) End(from, to uint64) uint64 {
+	if end := from + r.size - 1; end < to {
+		return end
+	}
+	return to
+}
+
+// Query runs the query from block from on, as far towards block to as the range allows, and returns the last block
+// it covers. The query is retried with a smaller range as long as it fails with a limit error.
+// An ambiguous error, which may be rate limiting as well, shrinks the range for the next query but is returned,
+// and the shrink is not persisted.
+func (r *AdaptiveRange) Query(from, to uint64, query func(from, end uint64) error) (uint64, error) {
+	for {
+		end := r.End(from, to)
+		err := query(from, end)
+		if err == nil {
+			if r.Succeed() {
+				r.logger.Info("growing fetch block range", "fetchBlockRange", r.size)
+				if r.size > r.saved {
+					r.save()
+				}
+			}
+			return end, nil
+		}
+		if IsLogsLimitError(err) && r.Shrink() {
+			r.logger.Info("query too large, shrinking fetch block range", "fromBlock", from, "toBlock", end, "fetchBlockRange", r.size, "err", err)
+			r.save()
+			continue
+		}
+		if isAmbiguousLimitError(err) && r.Shrink() {
+			r.logger.Info("query may be too large or rate limited, shrinking fetch block range", "fromBlock", from, "toBlock", end, "fetchBlockRange", r.size, "err", err)
+		}
+		return end, err
+	}
+}
+
+func (r *AdaptiveRange) save() {
+	r.saved = r.size
+	r.persist(r.size)
+}
+
+// Shrink halves the range after a limit error. It returns false if the range is a single block already.
+func (r *AdaptiveRange) Shrink() bool {
+	r.successes = 0
+	if r.size <= 1 {
+		return false
+	}
+	r.size /= 2
+	return true
+}
+
+// Succeed records a successful query and returns true if the range grew.
+func (r *AdaptiveRange) Succeed() bool {
+	if r.size >= r.max {
+		return false
+	}
+	r.successes++
+	if r.successes < DefaultRangeGrowAfter {
+		return false
+	}
+	r.successes = 0
+	r.size *= 2
+	if r.size > r.max {
+		r.size = r.max
+	}
+	return true
+}
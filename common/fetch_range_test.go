@@ -0,0 +1,113 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testRPCError struct {
+	msg  string
+	code int
+}
+
+func (e testRPCError) Error() string  { return e.msg }
+func (e testRPCError) ErrorCode() int { return e.code }
+
+func TestIsLogsLimitError(t *testing.T) {
+	require.True(t, IsLogsLimitError(errors.New("query returned more than 10000 results")))
+	require.True(t, IsLogsLimitError(errors.New("Log response size exceeded. You can make eth_getLogs requests with up to a 2K block range")))
+	require.True(t, IsLogsLimitError(errors.New("exceed maximum block range: 5000")))
+	require.False(t, IsLogsLimitError(errors.New("connection refused")))
+	require.False(t, IsLogsLimitError(nil))
+
+	// rate limiting is answered with the same code and message as too large queries
+	require.False(t, IsLogsLimitError(fmt.Errorf("wrapped: %w", testRPCError{msg: "whatever", code: -32005})))
+	require.False(t, IsLogsLimitError(errors.New("daily request limit exceeded")))
+	require.True(t, isAmbiguousLimitError(fmt.Errorf("wrapped: %w", testRPCError{msg: "whatever", code: -32005})))
+	require.True(t, isAmbiguousLimitError(errors.New("daily request limit exceeded")))
+	require.False(t, isAmbiguousLimitError(errors.New("connection refused")))
+}
+
+func TestAdaptiveRange(t *testing.T) {
+	r := NewAdaptiveRange(100, nil, nil, nil)
+	require.EqualValues(t, 100, r.Size())
+	require.EqualValues(t, 100, r.End(1, 1000))
+	require.EqualValues(t, 50, r.End(1, 50))
+
+	require.True(t, r.Shrink())
+	require.EqualValues(t, 50, r.Size())
+	for i := 0; i < DefaultRangeGrowAfter-1; i++ {
+		require.False(t, r.Succeed())
+	}
+	// a limit error resets the successes
+	require.True(t, r.Shrink())
+	for i := 0; i < DefaultRangeGrowAfter-1; i++ {
+		require.False(t, r.Succeed())
+	}
+	require.True(t, r.Succeed())
+	require.EqualValues(t, 50, r.Size())
+	for i := 0; i < DefaultRangeGrowAfter; i++ {
+		r.Succeed()
+	}
+	// capped by the configured range
+	require.EqualValues(t, 100, r.Size())
+	require.False(t, r.Succeed())
+
+	single := NewAdaptiveRange(1, nil, nil, nil)
+	require.False(t, single.Shrink())
+
+	persisted := uint64(20)
+	require.EqualValues(t, 20, NewAdaptiveRange(100, &persisted, nil, nil).Size())
+	// the configured range was lowered since the value was persisted
+	require.EqualValues(t, 10, NewAdaptiveRange(10, &persisted, nil, nil).Size())
+}
+
+func TestAdaptiveRange_Query(t *testing.T) {
+	var persisted []uint64
+	r := NewAdaptiveRange(100, nil, func(size uint64) { persisted = append(persisted, size) }, nil)
+
+	// a limit error shrinks the range until the query fits, and the shrink is persisted
+	end, err := r.Query(1, 1000, func(from, end uint64) error {
+		if end-from+1 > 30 {
+			return errors.New("query returned more than 10000 results")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.EqualValues(t, 25, end)
+	require.Equal(t, []uint64{50, 25}, persisted)
+
+	// an ambiguous error shrinks the range for the next query only, and is returned
+	rateLimited := testRPCError{msg: "limit exceeded", code: -32005}
+	queries := 0
+	_, err = r.Query(26, 1000, func(from, end uint64) error {
+		queries++
+		return rateLimited
+	})
+	require.ErrorIs(t, err, rateLimited)
+	require.Equal(t, 1, queries)
+	require.EqualValues(t, 12, r.Size())
+	require.Equal(t, []uint64{50, 25}, persisted)
+
+	// growing back is persisted only once beyond the size persisted last
+	for i := 0; i < DefaultRangeGrowAfter; i++ {
+		_, err = r.Query(26, 1000, func(from, end uint64) error { return nil })
+		require.NoError(t, err)
+	}
+	require.EqualValues(t, 24, r.Size())
+	require.Equal(t, []uint64{50, 25}, persisted)
+	for i := 0; i < DefaultRangeGrowAfter; i++ {
+		_, err = r.Query(26, 1000, func(from, end uint64) error { return nil })
+		require.NoError(t, err)
+	}
+	require.EqualValues(t, 48, r.Size())
+	require.Equal(t, []uint64{50, 25, 48}, persisted)
+
+	// other errors are returned as they are
+	_, err = r.Query(26, 1000, func(from, end uint64) error { return errors.New("connection refused") })
+	require.Error(t, err)
+	require.EqualValues(t, 48, r.Size())
+}
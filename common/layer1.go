@@ -7,7 +7,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
 	"math/big"
 
-	"github.com/scroll-tech/go-ethereum/ethclient"
+	"github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/rpc"
 )
 
@@ -17,7 +17,13 @@ type DeployContractBackend interface {
 	ethereum.ChainReader
 }
 
-func GetLatestConfirmedBlockNumber(ctx context.Context, l1Client *ethclient.Client, confirmations rpc.BlockNumber) (uint64, error) {
+// L1HeightReader is the part of an L1 client needed to find the latest confirmed block.
+type L1HeightReader interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+}
+
+func GetLatestConfirmedBlockNumber(ctx context.Context, l1Client L1HeightReader, confirmations rpc.BlockNumber) (uint64, error) {
 	// confirmation based on "safe" or "finalized" block tag
 	if confirmations == rpc.SafeBlockNumber || confirmations == rpc.FinalizedBlockNumber {
 		tag := big.NewInt(int64(confirmations))
@@ -0,0 +1,20 @@
+package common
+
+import "github.com/go-kit/kit/metrics"
+
+const (
+	// MetricsSubsystem is a subsystem shared by all metrics exposed by this
+	// package.
+	MetricsSubsystem = "l1"
+)
+
+//go:generate go run ../ops-morph/metricsgen -struct=Metrics
+
+type Metrics struct {
+	// Number of answers of an L1 provider which differ from the answer accepted by the quorum.
+	ProviderDisagreements metrics.Counter `metrics_labels:"method, provider"`
+	// Number of failed calls to an L1 provider.
+	ProviderErrors metrics.Counter `metrics_labels:"method, provider"`
+	// Number of L1 queries for which the providers did not reach a quorum.
+	QuorumFailures metrics.Counter `metrics_labels:"method"`
+}
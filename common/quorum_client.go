@@ -0,0 +1,370 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/ethclient"
+	"github.com/scroll-tech/go-ethereum/rlp"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// ErrNoQuorum is returned when not enough L1 providers agree on the answer of a query.
+var ErrNoQuorum = errors.New("l1 providers did not reach a quorum")
+
+var (
+	metricsOnce    sync.Once
+	quorumMetrics  *Metrics
+	notFoundDigest = common.Hash{0x01}
+)
+
+// defaultMetrics registers the quorum metrics once, all QuorumClients of the process share them.
+func defaultMetrics() *Metrics {
+	metricsOnce.Do(func() {
+		quorumMetrics = PrometheusMetrics("morphnode")
+	})
+	return quorumMetrics
+}
+
+type quorumProvider interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*eth.Header, error)
+	HeaderByHash(ctx context.Context, hash common.Hash) (*eth.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]eth.Log, error)
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*eth.Receipt, error)
+	CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+}
+
+// DefaultProviderTimeout bounds every call to an L1 provider, a hung provider cannot hold a query back longer.
+const DefaultProviderTimeout = 30 * time.Second
+
+// QuorumClient is an L1 client backed by several providers.
+// Logs, headers, receipts and contract calls are queried from every provider, and a result is accepted as soon as
+// quorum providers returned the very same one. All other calls, e.g. sending transactions, are served by the first
+// provider only, so the client can be used wherever an *ethclient.Client is used.
+type QuorumClient struct {
+	*ethclient.Client
+
+	providers []quorumProvider
+	quorum    int
+	timeout   time.Duration
+	metrics   *Metrics
+	logger    tmlog.Logger
+}
+
+// DialQuorumClient dials every address of addrs. A quorum of 0 requires a majority of the providers.
+func DialQuorumClient(addrs []string, quorum int, logger tmlog.Logger) (*QuorumClient, error) {
+	if len(addrs) == 0 {
+		return nil, errors.New("no l1 address configured")
+	}
+	clients := make([]*ethclient.Client, len(addrs))
+	providers := make([]quorumProvider, len(addrs))
+	for i, addr := range addrs {
+		client, err := ethclient.Dial(addr)
+		if err != nil {
+			return nil, fmt.Errorf("dial l1 provider %d error: %w", i, err)
+		}
+		clients[i] = client
+		providers[i] = client
+	}
+	return newQuorumClient(clients[0], providers, quorum, defaultMetrics(), logger)
+}
+
+func newQuorumClient(first *ethclient.Client, providers []quorumProvider, quorum int, metrics *Metrics, logger tmlog.Logger) (*QuorumClient, error) {
+	if quorum == 0 {
+		quorum = len(providers)/2 + 1
+	}
+	if quorum < 0 || quorum > len(providers) {
+		return nil, fmt.Errorf("invalid l1 quorum %d of %d providers", quorum, len(providers))
+	}
+	return &QuorumClient{
+		Client:    first,
+		providers: providers,
+		quorum:    quorum,
+		timeout:   DefaultProviderTimeout,
+		metrics:   metrics,
+		logger:    logger.With("module", "l1quorum"),
+	}, nil
+}
+
+type providerResult[T any] struct {
+	provider int
+	value    T
+	digest   common.Hash
+	err      error
+}
+
+// query calls every provider concurrently, each one within its own timeout, and hands the results over to collect
+// as they arrive. It stops as soon as collect returns true, the calls still running are cancelled then.
+func query[T any](ctx context.Context, c *QuorumClient, method string, call func(context.Context, quorumProvider) (T, error), collect func(providerResult[T]) bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan providerResult[T], len(c.providers))
+	for i, p := range c.providers {
+		go func(i int, p quorumProvider) {
+			callCtx, callCancel := context.WithTimeout(ctx, c.timeout)
+			defer callCancel()
+			v, err := call(callCtx, p)
+			results <- providerResult[T]{provider: i, value: v, err: err}
+		}(i, p)
+	}
+	for range c.providers {
+		select {
+		case r := <-results:
+			if r.err != nil && !errors.Is(r.err, ethereum.NotFound) {
+				c.metrics.ProviderErrors.With("method", method, "provider", strconv.Itoa(r.provider)).Add(1)
+				c.logger.Info("l1 provider call failed", "method", method, "provider", r.provider, "error", r.err)
+			}
+			if collect(r) {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// queryAll returns the results of every provider, a provider which does not answer in time returns its timeout.
+func queryAll[T any](ctx context.Context, c *QuorumClient, method string, call func(context.Context, quorumProvider) (T, error)) ([]providerResult[T], error) {
+	ret := make([]providerResult[T], 0, len(c.providers))
+	err := query(ctx, c, method, call, func(r providerResult[T]) bool {
+		ret = append(ret, r)
+		return false
+	})
+	return ret, err
+}
+
+// quorumCall accepts the result of call which quorum providers agree on, two results agree if their digests are equal.
+// Providers answering ethereum.NotFound agree with each other. It returns as soon as quorum providers agree,
+// or as soon as the providers left cannot make a quorum anymore.
+func quorumCall[T any](ctx context.Context, c *QuorumClient, method string, call func(context.Context, quorumProvider) (T, error), digest func(T) common.Hash) (T, error) {
+	var (
+		zero     T
+		results  []providerResult[T]
+		accepted *providerResult[T]
+		votes    = make(map[common.Hash]int)
+		maxVotes int
+	)
+	err := query(ctx, c, method, call, func(r providerResult[T]) bool {
+		switch {
+		case r.err == nil:
+			r.digest = digest(r.value)
+		case errors.Is(r.err, ethereum.NotFound):
+			r.digest = notFoundDigest
+		}
+		results = append(results, r)
+		if r.err == nil || r.digest == notFoundDigest {
+			votes[r.digest]++
+			if votes[r.digest] > maxVotes {
+				maxVotes = votes[r.digest]
+			}
+			if votes[r.digest] >= c.quorum {
+				accepted = &r
+				return true
+			}
+		}
+		return maxVotes+len(c.providers)-len(results) < c.quorum
+	})
+	if err != nil {
+		return zero, err
+	}
+	if accepted == nil {
+		c.metrics.QuorumFailures.With("method", method).Add(1)
+		// keep the provider errors in the chain, callers may react to them, e.g. to a too large query
+		errs := []error{fmt.Errorf("%s: %w, %d distinct answers from %d of %d providers, need %d agreeing", method, ErrNoQuorum, len(votes), len(results), len(c.providers), c.quorum)}
+		for _, r := range results {
+			if r.err != nil && r.digest != notFoundDigest {
+				errs = append(errs, fmt.Errorf("provider %d: %w", r.provider, r.err))
+			}
+		}
+		return zero, errors.Join(errs...)
+	}
+	// the providers answering after the quorum was reached are not compared
+	for _, r := range results {
+		if (r.err == nil || r.digest == notFoundDigest) && r.digest != accepted.digest {
+			c.metrics.ProviderDisagreements.With("method", method, "provider", strconv.Itoa(r.provider)).Add(1)
+			c.logger.Error("l1 provider disagrees with the quorum", "method", method, "provider", r.provider, "quorumProvider", accepted.provider)
+		}
+	}
+	if accepted.digest == notFoundDigest {
+		return zero, ethereum.NotFound
+	}
+	return accepted.value, nil
+}
+
+// BlockNumber returns the highest block number at least quorum providers have reached.
+func (c *QuorumClient) BlockNumber(ctx context.Context) (uint64, error) {
+	results, err := queryAll(ctx, c, "BlockNumber", func(ctx context.Context, p quorumProvider) (uint64, error) {
+		return p.BlockNumber(ctx)
+	})
+	if err != nil {
+		return 0, err
+	}
+	numbers := make([]uint64, 0, len(results))
+	for _, r := range results {
+		if r.err == nil {
+			numbers = append(numbers, r.value)
+		}
+	}
+	return c.quorumHeight("BlockNumber", numbers)
+}
+
+// quorumHeight returns the quorum-th highest of numbers, i.e. the highest number reached by quorum providers.
+func (c *QuorumClient) quorumHeight(method string, numbers []uint64) (uint64, error) {
+	if len(numbers) < c.quorum {
+		c.metrics.QuorumFailures.With("method", method).Add(1)
+		return 0, fmt.Errorf("%s: %w, %d of %d providers answered, need %d", method, ErrNoQuorum, len(numbers), len(c.providers), c.quorum)
+	}
+	sort.Slice(numbers, func(i, j int) bool { return numbers[i] > numbers[j] })
+	return numbers[c.quorum-1], nil
+}
+
+// HeaderByNumber returns the header quorum providers agree on.
+// A block tag (nil, latest, safe, finalized) is resolved to the highest block quorum providers have reached for that tag.
+func (c *QuorumClient) HeaderByNumber(ctx context.Context, number *big.Int) (*eth.Header, error) {
+	if number == nil || number.Sign() < 0 {
+		results, err := queryAll(ctx, c, "HeaderByNumber", func(ctx context.Context, p quorumProvider) (*eth.Header, error) {
+			return p.HeaderByNumber(ctx, number)
+		})
+		if err != nil {
+			return nil, err
+		}
+		numbers := make([]uint64, 0, len(results))
+		for _, r := range results {
+			if r.err == nil && r.value != nil {
+				numbers = append(numbers, r.value.Number.Uint64())
+			}
+		}
+		height, err := c.quorumHeight("HeaderByNumber", numbers)
+		if err != nil {
+			return nil, err
+		}
+		number = new(big.Int).SetUint64(height)
+	}
+	return quorumCall(ctx, c, "HeaderByNumber", func(ctx context.Context, p quorumProvider) (*eth.Header, error) {
+		return p.HeaderByNumber(ctx, number)
+	}, headerDigest)
+}
+
+func (c *QuorumClient) HeaderByHash(ctx context.Context, hash common.Hash) (*eth.Header, error) {
+	return quorumCall(ctx, c, "HeaderByHash", func(ctx context.Context, p quorumProvider) (*eth.Header, error) {
+		return p.HeaderByHash(ctx, hash)
+	}, headerDigest)
+}
+
+func (c *QuorumClient) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]eth.Log, error) {
+	return quorumCall(ctx, c, "FilterLogs", func(ctx context.Context, p quorumProvider) ([]eth.Log, error) {
+		return p.FilterLogs(ctx, q)
+	}, logsDigest)
+}
+
+func (c *QuorumClient) TransactionReceipt(ctx context.Context, txHash common.Hash) (*eth.Receipt, error) {
+	return quorumCall(ctx, c, "TransactionReceipt", func(ctx context.Context, p quorumProvider) (*eth.Receipt, error) {
+		return p.TransactionReceipt(ctx, txHash)
+	}, receiptDigest)
+}
+
+// CodeAt returns the code quorum providers agree on. The latest block is resolved to the highest block quorum
+// providers have reached, so that providers a block apart still agree.
+func (c *QuorumClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	blockNumber, err := c.quorumBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return quorumCall(ctx, c, "CodeAt", func(ctx context.Context, p quorumProvider) ([]byte, error) {
+		return p.CodeAt(ctx, contract, blockNumber)
+	}, bytesDigest)
+}
+
+// CallContract returns the result of the call quorum providers agree on, at the block resolved like CodeAt does.
+func (c *QuorumClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	blockNumber, err := c.quorumBlockNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return quorumCall(ctx, c, "CallContract", func(ctx context.Context, p quorumProvider) ([]byte, error) {
+		return p.CallContract(ctx, call, blockNumber)
+	}, bytesDigest)
+}
+
+func (c *QuorumClient) quorumBlockNumber(ctx context.Context, blockNumber *big.Int) (*big.Int, error) {
+	if blockNumber != nil {
+		return blockNumber, nil
+	}
+	number, err := c.BlockNumber(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetUint64(number), nil
+}
+
+func bytesDigest(data []byte) common.Hash {
+	return crypto.Keccak256Hash(data)
+}
+
+func headerDigest(header *eth.Header) common.Hash {
+	if header == nil {
+		return common.Hash{}
+	}
+	return header.Hash()
+}
+
+// loggedLog is the part of a log which identifies it, the consensus fields plus its position on L1.
+type loggedLog struct {
+	Address     common.Address
+	Topics      []common.Hash
+	Data        []byte
+	BlockNumber uint64
+	BlockHash   common.Hash
+	TxHash      common.Hash
+	TxIndex     uint
+	Index       uint
+	Removed     bool
+}
+
+func logsDigest(logs []eth.Log) common.Hash {
+	items := make([]loggedLog, len(logs))
+	for i, l := range logs {
+		items[i] = loggedLog{l.Address, l.Topics, l.Data, l.BlockNumber, l.BlockHash, l.TxHash, l.TxIndex, l.Index, l.Removed}
+	}
+	return rlpHash(items)
+}
+
+func receiptDigest(receipt *eth.Receipt) common.Hash {
+	if receipt == nil {
+		return common.Hash{}
+	}
+	logs := make([]eth.Log, len(receipt.Logs))
+	for i, l := range receipt.Logs {
+		logs[i] = *l
+	}
+	return rlpHash([]interface{}{
+		receipt.Status,
+		receipt.CumulativeGasUsed,
+		receipt.TxHash,
+		receipt.BlockHash,
+		receipt.BlockNumber,
+		receipt.TransactionIndex,
+		logsDigest(logs),
+	})
+}
+
+func rlpHash(x interface{}) common.Hash {
+	data, err := rlp.EncodeToBytes(x)
+	if err != nil {
+		panic(fmt.Errorf("failed to encode digest: %w", err))
+	}
+	return crypto.Keccak256Hash(data)
+}
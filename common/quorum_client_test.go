@@ -0,0 +1,190 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+type fakeProvider struct {
+	head      uint64
+	finalized uint64
+	headers   map[uint64]*eth.Header
+	logs      []eth.Log
+	code      []byte
+	err       error
+	// a hung provider answers when the call is cancelled only
+	hang bool
+}
+
+func newFakeProvider(head uint64, extra string) *fakeProvider {
+	p := &fakeProvider{head: head, finalized: head / 2, headers: make(map[uint64]*eth.Header)}
+	for i := uint64(0); i <= head; i++ {
+		p.headers[i] = &eth.Header{Number: new(big.Int).SetUint64(i), Difficulty: common.Big0, Extra: []byte(extra)}
+	}
+	return p
+}
+
+func (p *fakeProvider) answer(ctx context.Context) error {
+	if p.hang {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return p.err
+}
+
+func (p *fakeProvider) BlockNumber(ctx context.Context) (uint64, error) {
+	return p.head, p.answer(ctx)
+}
+
+func (p *fakeProvider) HeaderByNumber(ctx context.Context, number *big.Int) (*eth.Header, error) {
+	if err := p.answer(ctx); err != nil {
+		return nil, err
+	}
+	switch {
+	case number == nil:
+		return p.headers[p.head], nil
+	case number.Int64() == int64(rpc.FinalizedBlockNumber):
+		return p.headers[p.finalized], nil
+	}
+	header, ok := p.headers[number.Uint64()]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return header, nil
+}
+
+func (p *fakeProvider) HeaderByHash(context.Context, common.Hash) (*eth.Header, error) {
+	return nil, ethereum.NotFound
+}
+
+func (p *fakeProvider) FilterLogs(ctx context.Context, _ ethereum.FilterQuery) ([]eth.Log, error) {
+	return p.logs, p.answer(ctx)
+}
+
+func (p *fakeProvider) TransactionReceipt(context.Context, common.Hash) (*eth.Receipt, error) {
+	return nil, ethereum.NotFound
+}
+
+func (p *fakeProvider) CodeAt(ctx context.Context, _ common.Address, _ *big.Int) ([]byte, error) {
+	return p.code, p.answer(ctx)
+}
+
+func (p *fakeProvider) CallContract(ctx context.Context, _ ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if err := p.answer(ctx); err != nil {
+		return nil, err
+	}
+	if blockNumber == nil || blockNumber.Uint64() > p.head {
+		return nil, errors.New("unexpected block")
+	}
+	return append(p.code, byte(blockNumber.Uint64())), nil
+}
+
+func testQuorumClient(t *testing.T, quorum int, providers ...*fakeProvider) *QuorumClient {
+	ps := make([]quorumProvider, len(providers))
+	for i, p := range providers {
+		ps[i] = p
+	}
+	c, err := newQuorumClient(nil, ps, quorum, NopMetrics(), tmlog.NewNopLogger())
+	require.NoError(t, err)
+	return c
+}
+
+func TestQuorumClient_FilterLogs(t *testing.T) {
+	logs := []eth.Log{{Address: common.Address{1}, BlockNumber: 10, Data: []byte{1}}}
+	forged := []eth.Log{{Address: common.Address{1}, BlockNumber: 10, Data: []byte{2}}}
+	a, b, c := newFakeProvider(10, ""), newFakeProvider(10, ""), newFakeProvider(10, "")
+	a.logs, b.logs, c.logs = logs, logs, forged
+
+	client := testQuorumClient(t, 0, a, b, c)
+	ret, err := client.FilterLogs(context.Background(), ethereum.FilterQuery{})
+	require.NoError(t, err)
+	require.Equal(t, logs, ret)
+
+	// 2 of 3 disagree and the third one is down
+	b.logs, c.err = forged[:0], errors.New("down")
+	_, err = client.FilterLogs(context.Background(), ethereum.FilterQuery{})
+	require.ErrorIs(t, err, ErrNoQuorum)
+}
+
+func TestQuorumClient_Headers(t *testing.T) {
+	a, b, c := newFakeProvider(12, ""), newFakeProvider(10, ""), newFakeProvider(20, "fork")
+	client := testQuorumClient(t, 2, a, b, c)
+
+	// highest number reached by 2 providers
+	number, err := client.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 12, number)
+
+	header, err := client.HeaderByNumber(context.Background(), big.NewInt(8))
+	require.NoError(t, err)
+	require.Equal(t, a.headers[8].Hash(), header.Hash())
+
+	// finalized at 6, 5 and 10
+	header, err = client.HeaderByNumber(context.Background(), big.NewInt(int64(rpc.FinalizedBlockNumber)))
+	require.NoError(t, err)
+	require.EqualValues(t, 6, header.Number.Uint64())
+
+	// a has block 11, b does not have it yet and c is on another fork
+	_, err = client.HeaderByNumber(context.Background(), big.NewInt(11))
+	require.ErrorIs(t, err, ErrNoQuorum)
+
+	// every provider agrees the block does not exist
+	_, err = client.HeaderByNumber(context.Background(), big.NewInt(30))
+	require.ErrorIs(t, err, ethereum.NotFound)
+}
+
+func TestQuorumClient_InvalidQuorum(t *testing.T) {
+	_, err := newQuorumClient(nil, []quorumProvider{newFakeProvider(1, "")}, 2, NopMetrics(), tmlog.NewNopLogger())
+	require.Error(t, err)
+}
+
+func TestQuorumClient_HungProvider(t *testing.T) {
+	logs := []eth.Log{{Address: common.Address{1}, BlockNumber: 10, Data: []byte{1}}}
+	a, b, c := newFakeProvider(10, ""), newFakeProvider(10, ""), newFakeProvider(10, "")
+	a.logs, b.logs, c.hang = logs, logs, true
+	client := testQuorumClient(t, 0, a, b, c)
+
+	// the quorum is reached without the hung provider
+	ret, err := client.FilterLogs(context.Background(), ethereum.FilterQuery{})
+	require.NoError(t, err)
+	require.Equal(t, logs, ret)
+
+	// a and b disagree, a quorum of 3 cannot be reached whatever c answers
+	b.logs = nil
+	client.quorum = 3
+	_, err = client.FilterLogs(context.Background(), ethereum.FilterQuery{})
+	require.ErrorIs(t, err, ErrNoQuorum)
+
+	// the heights wait for every provider, the hung one until its timeout
+	client.quorum, client.timeout = 2, 50*time.Millisecond
+	start := time.Now()
+	number, err := client.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 10, number)
+	require.GreaterOrEqual(t, time.Since(start), client.timeout)
+}
+
+func TestQuorumClient_CallContract(t *testing.T) {
+	a, b, c := newFakeProvider(12, ""), newFakeProvider(11, ""), newFakeProvider(10, "")
+	a.code, b.code, c.code = []byte{1}, []byte{1}, []byte{2}
+	client := testQuorumClient(t, 2, a, b, c)
+
+	// the latest block is the one 2 providers reached
+	ret, err := client.CallContract(context.Background(), ethereum.CallMsg{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1, 11}, ret)
+
+	a.code = []byte{3}
+	_, err = client.CallContract(context.Background(), ethereum.CallMsg{}, big.NewInt(5))
+	require.ErrorIs(t, err, ErrNoQuorum)
+}
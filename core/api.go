@@ -0,0 +1,88 @@
+package node
+
+import (
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+// DebugAPI exposes the batching state of the executor under the "debug" namespace.
+type DebugAPI struct {
+	e *Executor
+}
+
+func NewDebugAPI(e *Executor) *DebugAPI {
+	return &DebugAPI{e: e}
+}
+
+// APIs returns the RPC APIs of the executor.
+func (e *Executor) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "debug",
+		Service:   NewDebugAPI(e),
+	}, {
+		Namespace: "batch",
+		Service:   NewBatchAPI(e),
+	}}
+}
+
+// ChunkClosings (debug_chunkClosings) lists the recent chunk closings with the limit each one broke,
+// the most recent last.
+func (api *DebugAPI) ChunkClosings() []ChunkClosingRecord {
+	return api.e.chunkClosings.list()
+}
+
+// CurrentChunk (debug_currentChunk) returns the usage of the open chunk against its limits,
+// null if no block is packed in the batch being built.
+func (api *DebugAPI) CurrentChunk() *types.ChunkStatus {
+	return api.e.chunkClosings.currentChunk()
+}
+
+const (
+	defaultBatchRecordLimit = 20
+	maxBatchRecordLimit     = 100
+)
+
+// BatchAPI exposes the lifecycle of the batches sealed by the executor, and the collection of their BLS signatures,
+// under the "batch" namespace.
+type BatchAPI struct {
+	e *Executor
+}
+
+func NewBatchAPI(e *Executor) *BatchAPI {
+	return &BatchAPI{e: e}
+}
+
+// Batch (batch_batch) returns the record of the batch of the given index,
+// null if the batch was not sealed by the node, or the node does not record its batches.
+func (api *BatchAPI) Batch(index uint64) *types.BatchRecord {
+	return api.e.batchRecords.get(index)
+}
+
+// Batches (batch_batches) lists the records of the batches from the batch index fromIndex on, at most limit of them.
+func (api *BatchAPI) Batches(fromIndex uint64, limit *int) []types.BatchRecord {
+	n := defaultBatchRecordLimit
+	if limit != nil && *limit > 0 {
+		n = *limit
+	}
+	if n > maxBatchRecordLimit {
+		n = maxBatchRecordLimit
+	}
+	records := api.e.batchRecords.list(fromIndex, n)
+	if records == nil {
+		records = []types.BatchRecord{}
+	}
+	return records
+}
+
+// Signatures (batch_signatures) returns the progress of the BLS signature collection of the batch hash,
+// null if no signature of it was collected recently.
+func (api *BatchAPI) Signatures(batchHash common.Hash) *BlsCollectionStatus {
+	return api.e.blsCollections.get(batchHash)
+}
+
+// SignatureCollections (batch_signatureCollections) lists the BLS signature collections of the recent batch hashes,
+// the most recent last. The batches stuck without a quorum are the ones whose signatures stop coming.
+func (api *BatchAPI) SignatureCollections() []BlsCollectionStatus {
+	return api.e.blsCollections.list()
+}
@@ -8,7 +8,7 @@ import (
 	"fmt"
 	"io"
 	"math/big"
-	"math/bits"
+	"time"
 
 	"github.com/morph-l2/node/types"
 	"github.com/scroll-tech/go-ethereum/common"
@@ -18,26 +18,33 @@ import (
 	tmtypes "github.com/tendermint/tendermint/types"
 )
 
+// blsSignatureLength is the length of the aggregated BLS signature of a batch, a G1 point in blssignatures.SignatureToBytes form.
+const blsSignatureLength = 96
+
 type BatchingCache struct {
 	parentBatchHeader types.BatchHeader
 	prevStateRoot     common.Hash
+	// version of the batch, decided by the height of its first block
+	version uint8
 
 	// accumulated batch data
 	chunks                *types.Chunks
 	totalL1MessagePopped  uint64
-	skippedBitmap         []*big.Int
+	skippedBitmap         *types.SkippedBitmap
 	postStateRoot         common.Hash
 	withdrawRoot          common.Hash
 	lastPackedBlockHeight uint64
 	// caches sealedBatchHeader according to the above accumulated batch data
 	sealedBatchHeader *types.BatchHeader
+	// sealedChunksCalldataSize is the calldata size of the chunks of the sealed batch, as encoded
+	sealedChunksCalldataSize int
 
 	currentBlockContext               []byte
 	currentTxsPayload                 []byte
 	currentTxs                        tmtypes.Txs
 	currentTxsHashes                  []common.Hash
 	totalL1MessagePoppedAfterCurBlock uint64
-	skippedBitmapAfterCurBlock        []*big.Int
+	skippedBitmapAfterCurBlock        *types.SkippedBitmap
 	currentStateRoot                  common.Hash
 	currentWithdrawRoot               common.Hash
 	currentBlockBytes                 []byte
@@ -100,13 +107,12 @@ func (e *Executor) CalculateCapWithProposalBlock(currentBlockBytes []byte, curre
 			}
 		}
 
-		// skipped L1 message bitmap, an array of 256-bit bitmaps
-		var skippedBitmap []*big.Int
-		var txsPayload []byte
+		// skipped L1 message bitmap
+		var skippedBitmap *types.SkippedBitmap
+		var blockContext, txsPayload []byte
 		var txHashes []common.Hash
 		var totalL1MessagePopped = parentBatchHeader.TotalL1MessagePopped
 		var lastHeightBeforeCurrentBatch uint64
-		var l2TxNum int
 
 		for i, blockBz := range blocks {
 			wBlock := new(types.WrappedBlock)
@@ -119,13 +125,12 @@ func (e *Executor) CalculateCapWithProposalBlock(currentBlockBytes []byte, curre
 			}
 
 			totalL1MessagePoppedBefore := totalL1MessagePopped
-			txsPayload, txHashes, totalL1MessagePopped, skippedBitmap, l2TxNum, err = ParsingTxs(transactions[i], parentBatchHeader.TotalL1MessagePopped, totalL1MessagePoppedBefore, skippedBitmap)
+			blockContext, txsPayload, txHashes, totalL1MessagePopped, skippedBitmap, err = parseBlock(wBlock, transactions[i], parentBatchHeader.TotalL1MessagePopped, totalL1MessagePoppedBefore, skippedBitmap)
 			if err != nil {
 				return 0, 0, err
 			}
-			l1TxNum := int(totalL1MessagePopped - totalL1MessagePoppedBefore) // include skipped L1 messages
-			e.logger.Info("fetched block", "block height", wBlock.Number, "involved transaction count", len(transactions[i]), "l2 tx num", l2TxNum, "l1 tx num", l1TxNum)
-			blockContext := wBlock.BlockContextBytes(l2TxNum+l1TxNum, l1TxNum)
+			e.logger.Info("fetched block", "block height", wBlock.Number, "involved transaction count", len(transactions[i]), "l1 tx num", totalL1MessagePopped-totalL1MessagePoppedBefore)
+			e.batchingCache.chunks.SetLimits(e.chunkLimits.At(wBlock.Number))
 			e.batchingCache.chunks.Append(blockContext, txsPayload, txHashes, wBlock.RowConsumption)
 			e.batchingCache.totalL1MessagePopped = totalL1MessagePopped
 			e.batchingCache.lastPackedBlockHeight = wBlock.Number
@@ -141,6 +146,7 @@ func (e *Executor) CalculateCapWithProposalBlock(currentBlockBytes []byte, curre
 		}
 
 		e.batchingCache.parentBatchHeader = parentBatchHeader
+		e.batchingCache.version = e.batchVersions.At(curHeight - uint64(len(blocks)))
 		e.batchingCache.skippedBitmap = skippedBitmap
 		header, err := e.l2Client.HeaderByNumber(context.Background(), big.NewInt(int64(lastHeightBeforeCurrentBatch)))
 		if err != nil {
@@ -167,24 +173,23 @@ func (e *Executor) CalculateCapWithProposalBlock(currentBlockBytes []byte, curre
 		return 0, 0, err
 	}
 
-	/**
-	 * commit batch which includes the fields:
-	 *  version: 1 byte
-	 *  parentBatchHeader
-	 *  chunks
-	 *  skippedL1MessageBitmap
-	 *  prevStateRoot: 32 byte
-	 *  postStateRoot: 32 byte
-	 *  withdrawRoot:  32 byte
-	 */
-	chunksSizeWithCurBlock := e.batchingCache.chunks.Size() + len(e.batchingCache.currentTxsPayload) + len(e.batchingCache.currentBlockContext)
+	e.batchingCache.chunks.SetLimits(e.chunkLimits.At(height))
+	// the size of the commitBatch calldata, with the current block packed into the batch.
+	// It is exact for V0 batches. The closed V1 chunks are compressed once and count exactly, the chunk the block
+	// goes to is not compressed for every proposal: it counts the worst case of the compression instead.
+	chunksCalldataSize := e.batchingCache.chunks.CalldataSizeWithBlock(e.batchingCache.version, e.batchingCache.currentBlockContext, e.batchingCache.currentTxsPayload, e.batchingCache.currentRowConsumption)
 	chunkNum := e.batchingCache.chunks.ChunkNum()
 	// current block will be filled in a new chunk
-	if e.batchingCache.chunks.IsChunksAppendedWithNewBlock(e.batchingCache.currentRowConsumption) {
-		chunksSizeWithCurBlock += 1
+	if e.batchingCache.chunks.IsChunksAppendedWithNewBlock(e.batchingCache.currentTxsPayload, e.batchingCache.currentRowConsumption) {
 		chunkNum += 1
 	}
-	batchSize := 97 + len(e.batchingCache.skippedBitmapAfterCurBlock)*32 + len(e.batchingCache.parentBatchHeader.Encode()) + chunksSizeWithCurBlock
+	batchSize := types.CommitBatchCalldataSize(
+		len(e.batchingCache.parentBatchHeader.Encode()),
+		chunksCalldataSize,
+		e.batchingCache.skippedBitmapAfterCurBlock.Words()*32,
+		e.maxBatchSigners(),
+		blsSignatureLength,
+	)
 	e.logger.Info("CalculateBatchSizeWithProposalBlock response", "batchSize", batchSize)
 	return int64(batchSize), int64(chunkNum), nil
 }
@@ -196,24 +201,20 @@ func (e *Executor) SealBatch() ([]byte, []byte, error) {
 		return nil, nil, errors.New("failed to seal batch. No data found in batch cache")
 	}
 
-	// compute skipped bitmap
-	skippedL1MessageBitmapBytes := make([]byte, len(e.batchingCache.skippedBitmap)*32)
-	for ii, num := range e.batchingCache.skippedBitmap {
-		bz := num.Bytes()
-		padding := 32 - len(bz)
-		copy(skippedL1MessageBitmapBytes[32*ii+padding:], bz)
+	batchHeader := sealBatchHeader(e.batchingCache.version, &e.batchingCache.parentBatchHeader, e.batchingCache.totalL1MessagePopped, e.batchingCache.chunks, e.batchingCache.skippedBitmap)
+	chunksBytes, err := e.batchingCache.chunks.EncodeWithVersion(batchHeader.Version)
+	if err != nil {
+		return nil, nil, err
 	}
-
-	batchHeader := types.BatchHeader{
-		Version:                0,
-		BatchIndex:             e.batchingCache.parentBatchHeader.BatchIndex + 1,
-		L1MessagePopped:        e.batchingCache.totalL1MessagePopped - e.batchingCache.parentBatchHeader.TotalL1MessagePopped,
-		TotalL1MessagePopped:   e.batchingCache.totalL1MessagePopped,
-		DataHash:               e.batchingCache.chunks.DataHash(),
-		ParentBatchHash:        e.batchingCache.parentBatchHeader.Hash(),
-		SkippedL1MessageBitmap: skippedL1MessageBitmapBytes,
+	if e.batchCheck {
+		if err = checkSealedBatch(&batchHeader, &e.batchingCache.parentBatchHeader, e.batchingCache.chunks, chunksBytes); err != nil {
+			e.metrics.SealedBatchMismatch.Add(1)
+			e.logger.Error("refuse to seal the batch", "batchIndex", batchHeader.BatchIndex, "error", err)
+			return nil, nil, err
+		}
 	}
 	e.batchingCache.sealedBatchHeader = &batchHeader
+	e.batchingCache.sealedChunksCalldataSize = types.EncodedChunksCalldataSize(chunksBytes)
 	batchHash := batchHeader.Hash()
 	e.logger.Info("Sealed batch header", "batchHash", batchHash.Hex())
 	e.logger.Info(fmt.Sprintf("===batchIndex: %d \n===L1MessagePopped: %d \n===TotalL1MessagePopped: %d \n===dataHash: %x \n===blockNum: %d \n===ParentBatchHash: %x \n===SkippedL1MessageBitmap: %x \n",
@@ -224,13 +225,31 @@ func (e *Executor) SealBatch() ([]byte, []byte, error) {
 		e.batchingCache.chunks.BlockNum(),
 		batchHeader.ParentBatchHash,
 		batchHeader.SkippedL1MessageBitmap))
-	chunksBytes, _ := e.batchingCache.chunks.Encode()
 	for i, chunk := range chunksBytes {
 		e.logger.Info(fmt.Sprintf("===chunk%d: %x \n", i, chunk))
 	}
+	e.batchRecords.sealed(types.BatchRecord{
+		Index:      batchHeader.BatchIndex,
+		Hash:       batchHash,
+		FirstBlock: e.batchingCache.lastPackedBlockHeight - uint64(e.batchingCache.chunks.BlockNum()) + 1,
+		LastBlock:  e.batchingCache.lastPackedBlockHeight,
+		Size:       e.sealedBatchSize(e.maxBatchSigners()),
+		SealedAt:   uint64(time.Now().Unix()),
+	})
 	return batchHash[:], batchHeader.Encode(), nil
 }
 
+// sealedBatchSize returns the exact length of the commitBatch calldata of the sealed batch, signed by signerNum sequencers.
+func (e *Executor) sealedBatchSize(signerNum int) uint64 {
+	return uint64(types.CommitBatchCalldataSize(
+		len(e.batchingCache.parentBatchHeader.Encode()),
+		e.batchingCache.sealedChunksCalldataSize,
+		len(e.batchingCache.sealedBatchHeader.SkippedL1MessageBitmap),
+		signerNum,
+		blsSignatureLength,
+	))
+}
+
 // CommitBatch commit the sealed batch. It does nothing if no batch header is sealed.
 // It is supposed to be called when the current block is confirmed.
 func (e *Executor) CommitBatch(currentBlockBytes []byte, currentTxs tmtypes.Txs, blsDatas []l2node.BlsData) error {
@@ -248,7 +267,7 @@ func (e *Executor) CommitBatch(currentBlockBytes []byte, currentTxs tmtypes.Txs,
 		}
 	}
 
-	chunksBytes, err := e.batchingCache.chunks.Encode()
+	chunksBytes, err := e.batchingCache.chunks.EncodeWithVersion(e.batchingCache.sealedBatchHeader.Version)
 	if err != nil {
 		return err
 	}
@@ -258,27 +277,25 @@ func (e *Executor) CommitBatch(currentBlockBytes []byte, currentTxs tmtypes.Txs,
 		return err
 	}
 
+	batchIndex, batchHash := e.batchingCache.sealedBatchHeader.BatchIndex, e.batchingCache.sealedBatchHeader.Hash()
 	var batchSigs []eth.BatchSignature
 	if !e.devSequencer {
-		batchSigs, err = e.ConvertBlsDatas(blsDatas, curHeight)
-		if err != nil {
-			return err
-		}
+		batchSigs = e.collectBlsSignatures(curHeight, batchHash, blsDatas)
 	}
 
-	if err = e.l2Client.CommitBatch(context.Background(), &eth.RollupBatch{
-		Version:                0,
-		Index:                  e.batchingCache.parentBatchHeader.BatchIndex + 1,
-		Hash:                   e.batchingCache.sealedBatchHeader.Hash(),
-		ParentBatchHeader:      e.batchingCache.parentBatchHeader.Encode(),
-		Chunks:                 chunksBytes,
-		SkippedL1MessageBitmap: e.batchingCache.sealedBatchHeader.SkippedL1MessageBitmap,
-		PrevStateRoot:          e.batchingCache.prevStateRoot,
-		PostStateRoot:          e.batchingCache.postStateRoot,
-		WithdrawRoot:           e.batchingCache.withdrawRoot,
-	}, batchSigs); err != nil {
+	if len(batchSigs) > 0 {
+		size := e.sealedBatchSize(len(batchSigs))
+		e.batchRecords.advance(batchIndex, batchHash, types.BatchSigned, func(record *types.BatchRecord) {
+			record.Signatures = uint64(len(batchSigs))
+			record.Size = size
+		})
+	}
+
+	rollupBatch := newRollupBatch(e.batchingCache.sealedBatchHeader, &e.batchingCache.parentBatchHeader, chunksBytes, e.batchingCache.prevStateRoot, e.batchingCache.postStateRoot, e.batchingCache.withdrawRoot)
+	if err = e.l2Client.CommitBatch(context.Background(), rollupBatch, batchSigs); err != nil {
 		return err
 	}
+	e.batchRecords.advance(batchIndex, batchHash, types.BatchSubmitted, nil)
 
 	// commit sealed batch header; move current block into the next batch
 	e.batchingCache.parentBatchHeader = *e.batchingCache.sealedBatchHeader
@@ -294,8 +311,11 @@ func (e *Executor) CommitBatch(currentBlockBytes []byte, currentTxs tmtypes.Txs,
 	e.batchingCache.postStateRoot = e.batchingCache.currentStateRoot
 	e.batchingCache.withdrawRoot = e.batchingCache.currentWithdrawRoot
 	e.batchingCache.lastPackedBlockHeight = curHeight
+	e.batchingCache.version = e.batchVersions.At(curHeight)
 	e.batchingCache.chunks = types.NewChunks()
+	e.batchingCache.chunks.SetLimits(e.chunkLimits.At(curHeight))
 	e.batchingCache.chunks.Append(e.batchingCache.currentBlockContext, e.batchingCache.currentTxsPayload, e.batchingCache.currentTxsHashes, e.batchingCache.currentRowConsumption)
+	e.chunkClosings.setCurrent(e.batchingCache.chunks.LastChunkStatus())
 	e.batchingCache.ClearCurrent()
 
 	e.logger.Info("Committed batch")
@@ -306,12 +326,12 @@ func (e *Executor) AppendBlsData(height int64, batchHash []byte, data l2node.Bls
 	if len(batchHash) != 32 {
 		return fmt.Errorf("wrong batchHash length. expected: 32, actual: %d", len(batchHash))
 	}
-	blsSig, err := e.ConvertBlsData(data, uint64(height))
+	var hash common.Hash
+	copy(hash[:], batchHash)
+	blsSig, err := e.collectBlsSignature(uint64(height), hash, data)
 	if err != nil {
 		return err
 	}
-	var hash common.Hash
-	copy(hash[:], batchHash)
 	return e.l2Client.AppendBlsSignature(context.Background(), hash, *blsSig)
 }
 
@@ -339,8 +359,13 @@ func (e *Executor) PackCurrentBlock(currentBlockBytes []byte, currentTxs tmtypes
 	}
 	if e.batchingCache.chunks == nil {
 		e.batchingCache.chunks = types.NewChunks()
+		e.batchingCache.version = e.batchVersions.At(curHeight)
 	}
-	e.batchingCache.chunks.Append(e.batchingCache.currentBlockContext, e.batchingCache.currentTxsPayload, e.batchingCache.currentTxsHashes, e.batchingCache.currentRowConsumption)
+	e.batchingCache.chunks.SetLimits(e.chunkLimits.At(curHeight))
+	if closing := e.batchingCache.chunks.Append(e.batchingCache.currentBlockContext, e.batchingCache.currentTxsPayload, e.batchingCache.currentTxsHashes, e.batchingCache.currentRowConsumption); closing != nil {
+		e.recordChunkClosing(curHeight, closing)
+	}
+	e.chunkClosings.setCurrent(e.batchingCache.chunks.LastChunkStatus())
 	e.batchingCache.skippedBitmap = e.batchingCache.skippedBitmapAfterCurBlock
 	e.batchingCache.totalL1MessagePopped = e.batchingCache.totalL1MessagePoppedAfterCurBlock
 	e.batchingCache.withdrawRoot = e.batchingCache.currentWithdrawRoot
@@ -353,16 +378,14 @@ func (e *Executor) PackCurrentBlock(currentBlockBytes []byte, currentTxs tmtypes
 }
 
 func (e *Executor) setCurrentBlock(currentBlockBytes []byte, currentTxs tmtypes.Txs) error {
-	currentTxsPayload, currentTxsHashes, totalL1MessagePopped, skippedBitmap, l2TxNum, err := ParsingTxs(currentTxs, e.batchingCache.parentBatchHeader.TotalL1MessagePopped, e.batchingCache.totalL1MessagePopped, e.batchingCache.skippedBitmap)
-	if err != nil {
+	var curBlock = new(types.WrappedBlock)
+	if err := curBlock.UnmarshalBinary(currentBlockBytes); err != nil {
 		return err
 	}
-	var curBlock = new(types.WrappedBlock)
-	if err = curBlock.UnmarshalBinary(currentBlockBytes); err != nil {
+	currentBlockContext, currentTxsPayload, currentTxsHashes, totalL1MessagePopped, skippedBitmap, err := parseBlock(curBlock, currentTxs, e.batchingCache.parentBatchHeader.TotalL1MessagePopped, e.batchingCache.totalL1MessagePopped, e.batchingCache.skippedBitmap)
+	if err != nil {
 		return err
 	}
-	l1TxNum := int(totalL1MessagePopped - e.batchingCache.totalL1MessagePopped)
-	currentBlockContext := curBlock.BlockContextBytes(l2TxNum+l1TxNum, l1TxNum)
 	e.batchingCache.currentBlockContext = currentBlockContext
 	e.batchingCache.currentTxsPayload = currentTxsPayload
 	e.batchingCache.currentTxs = currentTxs
@@ -385,16 +408,15 @@ func (e *Executor) BatchHash(batchHeaderBytes []byte) ([]byte, error) {
 	return batchHeader.Hash().Bytes(), nil
 }
 
-func ParsingTxs(transactions tmtypes.Txs, totalL1MessagePoppedBeforeTheBatch, totalL1MessagePoppedBefore uint64, skippedBitmapBefore []*big.Int) (txsPayload []byte, txHashes []common.Hash, totalL1MessagePopped uint64, skippedBitmap []*big.Int, l2TxNum int, err error) {
+// ParsingTxs parses the transactions of a block of a batch. L1 messages left out between the included ones are
+// marked in the bitmap of the block, which is merged into a copy of skippedBitmapBefore: the bitmap of the batch so far is not modified.
+func ParsingTxs(transactions tmtypes.Txs, totalL1MessagePoppedBeforeTheBatch, totalL1MessagePoppedBefore uint64, skippedBitmapBefore *types.SkippedBitmap) (txsPayload []byte, txHashes []common.Hash, totalL1MessagePopped uint64, skippedBitmap *types.SkippedBitmap, l2TxNum int, err error) {
 	// the first queue index that belongs to this batch
 	baseIndex := totalL1MessagePoppedBeforeTheBatch
 	// the next queue index that we need to process
 	nextIndex := totalL1MessagePoppedBefore
 
-	skippedBitmap = make([]*big.Int, len(skippedBitmapBefore))
-	for i, bm := range skippedBitmapBefore {
-		skippedBitmap[i] = new(big.Int).SetBytes(bm.Bytes())
-	}
+	blockBitmap := new(types.SkippedBitmap)
 
 	for i, txBz := range transactions {
 		var tx eth.Transaction
@@ -413,21 +435,11 @@ func ParsingTxs(transactions tmtypes.Txs, totalL1MessagePoppedBeforeTheBatch, to
 
 			// mark skipped messages
 			for skippedIndex := nextIndex; skippedIndex < currentIndex; skippedIndex++ {
-				quo := int((skippedIndex - baseIndex) / 256)
-				rem := int((skippedIndex - baseIndex) % 256)
-				for len(skippedBitmap) <= quo {
-					bitmap := big.NewInt(0)
-					skippedBitmap = append(skippedBitmap, bitmap)
-				}
-				skippedBitmap[quo].SetBit(skippedBitmap[quo], rem, 1)
+				blockBitmap.Set(skippedIndex - baseIndex)
 			}
 
 			// process included message
-			quo := int((currentIndex - baseIndex) / 256)
-			for len(skippedBitmap) <= quo {
-				bitmap := big.NewInt(0)
-				skippedBitmap = append(skippedBitmap, bitmap)
-			}
+			blockBitmap.Extend(currentIndex - baseIndex)
 
 			nextIndex = currentIndex + 1
 			continue
@@ -441,6 +453,8 @@ func ParsingTxs(transactions tmtypes.Txs, totalL1MessagePoppedBeforeTheBatch, to
 	}
 
 	totalL1MessagePopped = nextIndex
+	skippedBitmap = skippedBitmapBefore.Copy()
+	skippedBitmap.Merge(blockBitmap)
 	return
 }
 
@@ -467,6 +481,46 @@ func DecodeTxsPayload(reader *bytes.Reader, txNum int) ([]*eth.Transaction, erro
 	return txs, nil
 }
 
+// parseBlock parses the transactions of a block of the batch following the L1 messages popped before the batch and before the block.
+// It returns the block context and the payload added to the chunks, along with the L1 messages popped and the bitmap after the block.
+func parseBlock(wBlock *types.WrappedBlock, txs tmtypes.Txs, totalL1MessagePoppedBeforeTheBatch, totalL1MessagePoppedBefore uint64, skippedBitmapBefore *types.SkippedBitmap) (blockContext, txsPayload []byte, txHashes []common.Hash, totalL1MessagePopped uint64, skippedBitmap *types.SkippedBitmap, err error) {
+	txsPayload, txHashes, totalL1MessagePopped, skippedBitmap, l2TxNum, err := ParsingTxs(txs, totalL1MessagePoppedBeforeTheBatch, totalL1MessagePoppedBefore, skippedBitmapBefore)
+	if err != nil {
+		return nil, nil, nil, 0, nil, err
+	}
+	l1TxNum := int(totalL1MessagePopped - totalL1MessagePoppedBefore) // include skipped L1 messages
+	blockContext = wBlock.BlockContextBytes(l2TxNum+l1TxNum, l1TxNum)
+	return
+}
+
+// sealBatchHeader returns the header of the batch of the chunks following the parent batch.
+func sealBatchHeader(version uint8, parentBatchHeader *types.BatchHeader, totalL1MessagePopped uint64, chunks *types.Chunks, skippedBitmap *types.SkippedBitmap) types.BatchHeader {
+	return types.BatchHeader{
+		Version:                version,
+		BatchIndex:             parentBatchHeader.BatchIndex + 1,
+		L1MessagePopped:        totalL1MessagePopped - parentBatchHeader.TotalL1MessagePopped,
+		TotalL1MessagePopped:   totalL1MessagePopped,
+		DataHash:               chunks.DataHash(),
+		ParentBatchHash:        parentBatchHeader.Hash(),
+		SkippedL1MessageBitmap: skippedBitmap.Encode(),
+	}
+}
+
+// newRollupBatch returns the batch committed to L2 geth for the sealed batch header.
+func newRollupBatch(batchHeader, parentBatchHeader *types.BatchHeader, chunksBytes [][]byte, prevStateRoot, postStateRoot, withdrawRoot common.Hash) *eth.RollupBatch {
+	return &eth.RollupBatch{
+		Version:                uint(batchHeader.Version),
+		Index:                  parentBatchHeader.BatchIndex + 1,
+		Hash:                   batchHeader.Hash(),
+		ParentBatchHeader:      parentBatchHeader.Encode(),
+		Chunks:                 chunksBytes,
+		SkippedL1MessageBitmap: batchHeader.SkippedL1MessageBitmap,
+		PrevStateRoot:          prevStateRoot,
+		PostStateRoot:          postStateRoot,
+		WithdrawRoot:           withdrawRoot,
+	}
+}
+
 func GenesisBatchHeader(genesisHeader *eth.Header) (types.BatchHeader, error) {
 	wb := types.WrappedBlock{
 		ParentHash:  genesisHeader.ParentHash,
@@ -505,18 +559,14 @@ func (e *Executor) ConvertBlsDatas(blsDatas []l2node.BlsData, height uint64) (re
 }
 
 func (e *Executor) ConvertBlsData(blsData l2node.BlsData, height uint64) (*eth.BatchSignature, error) {
-	seqKey := e.getBlsPubKeyByTmKey(blsData.Signer, &height)
+	// the signer index is the one in the sequencer set of the height, the signature carries the version of that set
+	seqKey, set := e.getBlsPubKeyByTmKey(blsData.Signer, &height)
 	if seqKey == nil {
 		return nil, fmt.Errorf("found invalid validator: %x", blsData.Signer)
 	}
 
-	var curVersion uint64
-	if e.currentSequencerSet != nil {
-		curVersion = e.currentSequencerSet.version
-	}
-
 	bs := eth.BatchSignature{
-		Version:      curVersion,
+		Version:      set.version,
 		Signer:       seqKey.index,
 		SignerPubKey: new(bls12381.G2).EncodePoint(seqKey.blsPubKey.Key),
 		Signature:    blsData.Signature,
@@ -524,6 +574,14 @@ func (e *Executor) ConvertBlsData(blsData l2node.BlsData, height uint64) (*eth.B
 	return &bs, nil
 }
 
+// maxBatchSigners is the number of signers of a batch signed by all the current sequencers.
+func (e *Executor) maxBatchSigners() int {
+	if e.currentSequencerSet == nil {
+		return 0
+	}
+	return len(e.currentSequencerSet.sequencerSet)
+}
+
 func heightFromBCBytes(blockBytes []byte) (uint64, error) {
 	var curBlock = new(types.WrappedBlock)
 	if err := curBlock.UnmarshalBinary(blockBytes); err != nil {
@@ -531,10 +589,3 @@ func heightFromBCBytes(blockBytes []byte) (uint64, error) {
 	}
 	return curBlock.Number, nil
 }
-
-func skippedNums(skippedBitMap []*big.Int) (count int) {
-	for _, s := range skippedBitMap {
-		count += bits.OnesCount64(s.Uint64())
-	}
-	return
-}
@@ -0,0 +1,61 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// BuildBatch builds the batch of consecutive L2 blocks following the parent batch, the way the executor packs,
+// seals and commits it. prevStateRoot is the state root before the first block.
+// It lets a batch be reproduced offline from the blocks of an L2 geth.
+func BuildBatch(versions *types.BatchVersionSchedule, chunkLimits *types.ChunkLimitsSchedule, parentBatchHeader types.BatchHeader, prevStateRoot common.Hash, blocks []*eth.BlockWithRowConsumption) (*types.BatchHeader, *eth.RollupBatch, error) {
+	if len(blocks) == 0 {
+		return nil, nil, errors.New("no block to build the batch of")
+	}
+	chunks := types.NewChunks()
+	totalL1MessagePopped := parentBatchHeader.TotalL1MessagePopped
+	var skippedBitmap *types.SkippedBitmap
+	for i, block := range blocks {
+		if i > 0 && block.NumberU64() != blocks[i-1].NumberU64()+1 {
+			return nil, nil, fmt.Errorf("block %d does not follow block %d", block.NumberU64(), blocks[i-1].NumberU64())
+		}
+		if block.RowConsumption == nil {
+			return nil, nil, fmt.Errorf("block %d has no row consumption", block.NumberU64())
+		}
+		txs := make(tmtypes.Txs, len(block.Transactions()))
+		for j, tx := range block.Transactions() {
+			txBz, err := tx.MarshalBinary()
+			if err != nil {
+				return nil, nil, err
+			}
+			txs[j] = txBz
+		}
+		wBlock := &types.WrappedBlock{
+			Number:    block.NumberU64(),
+			GasLimit:  block.GasLimit(),
+			BaseFee:   block.BaseFee(),
+			Timestamp: block.Time(),
+		}
+		blockContext, txsPayload, txHashes, totalL1MessagePoppedAfter, skippedBitmapAfter, err := parseBlock(wBlock, txs, parentBatchHeader.TotalL1MessagePopped, totalL1MessagePopped, skippedBitmap)
+		if err != nil {
+			return nil, nil, fmt.Errorf("block %d: %w", block.NumberU64(), err)
+		}
+		chunks.SetLimits(chunkLimits.At(block.NumberU64()))
+		chunks.Append(blockContext, txsPayload, txHashes, *block.RowConsumption)
+		totalL1MessagePopped, skippedBitmap = totalL1MessagePoppedAfter, skippedBitmapAfter
+	}
+
+	version := versions.At(blocks[0].NumberU64())
+	batchHeader := sealBatchHeader(version, &parentBatchHeader, totalL1MessagePopped, chunks, skippedBitmap)
+	chunksBytes, err := chunks.EncodeWithVersion(version)
+	if err != nil {
+		return nil, nil, err
+	}
+	lastBlock := blocks[len(blocks)-1]
+	return &batchHeader, newRollupBatch(&batchHeader, &parentBatchHeader, chunksBytes, prevStateRoot, lastBlock.Root(), lastBlock.WithdrawTrieRoot), nil
+}
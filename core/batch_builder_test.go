@@ -0,0 +1,89 @@
+package node
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func testBuilderBlock(t *testing.T, number uint64, l1Txs tmtypes.Txs, l2TxNum int, rows uint64) *eth.BlockWithRowConsumption {
+	var txs eth.Transactions
+	for _, txBz := range l1Txs {
+		var tx eth.Transaction
+		require.NoError(t, tx.UnmarshalBinary(txBz))
+		txs = append(txs, &tx)
+	}
+	for i := 0; i < l2TxNum; i++ {
+		to := common.BigToAddress(big.NewInt(int64(i + 1)))
+		txs = append(txs, eth.NewTx(&eth.LegacyTx{Nonce: number*10 + uint64(i), To: &to, Gas: 21000, GasPrice: big.NewInt(1)}))
+	}
+	header := &eth.Header{
+		Number:   new(big.Int).SetUint64(number),
+		Time:     1700000000 + number,
+		GasLimit: 30_000_000,
+		BaseFee:  big.NewInt(1000),
+		Root:     common.BigToHash(new(big.Int).SetUint64(number)),
+	}
+	return &eth.BlockWithRowConsumption{
+		Block:            eth.NewBlockWithHeader(header).WithBody(txs, nil),
+		RowConsumption:   &eth.RowConsumption{{Name: "a", RowNumber: rows}},
+		WithdrawTrieRoot: common.BigToHash(new(big.Int).SetUint64(number + 100)),
+	}
+}
+
+func TestBuildBatch(t *testing.T) {
+	parentBatchHeader := types.BatchHeader{BatchIndex: 4, TotalL1MessagePopped: 0, SkippedL1MessageBitmap: []byte{}}
+	chunkLimits, err := types.NewChunkLimitsSchedule(types.ChunkLimitsUpgrade{Height: 12, ChunkLimits: types.ChunkLimits{MaxBlocks: 2, MaxRowNumber: 100}})
+	require.NoError(t, err)
+	blocks := []*eth.BlockWithRowConsumption{
+		testBuilderBlock(t, 10, l1MessageTxs(t, 0, 2), 1, 90), // queue index 1 is skipped
+		testBuilderBlock(t, 11, nil, 2, 20),                   // the default limits still apply
+		testBuilderBlock(t, 12, l1MessageTxs(t, 3), 0, 1),
+		testBuilderBlock(t, 13, nil, 1, 1),
+		testBuilderBlock(t, 14, nil, 1, 1), // max blocks
+	}
+
+	versions, err := types.NewBatchVersionSchedule(types.BatchVersionUpgrade{Height: 10, Version: types.BatchVersionV1})
+	require.NoError(t, err)
+
+	batchHeader, rollupBatch, err := BuildBatch(versions, chunkLimits, parentBatchHeader, common.HexToHash("0x09"), blocks)
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), batchHeader.BatchIndex)
+	require.Equal(t, uint64(4), batchHeader.L1MessagePopped)
+	require.Equal(t, uint64(4), batchHeader.TotalL1MessagePopped)
+	require.Equal(t, parentBatchHeader.Hash(), batchHeader.ParentBatchHash)
+	skippedBitmap, err := types.DecodeSkippedBitmap(batchHeader.SkippedL1MessageBitmap)
+	require.NoError(t, err)
+	require.Equal(t, 1, skippedBitmap.Count())
+	require.True(t, skippedBitmap.Test(1))
+
+	require.Equal(t, uint(types.BatchVersionV1), rollupBatch.Version)
+	require.Equal(t, uint64(5), rollupBatch.Index)
+	require.Equal(t, batchHeader.Hash(), rollupBatch.Hash)
+	require.Equal(t, parentBatchHeader.Encode(), rollupBatch.ParentBatchHeader)
+	require.Equal(t, common.HexToHash("0x09"), rollupBatch.PrevStateRoot)
+	require.Equal(t, blocks[4].Root(), rollupBatch.PostStateRoot)
+	require.Equal(t, blocks[4].WithdrawTrieRoot, rollupBatch.WithdrawRoot)
+	require.Len(t, rollupBatch.Chunks, 3)
+	chunk, err := types.DecodeChunk(types.BatchVersionV1, rollupBatch.Chunks[1])
+	require.NoError(t, err)
+	require.Equal(t, 2, chunk.BlockNum())
+
+	// the version is the one of the first block of the batch
+	later, err := types.NewBatchVersionSchedule(types.BatchVersionUpgrade{Height: 11, Version: types.BatchVersionV1})
+	require.NoError(t, err)
+	batchHeader, rollupBatch, err = BuildBatch(later, chunkLimits, parentBatchHeader, common.HexToHash("0x09"), blocks)
+	require.NoError(t, err)
+	require.Equal(t, types.BatchVersionV0, batchHeader.Version)
+	require.Equal(t, uint(types.BatchVersionV0), rollupBatch.Version)
+
+	_, _, err = BuildBatch(versions, chunkLimits, parentBatchHeader, common.Hash{}, []*eth.BlockWithRowConsumption{blocks[0], blocks[2]})
+	require.Error(t, err)
+	_, _, err = BuildBatch(versions, chunkLimits, parentBatchHeader, common.Hash{}, nil)
+	require.Error(t, err)
+}
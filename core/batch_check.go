@@ -0,0 +1,101 @@
+package node
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/crypto"
+)
+
+var ErrSealedBatchMismatch = errors.New("sealed batch does not decode back into the packed blocks")
+
+// checkSealedBatch decodes the encoded chunks of the sealed batch the way the derivation does, and checks them against
+// the packed chunks: the block contexts, the L2 transactions and the L1 messages popped. It recomputes the data hash
+// from the decoded chunks, with the hashes of the included L1 messages taken from the packed chunks,
+// as L1 messages are not part of the batch data.
+func checkSealedBatch(batchHeader, parentBatchHeader *types.BatchHeader, chunks *types.Chunks, chunksBytes [][]byte) error {
+	if len(chunksBytes) != chunks.ChunkNum() {
+		return fmt.Errorf("%w: %d encoded chunks, %d packed", ErrSealedBatchMismatch, len(chunksBytes), chunks.ChunkNum())
+	}
+	skippedBitmap, err := types.DecodeSkippedBitmap(batchHeader.SkippedL1MessageBitmap)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSealedBatchMismatch, err)
+	}
+	queueIndex := parentBatchHeader.TotalL1MessagePopped
+	var chunkHashes []byte
+	for i, chunkBytes := range chunksBytes {
+		packed := chunks.Chunk(i)
+		decoded, err := types.DecodeChunk(batchHeader.Version, chunkBytes)
+		if err != nil {
+			return fmt.Errorf("%w: chunk %d: %v", ErrSealedBatchMismatch, i, err)
+		}
+		if decoded.BlockNum() != packed.BlockNum() {
+			return fmt.Errorf("%w: chunk %d has %d blocks, %d packed", ErrSealedBatchMismatch, i, decoded.BlockNum(), packed.BlockNum())
+		}
+
+		reader := bytes.NewReader(decoded.TxsPayload())
+		packedHashes := packed.TxHashes()
+		var txHashes []common.Hash
+		for j := 0; j < decoded.BlockNum(); j++ {
+			blockContext := decoded.BlockContext()[j*60 : j*60+60]
+			if !bytes.Equal(blockContext, packed.BlockContext()[j*60:j*60+60]) {
+				return fmt.Errorf("%w: chunk %d: block context %d differs", ErrSealedBatchMismatch, i, j)
+			}
+			txsNum := binary.BigEndian.Uint16(blockContext[56:58])
+			l1MsgNum := binary.BigEndian.Uint16(blockContext[58:60])
+			if txsNum < l1MsgNum {
+				return fmt.Errorf("%w: chunk %d: block %d has %d txs and %d L1 messages", ErrSealedBatchMismatch, i, j, txsNum, l1MsgNum)
+			}
+
+			// the included L1 messages come first, the skipped ones have no transaction
+			for k := uint64(0); k < uint64(l1MsgNum); k++ {
+				if skippedBitmap.Test(queueIndex + k - parentBatchHeader.TotalL1MessagePopped) {
+					continue
+				}
+				if len(txHashes) >= len(packedHashes) {
+					return fmt.Errorf("%w: chunk %d: block %d misses L1 message %d", ErrSealedBatchMismatch, i, j, queueIndex+k)
+				}
+				txHashes = append(txHashes, packedHashes[len(txHashes)])
+			}
+			queueIndex += uint64(l1MsgNum)
+
+			l2TxNum := int(txsNum - l1MsgNum)
+			txs, err := DecodeTxsPayload(reader, l2TxNum)
+			if err != nil {
+				return fmt.Errorf("%w: chunk %d: block %d: %v", ErrSealedBatchMismatch, i, j, err)
+			}
+			if len(txs) != l2TxNum {
+				return fmt.Errorf("%w: chunk %d: block %d decodes %d L2 txs, expected %d", ErrSealedBatchMismatch, i, j, len(txs), l2TxNum)
+			}
+			for _, tx := range txs {
+				if len(txHashes) >= len(packedHashes) || packedHashes[len(txHashes)] != tx.Hash() {
+					return fmt.Errorf("%w: chunk %d: block %d: L2 tx %s was not packed there", ErrSealedBatchMismatch, i, j, tx.Hash().Hex())
+				}
+				txHashes = append(txHashes, tx.Hash())
+			}
+		}
+		if reader.Len() != 0 {
+			return fmt.Errorf("%w: chunk %d has %d trailing payload bytes", ErrSealedBatchMismatch, i, reader.Len())
+		}
+		if len(txHashes) != len(packedHashes) {
+			return fmt.Errorf("%w: chunk %d decodes %d txs, %d packed", ErrSealedBatchMismatch, i, len(txHashes), len(packedHashes))
+		}
+
+		chunk := types.NewChunk(decoded.BlockContext(), nil, txHashes, nil)
+		chunk.ResetBlockNum(decoded.BlockNum())
+		chunkHash := chunk.Hash()
+		chunkHashes = append(chunkHashes, chunkHash[:]...)
+	}
+
+	if queueIndex != batchHeader.TotalL1MessagePopped {
+		return fmt.Errorf("%w: the chunks pop L1 messages up to %d, the header up to %d", ErrSealedBatchMismatch, queueIndex, batchHeader.TotalL1MessagePopped)
+	}
+	if dataHash := crypto.Keccak256Hash(chunkHashes); dataHash != batchHeader.DataHash {
+		return fmt.Errorf("%w: data hash %s, sealed %s", ErrSealedBatchMismatch, dataHash.Hex(), batchHeader.DataHash.Hex())
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/morph-l2/node/types"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+// packTestBatch packs the blocks into chunks and seals them, the way the executor does.
+func packTestBatch(t *testing.T, version uint8, parentBatchHeader types.BatchHeader, blocks []*eth.BlockWithRowConsumption) (types.BatchHeader, *types.Chunks) {
+	chunks := types.NewChunks()
+	chunks.SetLimits(types.ChunkLimits{MaxBlocks: 2, MaxRowNumber: types.NormalizedRowLimit})
+	totalL1MessagePopped := parentBatchHeader.TotalL1MessagePopped
+	var skippedBitmap *types.SkippedBitmap
+	for _, block := range blocks {
+		var txs tmtypes.Txs
+		for _, tx := range block.Transactions() {
+			txBz, err := tx.MarshalBinary()
+			require.NoError(t, err)
+			txs = append(txs, txBz)
+		}
+		wBlock := &types.WrappedBlock{Number: block.NumberU64(), GasLimit: block.GasLimit(), BaseFee: block.BaseFee(), Timestamp: block.Time()}
+		blockContext, txsPayload, txHashes, totalAfter, bitmapAfter, err := parseBlock(wBlock, txs, parentBatchHeader.TotalL1MessagePopped, totalL1MessagePopped, skippedBitmap)
+		require.NoError(t, err)
+		chunks.Append(blockContext, txsPayload, txHashes, *block.RowConsumption)
+		totalL1MessagePopped, skippedBitmap = totalAfter, bitmapAfter
+	}
+	return sealBatchHeader(version, &parentBatchHeader, totalL1MessagePopped, chunks, skippedBitmap), chunks
+}
+
+func TestCheckSealedBatch(t *testing.T) {
+	parentBatchHeader := types.BatchHeader{BatchIndex: 2, TotalL1MessagePopped: 0, SkippedL1MessageBitmap: []byte{}}
+	blocks := []*eth.BlockWithRowConsumption{
+		testBuilderBlock(t, 1, l1MessageTxs(t, 0, 2), 2, 1), // queue index 1 is skipped
+		testBuilderBlock(t, 2, nil, 0, 1),
+		testBuilderBlock(t, 3, l1MessageTxs(t, 3), 3, 1),
+	}
+
+	for _, version := range []uint8{types.BatchVersionV0, types.BatchVersionV1} {
+		batchHeader, chunks := packTestBatch(t, version, parentBatchHeader, blocks)
+		require.Equal(t, 2, chunks.ChunkNum())
+		chunksBytes, err := chunks.EncodeWithVersion(version)
+		require.NoError(t, err)
+		require.NoError(t, checkSealedBatch(&batchHeader, &parentBatchHeader, chunks, chunksBytes))
+
+		// a chunk is missing
+		err = checkSealedBatch(&batchHeader, &parentBatchHeader, chunks, chunksBytes[:1])
+		require.ErrorIs(t, err, ErrSealedBatchMismatch)
+
+		// the sealed header does not match the chunks
+		wrongHeader := batchHeader
+		wrongHeader.DataHash[0] ^= 1
+		require.ErrorIs(t, checkSealedBatch(&wrongHeader, &parentBatchHeader, chunks, chunksBytes), ErrSealedBatchMismatch)
+		wrongHeader = batchHeader
+		wrongHeader.TotalL1MessagePopped++
+		require.ErrorIs(t, checkSealedBatch(&wrongHeader, &parentBatchHeader, chunks, chunksBytes), ErrSealedBatchMismatch)
+		wrongHeader = batchHeader
+		wrongHeader.SkippedL1MessageBitmap = (&types.SkippedBitmap{}).Encode()
+		require.ErrorIs(t, checkSealedBatch(&wrongHeader, &parentBatchHeader, chunks, chunksBytes), ErrSealedBatchMismatch)
+	}
+
+	// the encoder messes up a block context, or a transaction
+	batchHeader, chunks := packTestBatch(t, types.BatchVersionV0, parentBatchHeader, blocks)
+	chunksBytes, err := chunks.EncodeWithVersion(types.BatchVersionV0)
+	require.NoError(t, err)
+	corrupt := func(chunk, pos int) [][]byte {
+		corrupted := make([][]byte, len(chunksBytes))
+		for i := range chunksBytes {
+			corrupted[i] = append([]byte{}, chunksBytes[i]...)
+		}
+		corrupted[chunk][pos] ^= 1
+		return corrupted
+	}
+	require.ErrorIs(t, checkSealedBatch(&batchHeader, &parentBatchHeader, chunks, corrupt(0, 1+8)), ErrSealedBatchMismatch)
+	require.ErrorIs(t, checkSealedBatch(&batchHeader, &parentBatchHeader, chunks, corrupt(1, len(chunksBytes[1])-1)), ErrSealedBatchMismatch)
+	require.ErrorIs(t, checkSealedBatch(&batchHeader, &parentBatchHeader, chunks, append(chunksBytes[:1:1], append(chunksBytes[1], 0))), ErrSealedBatchMismatch)
+}
+
+func TestSealBatchSelfCheck(t *testing.T) {
+	parentBatchHeader := types.BatchHeader{BatchIndex: 2, TotalL1MessagePopped: 0, SkippedL1MessageBitmap: []byte{}}
+	blocks := []*eth.BlockWithRowConsumption{
+		testBuilderBlock(t, 1, l1MessageTxs(t, 0, 2), 2, 1),
+		testBuilderBlock(t, 2, nil, 0, 1),
+	}
+	batchHeader, chunks := packTestBatch(t, types.BatchVersionV1, parentBatchHeader, blocks)
+	skippedBitmap, err := types.DecodeSkippedBitmap(batchHeader.SkippedL1MessageBitmap)
+	require.NoError(t, err)
+	newExecutor := func(totalL1MessagePopped uint64) *Executor {
+		return &Executor{
+			batchCheck: true,
+			batchingCache: &BatchingCache{
+				parentBatchHeader:     parentBatchHeader,
+				version:               types.BatchVersionV1,
+				chunks:                chunks,
+				totalL1MessagePopped:  totalL1MessagePopped,
+				skippedBitmap:         skippedBitmap,
+				lastPackedBlockHeight: 2,
+			},
+			logger:  tmlog.NewNopLogger(),
+			metrics: NopMetrics(),
+		}
+	}
+
+	e := newExecutor(batchHeader.TotalL1MessagePopped)
+	batchHash, batchHeaderBytes, err := e.SealBatch()
+	require.NoError(t, err)
+	require.Equal(t, batchHeader.Hash().Bytes(), batchHash)
+	require.Equal(t, batchHeader.Encode(), batchHeaderBytes)
+	require.NotNil(t, e.batchingCache.sealedBatchHeader)
+
+	// the header claims more L1 messages than the chunks pop, the batch is not sealed
+	e = newExecutor(batchHeader.TotalL1MessagePopped + 1)
+	_, _, err = e.SealBatch()
+	require.ErrorIs(t, err, ErrSealedBatchMismatch)
+	require.Nil(t, e.batchingCache.sealedBatchHeader)
+	// there is nothing to commit then
+	require.NoError(t, e.CommitBatch(nil, nil, nil))
+}
@@ -1 +1,50 @@
 package node
+
+import (
+	"testing"
+
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func l1MessageTxs(t *testing.T, indexes ...uint64) tmtypes.Txs {
+	var gas []uint64
+	for range make([]struct{}, indexes[len(indexes)-1]+1) {
+		gas = append(gas, 21000)
+	}
+	msgs := testPolicyMessages(0, gas...)
+	txs := make(tmtypes.Txs, len(indexes))
+	for i, index := range indexes {
+		bz, err := eth.NewTx(&msgs[index].L1MessageTx).MarshalBinary()
+		require.NoError(t, err)
+		txs[i] = bz
+	}
+	return txs
+}
+
+func TestParsingTxsSkippedBitmapAcrossBlocks(t *testing.T) {
+	// the batch starts at queue index 100, the first block skips 170, the second one 400 and 401
+	_, _, popped, bitmap, _, err := ParsingTxs(l1MessageTxs(t, 169, 171)[0:1], 100, 169, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 170, popped)
+
+	_, _, popped, bitmap, _, err = ParsingTxs(l1MessageTxs(t, 171), 100, popped, bitmap)
+	require.NoError(t, err)
+	require.EqualValues(t, 172, popped)
+
+	before := bitmap.Encode()
+	_, _, popped, after, _, err := ParsingTxs(l1MessageTxs(t, 172, 402), 100, popped, bitmap)
+	require.NoError(t, err)
+	require.EqualValues(t, 403, popped)
+	// the bitmap of the previous blocks is left as is
+	require.Equal(t, before, bitmap.Encode())
+
+	require.Equal(t, 2, after.Words())
+	require.Equal(t, 230, after.Count())
+	require.True(t, after.Test(70))
+	require.True(t, after.Test(300))
+	require.True(t, after.Test(301))
+	require.False(t, after.Test(302))
+	require.False(t, after.Test(72))
+}
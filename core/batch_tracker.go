@@ -0,0 +1,227 @@
+package node
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/morph-l2/bindings/bindings"
+	nodecommon "github.com/morph-l2/node/common"
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+const (
+	// DefaultBatchTrackerPollInterval is the frequency at which L1 is queried for the batch events.
+	DefaultBatchTrackerPollInterval = 30 * time.Second
+	// DefaultBatchTrackerFetchBlockRange is the maximum number of L1 blocks queried in a single eth_getLogs query,
+	// the range shrinks when the provider limits it.
+	DefaultBatchTrackerFetchBlockRange = uint64(500)
+)
+
+// batchRecords persists the lifecycle of the batches sealed by the executor.
+// The executor records the batches it seals, signs and submits, the batch tracker the ones committed and finalized on L1.
+type batchRecords struct {
+	mu      sync.Mutex
+	store   BatchStore
+	metrics *Metrics
+	logger  tmlog.Logger
+}
+
+func newBatchRecords(store BatchStore, metrics *Metrics, logger tmlog.Logger) *batchRecords {
+	return &batchRecords{store: store, metrics: metrics, logger: logger}
+}
+
+// sealed records a sealed batch. A batch sealed again, in a later consensus round, replaces the former record.
+func (r *batchRecords) sealed(record types.BatchRecord) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record.Status = types.BatchSealed
+	r.store.WriteBatchRecord(record)
+	r.metrics.BatchIndex.With("status", types.BatchSealed.String()).Set(float64(record.Index))
+}
+
+// advance moves the batch to the given status and applies update to its record.
+// Unknown batches, batches of another hash and batches past the status already are left untouched,
+// it returns whether the record was updated.
+func (r *batchRecords) advance(index uint64, hash common.Hash, status types.BatchStatus, update func(record *types.BatchRecord)) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record := r.store.ReadBatchRecord(index)
+	if record == nil {
+		return false
+	}
+	if record.Hash != hash {
+		if status >= types.BatchCommitted {
+			r.metrics.BatchHashMismatch.Add(1)
+			r.logger.Error("the batch on L1 is not the one sealed", "batchIndex", index, "status", status,
+				"sealedHash", record.Hash.Hex(), "l1Hash", hash.Hex())
+		}
+		return false
+	}
+	if record.Status >= status {
+		return false
+	}
+	record.Status = status
+	if update != nil {
+		update(record)
+	}
+	r.store.WriteBatchRecord(*record)
+	r.metrics.BatchIndex.With("status", status.String()).Set(float64(index))
+	r.logger.Info("batch status updated", "batchIndex", index, "status", status)
+	return true
+}
+
+func (r *batchRecords) get(index uint64) *types.BatchRecord {
+	if r == nil {
+		return nil
+	}
+	return r.store.ReadBatchRecord(index)
+}
+
+func (r *batchRecords) list(fromIndex uint64, limit int) []types.BatchRecord {
+	if r == nil {
+		return nil
+	}
+	return r.store.ReadBatchRecords(fromIndex, limit)
+}
+
+// batchTracker watches the Rollup contract on L1 for the CommitBatch and FinalizeBatch events of the batches
+// sealed by the executor. It starts from the latest confirmed L1 block when it runs the first time,
+// the batches committed on L1 before are not tracked.
+type batchTracker struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+
+	records       *batchRecords
+	store         BatchStore
+	l1Client      nodecommon.L1HeightReader
+	rollup        *bindings.RollupFilterer
+	confirmations rpc.BlockNumber
+	pollInterval  time.Duration
+	fetchRange    *nodecommon.AdaptiveRange
+	logger        tmlog.Logger
+}
+
+// newBatchTracker creates a batch tracker reading L1 through l1Client, the client shared with the syncer.
+func newBatchTracker(records *batchRecords, l1Client *nodecommon.QuorumClient, config *Config, logger tmlog.Logger) (*batchTracker, error) {
+	rollup, err := bindings.NewRollupFilterer(*config.RollupAddress, l1Client)
+	if err != nil {
+		return nil, err
+	}
+	logger = logger.With("module", "batch_tracker")
+	store := records.store
+	ctx, cancel := context.WithCancel(context.Background())
+	return &batchTracker{
+		ctx:           ctx,
+		cancel:        cancel,
+		stop:          make(chan struct{}),
+		records:       records,
+		store:         store,
+		l1Client:      l1Client,
+		rollup:        rollup,
+		confirmations: config.L1.Confirmations,
+		pollInterval:  DefaultBatchTrackerPollInterval,
+		fetchRange:    nodecommon.NewAdaptiveRange(DefaultBatchTrackerFetchBlockRange, store.ReadBatchTrackerFetchBlockRange(), store.WriteBatchTrackerFetchBlockRange, logger),
+		logger:        logger,
+	}, nil
+}
+
+func (t *batchTracker) Start() {
+	go func() {
+		ticker := time.NewTicker(t.pollInterval)
+		defer ticker.Stop()
+		for {
+			t.track()
+			select {
+			case <-t.ctx.Done():
+				close(t.stop)
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (t *batchTracker) Stop() {
+	if t == nil {
+		return
+	}
+	t.cancel()
+	<-t.stop
+}
+
+// track handles the batch events emitted by the confirmed L1 blocks not tracked yet.
+func (t *batchTracker) track() {
+	latest, err := nodecommon.GetLatestConfirmedBlockNumber(t.ctx, t.l1Client, t.confirmations)
+	if err != nil {
+		t.logger.Error("failed to get the latest confirmed L1 block", "err", err)
+		return
+	}
+	tracked := t.store.ReadBatchTrackerL1Height()
+	if tracked == nil {
+		t.logger.Info("start tracking batches on L1", "l1Height", latest)
+		t.store.WriteBatchTrackerL1Height(latest)
+		return
+	}
+	for from := *tracked + 1; from <= latest; {
+		to, err := t.fetchRange.Query(from, latest, t.trackRange)
+		if err != nil {
+			t.logger.Error("failed to track batches on L1", "from", from, "to", to, "err", err)
+			return
+		}
+		t.store.WriteBatchTrackerL1Height(to)
+		from = to + 1
+	}
+}
+
+func (t *batchTracker) trackRange(from, to uint64) error {
+	opts := &bind.FilterOpts{Start: from, End: &to, Context: t.ctx}
+	commits, err := t.rollup.FilterCommitBatch(opts, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer commits.Close()
+	for commits.Next() {
+		t.committed(commits.Event.BatchIndex, commits.Event.BatchHash, commits.Event.Raw)
+	}
+	if err := commits.Error(); err != nil {
+		return err
+	}
+
+	finalizations, err := t.rollup.FilterFinalizeBatch(opts, nil, nil)
+	if err != nil {
+		return err
+	}
+	defer finalizations.Close()
+	for finalizations.Next() {
+		t.finalized(finalizations.Event.BatchIndex, finalizations.Event.BatchHash, finalizations.Event.Raw)
+	}
+	return finalizations.Error()
+}
+
+func (t *batchTracker) committed(index *big.Int, hash common.Hash, log eth.Log) {
+	t.records.advance(index.Uint64(), hash, types.BatchCommitted, func(record *types.BatchRecord) {
+		record.CommitL1Block = log.BlockNumber
+		record.CommitTxHash = log.TxHash
+	})
+}
+
+func (t *batchTracker) finalized(index *big.Int, hash common.Hash, log eth.Log) {
+	t.records.advance(index.Uint64(), hash, types.BatchFinalized, func(record *types.BatchRecord) {
+		record.FinalizeL1Block = log.BlockNumber
+		record.FinalizeTxHash = log.TxHash
+	})
+}
@@ -0,0 +1,129 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/morph-l2/bindings/bindings"
+	nodecommon "github.com/morph-l2/node/common"
+	"github.com/morph-l2/node/db"
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+func TestBatchLifecycle(t *testing.T) {
+	store := db.NewMemoryStore()
+	records := newBatchRecords(store, NopMetrics(), tmlog.NewNopLogger())
+	tracker := &batchTracker{records: records, store: store, logger: tmlog.NewNopLogger()}
+	e := &Executor{batchRecords: records}
+	api := NewBatchAPI(e)
+
+	hash := common.Hash{5}
+	records.sealed(types.BatchRecord{Index: 5, Hash: common.Hash{4}, FirstBlock: 10, LastBlock: 19, Size: 1000})
+	// sealed again in a later round
+	records.sealed(types.BatchRecord{Index: 5, Hash: hash, FirstBlock: 10, LastBlock: 20, Size: 1100})
+	record := api.Batch(5)
+	require.NotNil(t, record)
+	require.Equal(t, types.BatchSealed, record.Status)
+	require.Equal(t, hash, record.Hash)
+	require.EqualValues(t, 20, record.LastBlock)
+
+	require.False(t, records.advance(5, common.Hash{4}, types.BatchSigned, nil))
+	require.True(t, records.advance(5, hash, types.BatchSigned, func(record *types.BatchRecord) { record.Signatures = 3 }))
+	require.True(t, records.advance(5, hash, types.BatchSubmitted, nil))
+	require.False(t, records.advance(6, hash, types.BatchSubmitted, nil))
+
+	// the batch committed on L1 is another one
+	tracker.committed(big.NewInt(5), common.Hash{6}, eth.Log{BlockNumber: 100})
+	require.Equal(t, types.BatchSubmitted, api.Batch(5).Status)
+
+	tracker.committed(big.NewInt(5), hash, eth.Log{BlockNumber: 100, TxHash: common.Hash{1}})
+	tracker.finalized(big.NewInt(5), hash, eth.Log{BlockNumber: 200, TxHash: common.Hash{2}})
+	// a batch not sealed by the node
+	tracker.committed(big.NewInt(7), hash, eth.Log{BlockNumber: 100})
+	require.Nil(t, api.Batch(7))
+
+	record = api.Batch(5)
+	require.Equal(t, types.BatchFinalized, record.Status)
+	require.EqualValues(t, 3, record.Signatures)
+	require.EqualValues(t, 100, record.CommitL1Block)
+	require.Equal(t, common.Hash{1}, record.CommitTxHash)
+	require.EqualValues(t, 200, record.FinalizeL1Block)
+	require.Equal(t, common.Hash{2}, record.FinalizeTxHash)
+	// a status is not moved back
+	require.False(t, records.advance(5, hash, types.BatchSubmitted, nil))
+
+	records.sealed(types.BatchRecord{Index: 6, Hash: common.Hash{6}})
+	require.Len(t, api.Batches(0, nil), 2)
+	limit := 1
+	batches := api.Batches(6, &limit)
+	require.Len(t, batches, 1)
+	require.EqualValues(t, 6, batches[0].Index)
+
+	// the batches are not recorded without a store
+	api = NewBatchAPI(&Executor{})
+	require.Nil(t, api.Batch(5))
+	require.Empty(t, api.Batches(0, nil))
+}
+
+// rangeLimitedL1 serves the L1 height and rejects the log queries over more than maxRange blocks.
+type rangeLimitedL1 struct {
+	latest   uint64
+	maxRange uint64
+	queried  [][2]uint64
+}
+
+func (l *rangeLimitedL1) BlockNumber(context.Context) (uint64, error) {
+	return l.latest, nil
+}
+
+func (l *rangeLimitedL1) HeaderByNumber(context.Context, *big.Int) (*eth.Header, error) {
+	return &eth.Header{Number: new(big.Int).SetUint64(l.latest)}, nil
+}
+
+func (l *rangeLimitedL1) FilterLogs(_ context.Context, query ethereum.FilterQuery) ([]eth.Log, error) {
+	from, to := query.FromBlock.Uint64(), query.ToBlock.Uint64()
+	if to-from+1 > l.maxRange {
+		return nil, errors.New("block range is too large")
+	}
+	l.queried = append(l.queried, [2]uint64{from, to})
+	return nil, nil
+}
+
+func (l *rangeLimitedL1) SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- eth.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func TestBatchTrackerFetchRange(t *testing.T) {
+	store := db.NewMemoryStore()
+	l1 := &rangeLimitedL1{latest: 1300, maxRange: 200}
+	rollup, err := bindings.NewRollupFilterer(common.Address{1}, l1)
+	require.NoError(t, err)
+	tracker := &batchTracker{
+		ctx:           context.Background(),
+		records:       newBatchRecords(store, NopMetrics(), tmlog.NewNopLogger()),
+		store:         store,
+		l1Client:      l1,
+		rollup:        rollup,
+		confirmations: rpc.LatestBlockNumber,
+		fetchRange:    nodecommon.NewAdaptiveRange(DefaultBatchTrackerFetchBlockRange, store.ReadBatchTrackerFetchBlockRange(), store.WriteBatchTrackerFetchBlockRange, nil),
+		logger:        tmlog.NewNopLogger(),
+	}
+	store.WriteBatchTrackerL1Height(1000)
+
+	// the range is shrunk to the provider limit and persisted
+	tracker.track()
+	require.EqualValues(t, 1300, *store.ReadBatchTrackerL1Height())
+	require.EqualValues(t, 125, *store.ReadBatchTrackerFetchBlockRange())
+	require.Equal(t, [2]uint64{1001, 1125}, l1.queried[0])
+	for _, queried := range l1.queried {
+		require.LessOrEqual(t, queried[1]-queried[0]+1, l1.maxRange)
+	}
+}
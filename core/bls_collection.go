@@ -0,0 +1,226 @@
+package node
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/tendermint/tendermint/l2node"
+)
+
+// maxBlsCollections is the number of recent batch hashes the signature collection is kept for.
+const maxBlsCollections = 64
+
+var (
+	ErrInvalidBlsSignature   = errors.New("invalid BLS signature")
+	ErrDuplicateBlsSignature = errors.New("duplicate BLS signature")
+)
+
+// BlsCollectionStatus is the progress of the BLS signature collection of a batch hash.
+type BlsCollectionStatus struct {
+	BatchHash common.Hash `json:"batchHash"`
+	// Height is the L2 height the first signature was collected at.
+	Height uint64 `json:"height"`
+	// Signers are the indices of the sequencers who signed the batch, in the sequencer set of Height.
+	Signers     []uint64 `json:"signers"`
+	Weight      uint64   `json:"weight"`
+	TotalWeight uint64   `json:"totalWeight"`
+	Quorum      bool     `json:"quorum"`
+	// FirstSignedAt and LastSignedAt are the unix times the first and the last signature were collected.
+	FirstSignedAt uint64 `json:"firstSignedAt"`
+	LastSignedAt  uint64 `json:"lastSignedAt"`
+}
+
+// hasQuorum reports whether more than 2/3 of the total weight signed, the BFT threshold of the consensus.
+func hasQuorum(weight, totalWeight uint64) bool {
+	return totalWeight > 0 && weight*3 > totalWeight*2
+}
+
+type blsCollection struct {
+	BlsCollectionStatus
+	signed map[uint64]struct{}
+}
+
+// blsCollections keeps the signatures collected for the recent batch hashes.
+// They are written by the consensus and read by the RPC server.
+type blsCollections struct {
+	mu          sync.Mutex
+	collections map[common.Hash]*blsCollection
+	order       []common.Hash
+}
+
+// add records the signature of the signer for the batch hash, it fails if the signer signed the batch hash already.
+// totalWeight is the weight of the sequencer set at the height, taken when the first signature is collected.
+func (c *blsCollections) add(batchHash common.Hash, height, signer, weight, totalWeight uint64, now time.Time) (BlsCollectionStatus, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.collections == nil {
+		c.collections = make(map[common.Hash]*blsCollection)
+	}
+	collection, ok := c.collections[batchHash]
+	if !ok {
+		if len(c.order) == maxBlsCollections {
+			delete(c.collections, c.order[0])
+			c.order = c.order[1:]
+		}
+		collection = &blsCollection{
+			BlsCollectionStatus: BlsCollectionStatus{
+				BatchHash:     batchHash,
+				Height:        height,
+				TotalWeight:   totalWeight,
+				FirstSignedAt: uint64(now.Unix()),
+			},
+			signed: make(map[uint64]struct{}),
+		}
+		c.collections[batchHash] = collection
+		c.order = append(c.order, batchHash)
+	}
+	if _, ok := collection.signed[signer]; ok {
+		return collection.status(), fmt.Errorf("%w: sequencer %d signed batch %s already", ErrDuplicateBlsSignature, signer, batchHash.Hex())
+	}
+	collection.signed[signer] = struct{}{}
+	collection.Signers = append(collection.Signers, signer)
+	sort.Slice(collection.Signers, func(i, j int) bool { return collection.Signers[i] < collection.Signers[j] })
+	collection.Weight += weight
+	collection.Quorum = hasQuorum(collection.Weight, collection.TotalWeight)
+	collection.LastSignedAt = uint64(now.Unix())
+	return collection.status(), nil
+}
+
+func (c *blsCollection) status() BlsCollectionStatus {
+	status := c.BlsCollectionStatus
+	status.Signers = append([]uint64{}, c.Signers...)
+	return status
+}
+
+func (c *blsCollections) get(batchHash common.Hash) *BlsCollectionStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	collection, ok := c.collections[batchHash]
+	if !ok {
+		return nil
+	}
+	status := collection.status()
+	return &status
+}
+
+// list returns the collections of the recent batch hashes, the most recent last.
+func (c *blsCollections) list() []BlsCollectionStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	statuses := make([]BlsCollectionStatus, 0, len(c.order))
+	for _, batchHash := range c.order {
+		statuses = append(statuses, c.collections[batchHash].status())
+	}
+	return statuses
+}
+
+// withoutQuorum returns the number of recent batch hashes whose signatures have not reached the quorum.
+func (c *blsCollections) withoutQuorum() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int
+	for _, collection := range c.collections {
+		if !collection.Quorum {
+			n++
+		}
+	}
+	return n
+}
+
+// signerWeight is the voting weight of a sequencer, all the sequencers weigh the same.
+func (e *Executor) signerWeight(*sequencerKey) uint64 {
+	return 1
+}
+
+// sequencerSetWeight is the total voting weight of the sequencer set of the height, the one the signers are checked against.
+func (e *Executor) sequencerSetWeight(height uint64) uint64 {
+	set := e.sequencerSetAt(height)
+	if set == nil {
+		return 0
+	}
+	var total uint64
+	for _, seqKey := range set.sequencerSet {
+		total += e.signerWeight(&seqKey)
+	}
+	return total
+}
+
+// verifyBlsData verifies the BLS signature of the batch hash with the key of the signer at the height,
+// and converts it into the signature handed to geth. It returns the voting weight of the signer as well.
+func (e *Executor) verifyBlsData(height uint64, batchHash common.Hash, data l2node.BlsData) (*eth.BatchSignature, uint64, error) {
+	valid, err := e.verifySignatureAt(data.Signer, batchHash[:], data.Signature, &height)
+	if errors.Is(err, errNotSequencer) {
+		e.metrics.BlsSignatureRejected.With("reason", "unknown_signer").Add(1)
+		return nil, 0, fmt.Errorf("%w at height %d: %x", err, height, data.Signer)
+	}
+	if err != nil || !valid {
+		e.metrics.BlsSignatureRejected.With("reason", "invalid").Add(1)
+		if err == nil {
+			err = errors.New("signature mismatch")
+		}
+		return nil, 0, fmt.Errorf("%w from %x: %v", ErrInvalidBlsSignature, data.Signer, err)
+	}
+	blsSig, err := e.ConvertBlsData(data, height)
+	if err != nil {
+		e.metrics.BlsSignatureRejected.With("reason", "unknown_signer").Add(1)
+		return nil, 0, err
+	}
+	seqKey, _ := e.getBlsPubKeyByTmKey(data.Signer, &height)
+	return blsSig, e.signerWeight(seqKey), nil
+}
+
+// addBlsSignature records the verified signature in the collection of the batch hash.
+func (e *Executor) addBlsSignature(height uint64, batchHash common.Hash, blsSig *eth.BatchSignature, weight uint64) error {
+	status, err := e.blsCollections.add(batchHash, height, blsSig.Signer, weight, e.sequencerSetWeight(height), time.Now())
+	if err != nil {
+		return err
+	}
+	e.metrics.BlsSignatureCollected.Add(1)
+	e.metrics.BatchesWithoutQuorum.Set(float64(e.blsCollections.withoutQuorum()))
+	e.logger.Debug("BLS signature collected", "batchHash", batchHash.Hex(), "signer", blsSig.Signer,
+		"weight", status.Weight, "totalWeight", status.TotalWeight, "quorum", status.Quorum)
+	return nil
+}
+
+// collectBlsSignature verifies the BLS signature of the batch hash and records it in the signature collection
+// of the batch hash. It returns the signature to forward to geth, failing on invalid or duplicate signatures.
+func (e *Executor) collectBlsSignature(height uint64, batchHash common.Hash, data l2node.BlsData) (*eth.BatchSignature, error) {
+	blsSig, weight, err := e.verifyBlsData(height, batchHash, data)
+	if err != nil {
+		return nil, err
+	}
+	if err = e.addBlsSignature(height, batchHash, blsSig, weight); err != nil {
+		e.metrics.BlsSignatureRejected.With("reason", "duplicate").Add(1)
+		return nil, err
+	}
+	return blsSig, nil
+}
+
+// collectBlsSignatures collects the BLS signatures of the batch hash gathered by the consensus, to commit the batch with.
+// Invalid signatures are dropped, and so are the duplicates. The signatures appended one by one before are committed
+// with the batch still.
+func (e *Executor) collectBlsSignatures(height uint64, batchHash common.Hash, blsDatas []l2node.BlsData) []eth.BatchSignature {
+	var batchSigs []eth.BatchSignature
+	seen := make(map[uint64]struct{})
+	for _, blsData := range blsDatas {
+		blsSig, weight, err := e.verifyBlsData(height, batchHash, blsData)
+		if err != nil {
+			e.logger.Error("drop BLS signature", "batchHash", batchHash.Hex(), "error", err)
+			continue
+		}
+		if _, ok := seen[blsSig.Signer]; ok {
+			e.metrics.BlsSignatureRejected.With("reason", "duplicate").Add(1)
+			continue
+		}
+		seen[blsSig.Signer] = struct{}{}
+		// the signatures appended one by one are in the collection already
+		_ = e.addBlsSignature(height, batchHash, blsSig, weight)
+		batchSigs = append(batchSigs, *blsSig)
+	}
+	return batchSigs
+}
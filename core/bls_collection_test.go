@@ -0,0 +1,236 @@
+package node
+
+import (
+	"context"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/ethclient/authclient"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/blssignatures"
+	"github.com/tendermint/tendermint/l2node"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+type testSequencer struct {
+	tmKey   [tmKeySize]byte
+	blsPub  blssignatures.PublicKey
+	blsPriv blssignatures.PrivateKey
+}
+
+func (s testSequencer) sign(t *testing.T, batchHash common.Hash) l2node.BlsData {
+	sig, err := blssignatures.SignMessage(s.blsPriv, batchHash[:])
+	require.NoError(t, err)
+	return l2node.BlsData{Signer: s.tmKey[:], Signature: blssignatures.SignatureToBytes(sig)}
+}
+
+// testSequencerSet returns an executor with a sequencer set of n sequencers, and the sequencers.
+func testSequencerSet(t *testing.T, n int) (*Executor, []testSequencer) {
+	sequencers := make([]testSequencer, n)
+	sequencerSet := make(map[[tmKeySize]byte]sequencerKey)
+	for i := range sequencers {
+		pub, priv, err := blssignatures.GenerateKeys()
+		require.NoError(t, err)
+		sequencers[i] = testSequencer{blsPub: pub, blsPriv: priv}
+		sequencers[i].tmKey[0] = byte(i + 1)
+		sequencerSet[sequencers[i].tmKey] = sequencerKey{index: uint64(i), blsPubKey: pub}
+	}
+	e := &Executor{
+		currentSequencerSet: &SequencerSetInfo{version: 1, startHeight: 1, sequencerSet: sequencerSet},
+		logger:              tmlog.NewNopLogger(),
+		metrics:             NopMetrics(),
+	}
+	return e, sequencers
+}
+
+func TestBlsSignatureCollection(t *testing.T) {
+	e, sequencers := testSequencerSet(t, 4)
+	api := NewBatchAPI(e)
+	batchHash := common.Hash{1}
+
+	blsSig, err := e.collectBlsSignature(10, batchHash, sequencers[2].sign(t, batchHash))
+	require.NoError(t, err)
+	require.EqualValues(t, 2, blsSig.Signer)
+	// duplicate
+	_, err = e.collectBlsSignature(10, batchHash, sequencers[2].sign(t, batchHash))
+	require.ErrorIs(t, err, ErrDuplicateBlsSignature)
+	// signed another batch hash
+	_, err = e.collectBlsSignature(10, batchHash, sequencers[0].sign(t, common.Hash{2}))
+	require.ErrorIs(t, err, ErrInvalidBlsSignature)
+	// not a sequencer
+	stranger := sequencers[0]
+	stranger.tmKey[0] = 100
+	_, err = e.collectBlsSignature(10, batchHash, stranger.sign(t, batchHash))
+	require.Error(t, err)
+
+	_, err = e.collectBlsSignature(10, batchHash, sequencers[0].sign(t, batchHash))
+	require.NoError(t, err)
+	status := api.Signatures(batchHash)
+	require.NotNil(t, status)
+	require.Equal(t, []uint64{0, 2}, status.Signers)
+	require.EqualValues(t, 2, status.Weight)
+	require.EqualValues(t, 4, status.TotalWeight)
+	require.False(t, status.Quorum)
+	require.EqualValues(t, 10, status.Height)
+
+	// the consensus hands the signatures over with the batch, including the appended ones
+	batchSigs := e.collectBlsSignatures(11, batchHash, []l2node.BlsData{
+		sequencers[0].sign(t, batchHash),
+		sequencers[1].sign(t, batchHash),
+		sequencers[1].sign(t, batchHash),
+		sequencers[3].sign(t, common.Hash{2}),
+	})
+	require.Len(t, batchSigs, 2)
+	require.EqualValues(t, 0, batchSigs[0].Signer)
+	require.EqualValues(t, 1, batchSigs[1].Signer)
+	status = api.Signatures(batchHash)
+	require.Equal(t, []uint64{0, 1, 2}, status.Signers)
+	require.True(t, status.Quorum)
+
+	require.Nil(t, api.Signatures(common.Hash{2}))
+	require.Len(t, api.SignatureCollections(), 1)
+	for i := 0; i < maxBlsCollections; i++ {
+		hash := common.Hash{byte(i), 3}
+		_, err = e.collectBlsSignature(12, hash, sequencers[0].sign(t, hash))
+		require.NoError(t, err)
+	}
+	collections := api.SignatureCollections()
+	require.Len(t, collections, maxBlsCollections)
+	require.Equal(t, common.Hash{0, 3}, collections[0].BatchHash)
+	require.Equal(t, maxBlsCollections, e.blsCollections.withoutQuorum())
+}
+
+func TestVerifyBlsDataAtHeight(t *testing.T) {
+	e, sequencers := testSequencerSet(t, 2)
+	batchHash := common.Hash{1}
+	// the first sequencer rotated its BLS key at height 20
+	rotated := sequencers[0]
+	pub, priv, err := blssignatures.GenerateKeys()
+	require.NoError(t, err)
+	rotated.blsPub, rotated.blsPriv = pub, priv
+	previous := *e.currentSequencerSet
+	e.previousSequencerSet = []SequencerSetInfo{previous}
+	e.currentSequencerSet = &SequencerSetInfo{version: 2, startHeight: 20, sequencerSet: map[[tmKeySize]byte]sequencerKey{
+		rotated.tmKey:       {index: 0, blsPubKey: rotated.blsPub},
+		sequencers[1].tmKey: previous.sequencerSet[sequencers[1].tmKey],
+	}}
+
+	// the signature of a batch before the rotation is verified with the former key
+	_, _, err = e.verifyBlsData(10, batchHash, sequencers[0].sign(t, batchHash))
+	require.NoError(t, err)
+	_, _, err = e.verifyBlsData(10, batchHash, rotated.sign(t, batchHash))
+	require.ErrorIs(t, err, ErrInvalidBlsSignature)
+	_, _, err = e.verifyBlsData(25, batchHash, rotated.sign(t, batchHash))
+	require.NoError(t, err)
+	_, _, err = e.verifyBlsData(25, batchHash, sequencers[0].sign(t, batchHash))
+	require.ErrorIs(t, err, ErrInvalidBlsSignature)
+
+	// a sequencer joining at height 20 did not sign before
+	joined := rotated
+	joined.tmKey[0] = 100
+	e.currentSequencerSet.sequencerSet[joined.tmKey] = sequencerKey{index: 2, blsPubKey: joined.blsPub}
+	_, _, err = e.verifyBlsData(10, batchHash, joined.sign(t, batchHash))
+	require.ErrorIs(t, err, errNotSequencer)
+	_, _, err = e.verifyBlsData(25, batchHash, joined.sign(t, batchHash))
+	require.NoError(t, err)
+}
+
+func TestBlsQuorumAtBatchHeight(t *testing.T) {
+	e, sequencers := testSequencerSet(t, 6)
+	api := NewBatchAPI(e)
+	// 3 sequencers signed the blocks before height 20, the 6 of the current set sign from then on
+	previous := SequencerSetInfo{version: 1, startHeight: 1, sequencerSet: make(map[[tmKeySize]byte]sequencerKey)}
+	for _, seq := range sequencers[:3] {
+		previous.sequencerSet[seq.tmKey] = e.currentSequencerSet.sequencerSet[seq.tmKey]
+	}
+	e.previousSequencerSet = []SequencerSetInfo{previous}
+	e.currentSequencerSet.version, e.currentSequencerSet.startHeight = 2, 20
+	require.EqualValues(t, 3, e.sequencerSetWeight(10))
+	require.EqualValues(t, 6, e.sequencerSetWeight(25))
+	require.Zero(t, e.sequencerSetWeight(0))
+
+	// the signatures of the whole set of the batch height reach the quorum
+	batchHash := common.Hash{1}
+	require.Len(t, e.collectBlsSignatures(10, batchHash, []l2node.BlsData{
+		sequencers[0].sign(t, batchHash),
+		sequencers[1].sign(t, batchHash),
+		sequencers[2].sign(t, batchHash),
+	}), 3)
+	status := api.Signatures(batchHash)
+	require.EqualValues(t, 3, status.TotalWeight)
+	require.True(t, status.Quorum)
+
+	// the same signers are short of it in the current set
+	batchHash = common.Hash{2}
+	require.Len(t, e.collectBlsSignatures(25, batchHash, []l2node.BlsData{
+		sequencers[0].sign(t, batchHash),
+		sequencers[1].sign(t, batchHash),
+		sequencers[2].sign(t, batchHash),
+	}), 3)
+	status = api.Signatures(batchHash)
+	require.EqualValues(t, 6, status.TotalWeight)
+	require.False(t, status.Quorum)
+}
+
+// testEngine records the signatures the batches are committed with.
+type testEngine struct {
+	signatures []eth.BatchSignature
+}
+
+func (e *testEngine) CommitBatch(_ eth.RollupBatch, signatures []eth.BatchSignature) error {
+	e.signatures = signatures
+	return nil
+}
+
+func TestCommitBatchDropsInvalidSignatures(t *testing.T) {
+	engine := &testEngine{}
+	server := rpc.NewServer()
+	require.NoError(t, server.RegisterName("engine", engine))
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(server.Stop)
+	authClient, err := authclient.DialContext(context.Background(), httpServer.URL, [32]byte{})
+	require.NoError(t, err)
+
+	parentBatchHeader := types.BatchHeader{BatchIndex: 2, SkippedL1MessageBitmap: []byte{}}
+	batchHeader, chunks := packTestBatch(t, types.BatchVersionV1, parentBatchHeader, []*eth.BlockWithRowConsumption{
+		testBuilderBlock(t, 1, nil, 1, 1),
+		testBuilderBlock(t, 2, nil, 1, 1),
+	})
+	e, sequencers := testSequencerSet(t, 4)
+	e.l2Client = types.NewRetryableClient([]types.L2Endpoint{{AuthClient: authClient}}, types.DefaultRetryConfig(), tmlog.NewNopLogger())
+	e.batchingCache = &BatchingCache{
+		parentBatchHeader:     parentBatchHeader,
+		version:               types.BatchVersionV1,
+		chunks:                chunks,
+		skippedBitmap:         &types.SkippedBitmap{},
+		lastPackedBlockHeight: 2,
+	}
+	_, _, err = e.SealBatch()
+	require.NoError(t, err)
+	batchHash := batchHeader.Hash()
+
+	currentBlock := &types.WrappedBlock{Number: 3, BaseFee: big.NewInt(1)}
+	currentBlockBytes, err := currentBlock.MarshalBinary()
+	require.NoError(t, err)
+	stranger := sequencers[3]
+	stranger.tmKey[0] = 100
+	require.NoError(t, e.CommitBatch(currentBlockBytes, nil, []l2node.BlsData{
+		sequencers[0].sign(t, batchHash),
+		// signed another batch hash
+		sequencers[1].sign(t, common.Hash{2}),
+		// not a sequencer
+		stranger.sign(t, batchHash),
+		sequencers[2].sign(t, batchHash),
+	}))
+	// the batch is committed with the valid signatures only
+	require.Len(t, engine.signatures, 2)
+	require.EqualValues(t, 0, engine.signatures[0].Signer)
+	require.EqualValues(t, 2, engine.signatures[1].Signer)
+	require.Nil(t, e.batchingCache.sealedBatchHeader)
+}
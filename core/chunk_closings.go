@@ -0,0 +1,62 @@
+package node
+
+import (
+	"sync"
+
+	"github.com/morph-l2/node/types"
+)
+
+// maxChunkClosings is the number of recent chunk closings kept for the debug API.
+const maxChunkClosings = 128
+
+// ChunkClosingRecord is a chunk closed by the block of the given height.
+type ChunkClosingRecord struct {
+	Height uint64 `json:"height"`
+	types.ChunkClosing
+}
+
+// chunkClosings keeps the recent chunk closings and the status of the open chunk.
+// They are written by the consensus and read by the RPC server.
+type chunkClosings struct {
+	mu      sync.Mutex
+	records []ChunkClosingRecord
+	current *types.ChunkStatus
+}
+
+func (c *chunkClosings) add(record ChunkClosingRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.records) == maxChunkClosings {
+		copy(c.records, c.records[1:])
+		c.records = c.records[:len(c.records)-1]
+	}
+	c.records = append(c.records, record)
+}
+
+func (c *chunkClosings) setCurrent(status *types.ChunkStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.current = status
+}
+
+// list returns the recent closings, the most recent last.
+func (c *chunkClosings) list() []ChunkClosingRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]ChunkClosingRecord{}, c.records...)
+}
+
+func (c *chunkClosings) currentChunk() *types.ChunkStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.current
+}
+
+// recordChunkClosing keeps the reason why the block of the given height closed the last chunk.
+// Only blocks packed at consensus are recorded, the chunks rebuilt from history have been recorded already.
+func (e *Executor) recordChunkClosing(height uint64, closing *types.ChunkClosing) {
+	e.chunkClosings.add(ChunkClosingRecord{Height: height, ChunkClosing: *closing})
+	e.metrics.ChunkClosed.With("reason", string(closing.Reason), "circuit", closing.Circuit).Add(1)
+	e.logger.Debug("chunk closed", "height", height, "reason", closing.Reason, "circuit", closing.Circuit,
+		"value", closing.Value, "limit", closing.Limit, "blockNum", closing.BlockNum)
+}
@@ -0,0 +1,34 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/morph-l2/node/types"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+func TestDebugAPIChunkClosings(t *testing.T) {
+	e := &Executor{
+		logger:  tmlog.NewNopLogger(),
+		metrics: NopMetrics(),
+	}
+	api := NewDebugAPI(e)
+	require.NotNil(t, api.ChunkClosings())
+	require.Empty(t, api.ChunkClosings())
+	require.Nil(t, api.CurrentChunk())
+
+	for i := 0; i < maxChunkClosings+10; i++ {
+		e.recordChunkClosing(uint64(i), &types.ChunkClosing{Reason: types.ChunkCloseMaxRowNumber, Circuit: "keccak", Value: 1_000_001, Limit: 1_000_000, BlockNum: 3})
+	}
+	closings := api.ChunkClosings()
+	require.Len(t, closings, maxChunkClosings)
+	require.EqualValues(t, 10, closings[0].Height)
+	require.EqualValues(t, maxChunkClosings+9, closings[len(closings)-1].Height)
+	require.Equal(t, "keccak", closings[0].Circuit)
+
+	chunks := types.NewChunks()
+	chunks.Append(make([]byte, 60), nil, nil, nil)
+	e.chunkClosings.setCurrent(chunks.LastChunkStatus())
+	require.Equal(t, 1, api.CurrentChunk().BlockNum)
+}
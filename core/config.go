@@ -5,37 +5,53 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/morph-l2/bindings/predeploys"
 	"github.com/morph-l2/node/flags"
 	"github.com/morph-l2/node/types"
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/rpc"
 	tmconfig "github.com/tendermint/tendermint/config"
 	tmlog "github.com/tendermint/tendermint/libs/log"
 	"github.com/urfave/cli"
 )
 
 type Config struct {
-	L2                            *types.L2Config `json:"l2"`
-	L2CrossDomainMessengerAddress common.Address  `json:"cross_domain_messenger_address"`
-	L2SequencerAddress            common.Address  `json:"l2_sequencer_address"`
-	L2GovAddress                  common.Address  `json:"l2_gov_address"`
-	MaxL1MessageNumPerBlock       uint64          `json:"max_l1_message_num_per_block"`
-	DevSequencer                  bool            `json:"dev_sequencer"`
-	Logger                        tmlog.Logger    `json:"logger"`
+	L2                            *types.L2Config             `json:"l2"`
+	L2CrossDomainMessengerAddress common.Address              `json:"cross_domain_messenger_address"`
+	L2SequencerAddress            common.Address              `json:"l2_sequencer_address"`
+	L2GovAddress                  common.Address              `json:"l2_gov_address"`
+	MaxL1MessageNumPerBlock       uint64                      `json:"max_l1_message_num_per_block"`
+	L1MessageGasBudget            uint64                      `json:"l1_message_gas_budget"`
+	L1MessageGasLimitFraction     float64                     `json:"l1_message_gas_limit_fraction"`
+	L1MessageMaxAge               time.Duration               `json:"l1_message_max_age"`
+	BatchVersionUpgrades          []types.BatchVersionUpgrade `json:"batch_version_upgrades"`
+	BatchSelfCheck                bool                        `json:"batch_self_check"`
+	ChunkLimitsUpgrades           []types.ChunkLimitsUpgrade  `json:"chunk_limits_upgrades"`
+	DevSequencer                  bool                        `json:"dev_sequencer"`
+	// L1 and RollupAddress let the executor track its batches on L1, they are not tracked without them.
+	L1            *types.L1Config `json:"l1"`
+	RollupAddress *common.Address `json:"rollup_address"`
+	Logger        tmlog.Logger    `json:"logger"`
 }
 
 func DefaultConfig() *Config {
 	return &Config{
-		L2:                            new(types.L2Config),
+		L2:                            types.DefaultL2Config(),
 		Logger:                        tmlog.NewTMLogger(tmlog.NewSyncWriter(os.Stdout)),
 		MaxL1MessageNumPerBlock:       100,
+		L1MessageGasLimitFraction:     DefaultL1MessageGasLimitFraction,
 		L2CrossDomainMessengerAddress: predeploys.L2CrossDomainMessengerAddr,
 		L2SequencerAddress:            predeploys.L2SequencerAddr,
 		L2GovAddress:                  predeploys.GovAddr,
+		L1: &types.L1Config{
+			Confirmations: rpc.FinalizedBlockNumber,
+		},
 	}
 }
 
@@ -57,8 +73,11 @@ func (c *Config) SetCliContext(ctx *cli.Context) error {
 	logger = tmlog.NewFilter(logger, option)
 	c.Logger = logger
 
-	l2EthAddr := ctx.GlobalString(flags.L2EthAddr.Name)
-	l2EngineAddr := ctx.GlobalString(flags.L2EngineAddr.Name)
+	l2EthAddrs := types.SplitAddrs(ctx.GlobalString(flags.L2EthAddr.Name))
+	l2EngineAddrs := types.SplitAddrs(ctx.GlobalString(flags.L2EngineAddr.Name))
+	if len(l2EthAddrs) != len(l2EngineAddrs) {
+		return fmt.Errorf("l2.eth and l2.engine must list the same number of endpoints, got %d and %d", len(l2EthAddrs), len(l2EngineAddrs))
+	}
 	fileName := ctx.GlobalString(flags.L2EngineJWTSecret.Name)
 	var secret [32]byte
 	fileName = strings.TrimSpace(fileName)
@@ -80,9 +99,12 @@ func (c *Config) SetCliContext(ctx *cli.Context) error {
 			return err
 		}
 	}
-	c.L2.EthAddr = l2EthAddr
-	c.L2.EngineAddr = l2EngineAddr
+	c.L2.EthAddrs = l2EthAddrs
+	c.L2.EngineAddrs = l2EngineAddrs
 	c.L2.JwtSecret = secret
+	if err := c.L2.Retry.SetCliContext(ctx); err != nil {
+		return err
+	}
 
 	if ctx.GlobalIsSet(flags.MaxL1MessageNumPerBlock.Name) {
 		c.MaxL1MessageNumPerBlock = ctx.GlobalUint64(flags.MaxL1MessageNumPerBlock.Name)
@@ -91,6 +113,26 @@ func (c *Config) SetCliContext(ctx *cli.Context) error {
 		}
 	}
 
+	if ctx.GlobalIsSet(flags.L1MessageGasBudget.Name) {
+		c.L1MessageGasBudget = ctx.GlobalUint64(flags.L1MessageGasBudget.Name)
+	}
+	if ctx.GlobalIsSet(flags.L1MessageGasLimitFraction.Name) {
+		c.L1MessageGasLimitFraction = ctx.GlobalFloat64(flags.L1MessageGasLimitFraction.Name)
+		if c.L1MessageGasLimitFraction < 0 || c.L1MessageGasLimitFraction > 1 {
+			return fmt.Errorf("l1MessageGasLimitFraction must be between 0 and 1")
+		}
+	}
+	if ctx.GlobalIsSet(flags.L1MessageMaxAge.Name) {
+		c.L1MessageMaxAge = ctx.GlobalDuration(flags.L1MessageMaxAge.Name)
+	}
+
+	if err := c.SetBatchingCliContext(ctx); err != nil {
+		return err
+	}
+	if ctx.GlobalIsSet(flags.BatchSelfCheck.Name) {
+		c.BatchSelfCheck = ctx.GlobalBool(flags.BatchSelfCheck.Name)
+	}
+
 	if ctx.GlobalIsSet(flags.L2CrossDomainMessengerContractAddr.Name) {
 		addr := common.HexToAddress(ctx.GlobalString(flags.L2CrossDomainMessengerContractAddr.Name))
 		c.L2CrossDomainMessengerAddress = addr
@@ -119,5 +161,56 @@ func (c *Config) SetCliContext(ctx *cli.Context) error {
 		c.DevSequencer = ctx.GlobalBool(flags.DevSequencer.Name)
 	}
 
+	c.L1.Addrs = types.SplitAddrs(ctx.GlobalString(flags.L1NodeAddr.Name))
+	if ctx.GlobalIsSet(flags.L1Quorum.Name) {
+		c.L1.Quorum = ctx.GlobalInt(flags.L1Quorum.Name)
+	}
+	if ctx.GlobalIsSet(flags.L1Confirmations.Name) {
+		c.L1.Confirmations = rpc.BlockNumber(ctx.GlobalInt64(flags.L1Confirmations.Name))
+	}
+	if ctx.GlobalIsSet(flags.RollupContractAddress.Name) {
+		addr := common.HexToAddress(ctx.GlobalString(flags.RollupContractAddress.Name))
+		c.RollupAddress = &addr
+	}
+
+	return nil
+}
+
+// SetBatchingCliContext sets the batch version and the chunk limits, which decide how blocks are batched.
+func (c *Config) SetBatchingCliContext(ctx *cli.Context) error {
+	if ctx.GlobalIsSet(flags.BatchVersion.Name) {
+		version := ctx.GlobalUint(flags.BatchVersion.Name)
+		if version > math.MaxUint8 || !types.IsSupportedBatchVersion(uint8(version)) {
+			return fmt.Errorf("unsupported batch version %d", version)
+		}
+		c.BatchVersionUpgrades = []types.BatchVersionUpgrade{{
+			Height:  ctx.GlobalUint64(flags.BatchVersionHeight.Name),
+			Version: uint8(version),
+		}}
+	} else if ctx.GlobalIsSet(flags.BatchVersionHeight.Name) {
+		return errors.New("batchVersionHeight is set without batchVersion")
+	}
+
+	if ctx.GlobalIsSet(flags.ChunkMaxBlocks.Name) || ctx.GlobalIsSet(flags.ChunkMaxRowNumber.Name) || ctx.GlobalIsSet(flags.ChunkMaxTxsPayloadBytes.Name) {
+		upgrade := types.ChunkLimitsUpgrade{
+			Height:      ctx.GlobalUint64(flags.ChunkLimitsHeight.Name),
+			ChunkLimits: types.DefaultChunkLimits(),
+		}
+		if ctx.GlobalIsSet(flags.ChunkMaxBlocks.Name) {
+			upgrade.MaxBlocks = ctx.GlobalInt(flags.ChunkMaxBlocks.Name)
+		}
+		if ctx.GlobalIsSet(flags.ChunkMaxRowNumber.Name) {
+			upgrade.MaxRowNumber = ctx.GlobalUint64(flags.ChunkMaxRowNumber.Name)
+		}
+		if ctx.GlobalIsSet(flags.ChunkMaxTxsPayloadBytes.Name) {
+			upgrade.MaxTxsPayloadBytes = ctx.GlobalInt(flags.ChunkMaxTxsPayloadBytes.Name)
+		}
+		if err := upgrade.Validate(); err != nil {
+			return err
+		}
+		c.ChunkLimitsUpgrades = []types.ChunkLimitsUpgrade{upgrade}
+	} else if ctx.GlobalIsSet(flags.ChunkLimitsHeight.Name) {
+		return errors.New("chunk.limitsHeight is set without any chunk limit")
+	}
 	return nil
 }
@@ -0,0 +1,98 @@
+package node
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/morph-l2/bindings/bindings"
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+)
+
+// govParamsReader is the part of the Gov contract the batch params are read from.
+type govParamsReader interface {
+	BatchBlockInterval(opts *bind.CallOpts) (*big.Int, error)
+	BatchMaxBytes(opts *bind.CallOpts) (*big.Int, error)
+	BatchTimeout(opts *bind.CallOpts) (*big.Int, error)
+	MaxChunks(opts *bind.CallOpts) (*big.Int, error)
+}
+
+// govStorageReader reads the storage root of the Gov contract, the gov params are refreshed when it changes.
+type govStorageReader interface {
+	StorageRoot(ctx context.Context, account common.Address, blockNumber *big.Int) (common.Hash, error)
+}
+
+// contractChanges tells which of the contracts the consensus parameters are read from may have changed in a block.
+type contractChanges struct {
+	sequencerSet bool
+	govParams    bool
+	// govStorageRoot is the storage root of the Gov contract after the block, zero if it could not be read
+	govStorageRoot common.Hash
+}
+
+// detectContractChanges finds the blocks which may change the sequencer set or the gov params.
+// The sequencer set changes with a SequencerUpdated event of the L2Sequencer contract, found in the logs bloom of the
+// block without calling geth. The Gov contract emits no event on parameter changes, and they may be made by an
+// internal call, so the storage root of the Gov contract is read for every block and compared with the one the
+// gov params were read at. Proposals and votes change the storage root as well, which only costs a refresh.
+func (e *Executor) detectContractChanges(block *types.WrappedBlock) contractChanges {
+	var changes contractChanges
+	if len(block.LogsBloom) != eth.BloomByteLength {
+		e.logger.Error("unexpected logs bloom length, refresh the sequencer set", "height", block.Number, "length", len(block.LogsBloom))
+		changes.sequencerSet = true
+	} else {
+		bloom := eth.BytesToBloom(block.LogsBloom)
+		changes.sequencerSet = eth.BloomLookup(bloom, e.sequencerAddress) && eth.BloomLookup(bloom, e.sequencerUpdatedTopic)
+	}
+
+	root, err := e.govStorage.StorageRoot(context.Background(), e.govAddress, new(big.Int).SetUint64(block.Number))
+	if err != nil {
+		e.logger.Error("failed to read the storage root of the Gov contract, refresh the gov params", "height", block.Number, "err", err)
+		changes.govParams = true
+		return changes
+	}
+	changes.govParams = root != e.govStorageRoot
+	changes.govStorageRoot = root
+	return changes
+}
+
+// consensusUpdates returns the batch params changed by the block, nil if they did not change,
+// and the sequencer set following the block. The contracts are only called when the block may have changed them,
+// and for the first block delivered, which sets the parameters of the consensus.
+func (e *Executor) consensusUpdates(block *types.WrappedBlock) (*tmproto.BatchParams, [][]byte, error) {
+	changes := e.detectContractChanges(block)
+	if !e.consensusParamsLoaded {
+		changes.sequencerSet, changes.govParams = true, true
+	}
+	validators := e.nextValidators
+	if changes.sequencerSet {
+		var err error
+		if validators, err = e.updateSequencerSet(&block.Number); err != nil {
+			return nil, nil, err
+		}
+		e.metrics.ConsensusParamsRefreshed.With("contract", "sequencer").Add(1)
+	}
+	var batchParams *tmproto.BatchParams
+	if changes.govParams {
+		var err error
+		if batchParams, err = e.batchParamsUpdates(block.Number); err != nil {
+			return nil, nil, err
+		}
+		// the root is only cached once the gov params are read at it
+		e.govStorageRoot = changes.govStorageRoot
+		e.metrics.ConsensusParamsRefreshed.With("contract", "gov").Add(1)
+	}
+	e.consensusParamsLoaded = true
+	return batchParams, validators, nil
+}
+
+func sequencerUpdatedTopic() (common.Hash, error) {
+	sequencerAbi, err := bindings.L2SequencerMetaData.GetAbi()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return sequencerAbi.Events["SequencerUpdated"].ID, nil
+}
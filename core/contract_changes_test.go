@@ -0,0 +1,172 @@
+package node
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/morph-l2/bindings/bindings"
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/crypto/bls12381"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+)
+
+// testGovContract is a Gov contract whose params[i] are set at heights[i].
+// Its storage changes at the writes heights as well, the proposals and votes which do not change the params.
+type testGovContract struct {
+	heights []uint64
+	params  []tmproto.BatchParams
+	writes  []uint64
+	latest  uint64
+	calls   int
+}
+
+func (g *testGovContract) StorageRoot(_ context.Context, _ common.Address, blockNumber *big.Int) (common.Hash, error) {
+	var root common.Hash
+	for _, heights := range [][]uint64{g.heights, g.writes} {
+		for _, h := range heights {
+			if h <= blockNumber.Uint64() {
+				root = crypto.Keccak256Hash(root[:], new(big.Int).SetUint64(h).Bytes())
+			}
+		}
+	}
+	return root, nil
+}
+
+func (g *testGovContract) at(opts *bind.CallOpts) tmproto.BatchParams {
+	g.calls++
+	height := g.latest
+	if opts != nil && opts.BlockNumber != nil {
+		height = opts.BlockNumber.Uint64()
+	}
+	var params tmproto.BatchParams
+	for i, h := range g.heights {
+		if h <= height {
+			params = g.params[i]
+		}
+	}
+	return params
+}
+
+func (g *testGovContract) BatchBlockInterval(opts *bind.CallOpts) (*big.Int, error) {
+	return big.NewInt(g.at(opts).BlocksInterval), nil
+}
+
+func (g *testGovContract) BatchMaxBytes(opts *bind.CallOpts) (*big.Int, error) {
+	return big.NewInt(g.at(opts).MaxBytes), nil
+}
+
+func (g *testGovContract) BatchTimeout(opts *bind.CallOpts) (*big.Int, error) {
+	return big.NewInt(int64(g.at(opts).Timeout / time.Second)), nil
+}
+
+func (g *testGovContract) MaxChunks(opts *bind.CallOpts) (*big.Int, error) {
+	return big.NewInt(g.at(opts).MaxChunks), nil
+}
+
+func TestConsensusUpdatesMatchPolling(t *testing.T) {
+	_, sequencers := testSequencerSet(t, 6)
+	sequencerAddress, govAddress := common.Address{0x53}, common.Address{0x60}
+	updatedTopic, err := sequencerUpdatedTopic()
+	require.NoError(t, err)
+
+	// the sequencer set changes at 20 and 41, the gov params at 15, 33 and 50, the gov storage at 25 as well
+	sequencerContract := &testSequencerContract{versionHeights: []uint64{0, 20, 41}}
+	for v := range sequencerContract.versionHeights {
+		var infos []bindings.TypesSequencerInfo
+		for _, s := range sequencers[v : v+3] {
+			infos = append(infos, bindings.TypesSequencerInfo{TmKey: s.tmKey, BlsKey: new(bls12381.G2).EncodePoint(s.blsPub.Key)})
+		}
+		sequencerContract.infos = append(sequencerContract.infos, infos)
+	}
+	govContract := &testGovContract{
+		heights: []uint64{0, 15, 33, 50},
+		params: []tmproto.BatchParams{
+			{BlocksInterval: 10, MaxBytes: 1000, Timeout: time.Minute, MaxChunks: 15},
+			{BlocksInterval: 20, MaxBytes: 1000, Timeout: time.Minute, MaxChunks: 15},
+			{BlocksInterval: 20, MaxBytes: 2000, Timeout: time.Minute, MaxChunks: 15},
+			{BlocksInterval: 20, MaxBytes: 2000, Timeout: 2 * time.Minute, MaxChunks: 30},
+		},
+		writes: []uint64{25},
+	}
+	newExecutor := func() *Executor {
+		e := &Executor{
+			sequencerContract:     sequencerContract,
+			govContract:           govContract,
+			govStorage:            govContract,
+			sequencerAddress:      sequencerAddress,
+			govAddress:            govAddress,
+			sequencerUpdatedTopic: updatedTopic,
+			logger:                tmlog.NewNopLogger(),
+			metrics:               NopMetrics(),
+		}
+		_, err := e.updateSequencerSet(nil)
+		require.NoError(t, err)
+		return e
+	}
+	polling, events := newExecutor(), newExecutor()
+
+	var pollingCalls, eventCalls int
+	for height := uint64(1); height <= 60; height++ {
+		sequencerContract.latest, govContract.latest = height, height
+		// the gov params change without a log of the Gov contract, by a transaction sent to it or by an internal call,
+		// which is not found in the block
+		var bloom eth.Bloom
+		switch height {
+		case 20, 41:
+			// the update comes from L1, through the messenger
+			bloom.Add(sequencerAddress.Bytes())
+			bloom.Add(updatedTopic.Bytes())
+		case 25:
+			// a log of the L2Sequencer contract which is not a sequencer set update
+			bloom.Add(sequencerAddress.Bytes())
+		default:
+			bloom.Add(common.Address{3}.Bytes())
+		}
+		block := &types.WrappedBlock{Number: height, LogsBloom: bloom.Bytes()}
+
+		calls := sequencerContract.calls + govContract.calls
+		wantValidators, err := polling.updateSequencerSet(&height)
+		require.NoError(t, err)
+		wantParams, err := polling.batchParamsUpdates(height)
+		require.NoError(t, err)
+		pollingCalls += sequencerContract.calls + govContract.calls - calls
+
+		calls = sequencerContract.calls + govContract.calls
+		params, validators, err := events.consensusUpdates(block)
+		require.NoError(t, err)
+		eventCalls += sequencerContract.calls + govContract.calls - calls
+
+		require.Equal(t, wantParams, params, "height %d", height)
+		require.Equal(t, wantValidators, validators, "height %d", height)
+		require.Equal(t, polling.currentSequencerSet.version, events.currentSequencerSet.version, "height %d", height)
+		require.Equal(t, polling.batchParams, events.batchParams, "height %d", height)
+	}
+	require.EqualValues(t, 2, events.currentSequencerSet.version)
+	require.Len(t, events.previousSequencerSet, 2)
+	require.Less(t, eventCalls*5, pollingCalls)
+}
+
+func TestDetectContractChanges(t *testing.T) {
+	gov := &testGovContract{heights: []uint64{0, 5}}
+	e := &Executor{govAddress: common.Address{1}, sequencerAddress: common.Address{2}, govStorage: gov, logger: tmlog.NewNopLogger()}
+	root, err := gov.StorageRoot(context.Background(), e.govAddress, big.NewInt(4))
+	require.NoError(t, err)
+	e.govStorageRoot = root
+	// an unexpected bloom refreshes the sequencer set
+	require.Equal(t, contractChanges{sequencerSet: true, govStorageRoot: root}, e.detectContractChanges(&types.WrappedBlock{Number: 4, LogsBloom: []byte{1}}))
+	var bloom eth.Bloom
+	require.Equal(t, contractChanges{govStorageRoot: root}, e.detectContractChanges(&types.WrappedBlock{Number: 4, LogsBloom: bloom.Bytes()}))
+	// the gov params changed by an internal call, the block has neither a log of the Gov contract nor a transaction to it
+	changes := e.detectContractChanges(&types.WrappedBlock{Number: 5, LogsBloom: bloom.Bytes()})
+	require.True(t, changes.govParams)
+	require.False(t, changes.sequencerSet)
+	require.NotEqual(t, root, changes.govStorageRoot)
+}
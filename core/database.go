@@ -0,0 +1,26 @@
+package node
+
+import "github.com/morph-l2/node/types"
+
+// Database is the store of the executor.
+type Database interface {
+	BatchStore
+	SequencerSetStore
+}
+
+// BatchStore persists the batches sealed by the executor and how far L1 is tracked for them.
+type BatchStore interface {
+	WriteBatchRecord(record types.BatchRecord)
+	ReadBatchRecord(batchIndex uint64) *types.BatchRecord
+	ReadBatchRecords(fromIndex uint64, limit int) []types.BatchRecord
+	ReadBatchTrackerL1Height() *uint64
+	WriteBatchTrackerL1Height(height uint64)
+	ReadBatchTrackerFetchBlockRange() *uint64
+	WriteBatchTrackerFetchBlockRange(blockRange uint64)
+}
+
+// SequencerSetStore persists the history of the sequencer sets.
+type SequencerSetStore interface {
+	WriteSequencerSet(set types.SequencerSet)
+	ReadSequencerSets() []types.SequencerSet
+}
@@ -5,10 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
-	"strings"
 	"time"
 
 	"github.com/morph-l2/bindings/bindings"
+	nodecommon "github.com/morph-l2/node/common"
 	"github.com/morph-l2/node/sync"
 	"github.com/morph-l2/node/types"
 	"github.com/scroll-tech/go-ethereum/accounts/abi"
@@ -17,8 +17,6 @@ import (
 	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	eth "github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/eth/catalyst"
-	"github.com/scroll-tech/go-ethereum/ethclient"
-	"github.com/scroll-tech/go-ethereum/ethclient/authclient"
 	"github.com/scroll-tech/go-ethereum/rlp"
 	"github.com/tendermint/tendermint/crypto"
 	"github.com/tendermint/tendermint/l2node"
@@ -26,7 +24,8 @@ import (
 	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
 )
 
-type NewSyncerFunc func() (*sync.Syncer, error)
+// NewSyncerFunc creates the syncer, reading L1 through l1Client when it is not nil.
+type NewSyncerFunc func(l1Client *nodecommon.QuorumClient) (*sync.Syncer, error)
 
 type Executor struct {
 	l2Client            *types.RetryableClient
@@ -34,14 +33,29 @@ type Executor struct {
 	nextL1MsgIndex      uint64
 	maxL1MsgNumPerBlock uint64
 	l1MsgReader         types.L1MessageReader
+	l1MsgPolicy         L1MessagePolicy
+	// latestGasLimit is the gas limit of the latest block, the L1 messages of the next one are budgeted against it
+	latestGasLimit uint64
 
 	newSyncerFunc NewSyncerFunc
 	syncer        *sync.Syncer
-
-	govContract       *bindings.Gov
-	sequencerContract *bindings.L2Sequencer
-
-	currentSequencerSet  *SequencerSetInfo
+	// l1Client is shared by the syncer and the batch tracker, it is nil if L1 is not configured
+	l1Client *nodecommon.QuorumClient
+
+	govContract       govParamsReader
+	sequencerContract sequencerSetReader
+	sequencerSets     SequencerSetStore
+
+	// the sequencer set is refreshed on its update events, the gov params when the Gov storage root changes
+	govStorage            govStorageReader
+	govStorageRoot        common.Hash
+	govAddress            common.Address
+	sequencerAddress      common.Address
+	sequencerUpdatedTopic common.Hash
+	consensusParamsLoaded bool
+
+	currentSequencerSet *SequencerSetInfo
+	// previousSequencerSet lists all the sequencer sets before the current one, in version order
 	previousSequencerSet []SequencerSetInfo
 
 	nextValidators [][]byte
@@ -50,62 +64,57 @@ type Executor struct {
 	isSequencer    bool
 	devSequencer   bool
 
-	rollupABI     *abi.ABI
-	batchingCache *BatchingCache
+	rollupABI      *abi.ABI
+	batchVersions  *types.BatchVersionSchedule
+	batchCheck     bool
+	chunkLimits    *types.ChunkLimitsSchedule
+	batchingCache  *BatchingCache
+	chunkClosings  chunkClosings
+	blsCollections blsCollections
+	batchRecords   *batchRecords
+	batchTracker   *batchTracker
 
 	logger  tmlog.Logger
 	metrics *Metrics
 }
 
-func getNextL1MsgIndex(client *ethclient.Client, logger tmlog.Logger) (uint64, error) {
-	currentHeader, err := client.HeaderByNumber(context.Background(), nil)
+// NewExecutor creates the executor. The batches it seals and the sequencer sets are recorded in the store
+// if one is given, and the batches are tracked on L1 if the L1 and the Rollup contract are configured as well.
+func NewExecutor(newSyncFunc NewSyncerFunc, config *Config, tmPubKey crypto.PubKey, store Database) (*Executor, error) {
+	logger := config.Logger
+	logger = logger.With("module", "executor")
+	l2Client, err := types.DialRetryableClient(context.Background(), config.L2, config.Logger)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	currentHeader, err := l2Client.HeaderByNumber(context.Background(), nil)
 	if err != nil {
-		var count = 0
-		for err != nil && strings.Contains(err.Error(), "connection refused") {
-			time.Sleep(5 * time.Second)
-			count++
-			logger.Error("connection refused, try again", "retryCount", count)
-			currentHeader, err = client.HeaderByNumber(context.Background(), nil)
-		}
-		if err != nil {
-			logger.Error("failed to get currentHeader", "error", err)
-			return 0, fmt.Errorf("failed to get currentHeader, err: %v", err)
-		}
+		return nil, fmt.Errorf("failed to get currentHeader, err: %v", err)
 	}
 
-	return currentHeader.NextL1MsgIndex, nil
-}
-
-func NewExecutor(newSyncFunc NewSyncerFunc, config *Config, tmPubKey crypto.PubKey) (*Executor, error) {
-	logger := config.Logger
-	logger = logger.With("module", "executor")
-	aClient, err := authclient.DialContext(context.Background(), config.L2.EngineAddr, config.L2.JwtSecret)
+	// the contract reads fail over with the other L2 reads, from the current primary geth on
+	sequencer, err := bindings.NewL2SequencerCaller(config.L2SequencerAddress, l2Client)
 	if err != nil {
 		return nil, err
 	}
-	eClient, err := ethclient.Dial(config.L2.EthAddr)
+	gov, err := bindings.NewGovCaller(config.L2GovAddress, l2Client)
 	if err != nil {
 		return nil, err
 	}
 
-	index, err := getNextL1MsgIndex(eClient, logger)
+	rollupAbi, err := bindings.RollupMetaData.GetAbi()
 	if err != nil {
 		return nil, err
 	}
-
-	sequencer, err := bindings.NewL2Sequencer(config.L2SequencerAddress, eClient)
+	updatedTopic, err := sequencerUpdatedTopic()
 	if err != nil {
 		return nil, err
 	}
-	gov, err := bindings.NewGov(config.L2GovAddress, eClient)
+	batchVersions, err := types.NewBatchVersionSchedule(config.BatchVersionUpgrades...)
 	if err != nil {
 		return nil, err
 	}
-
-	rollupAbi, err := bindings.RollupMetaData.GetAbi()
+	chunkLimits, err := types.NewChunkLimitsSchedule(config.ChunkLimitsUpgrades...)
 	if err != nil {
 		return nil, err
 	}
@@ -114,23 +123,47 @@ func NewExecutor(newSyncFunc NewSyncerFunc, config *Config, tmPubKey crypto.PubK
 		tmPubKeyBytes = tmPubKey.Bytes()
 	}
 	executor := &Executor{
-		l2Client:            types.NewRetryableClient(aClient, eClient, config.Logger),
-		bc:                  &Version1Converter{},
-		sequencerContract:   sequencer,
-		govContract:         gov,
-		tmPubKey:            tmPubKeyBytes,
-		nextL1MsgIndex:      index,
-		maxL1MsgNumPerBlock: config.MaxL1MessageNumPerBlock,
-		newSyncerFunc:       newSyncFunc,
-		devSequencer:        config.DevSequencer,
-		rollupABI:           rollupAbi,
-		batchingCache:       NewBatchingCache(),
-		logger:              logger,
-		metrics:             PrometheusMetrics("morphnode"),
+		l2Client:              l2Client,
+		bc:                    &Version1Converter{},
+		sequencerContract:     sequencer,
+		govContract:           gov,
+		govStorage:            l2Client,
+		govAddress:            config.L2GovAddress,
+		sequencerAddress:      config.L2SequencerAddress,
+		sequencerUpdatedTopic: updatedTopic,
+		tmPubKey:              tmPubKeyBytes,
+		nextL1MsgIndex:        currentHeader.NextL1MsgIndex,
+		latestGasLimit:        currentHeader.GasLimit,
+		maxL1MsgNumPerBlock:   config.MaxL1MessageNumPerBlock,
+		l1MsgPolicy:           NewGasBudgetPolicy(config.L1MessageGasBudget, config.L1MessageGasLimitFraction, config.L1MessageMaxAge),
+		newSyncerFunc:         newSyncFunc,
+		devSequencer:          config.DevSequencer,
+		rollupABI:             rollupAbi,
+		batchVersions:         batchVersions,
+		batchCheck:            config.BatchSelfCheck,
+		chunkLimits:           chunkLimits,
+		batchingCache:         NewBatchingCache(),
+		logger:                logger,
+		metrics:               PrometheusMetrics("morphnode"),
+	}
+	if len(config.L1.Addrs) > 0 {
+		if executor.l1Client, err = nodecommon.DialQuorumClient(config.L1.Addrs, config.L1.Quorum, config.Logger); err != nil {
+			return nil, err
+		}
+	}
+	if store != nil {
+		executor.sequencerSets = store
+		executor.batchRecords = newBatchRecords(store, executor.metrics, logger)
+		if config.RollupAddress != nil && executor.l1Client != nil {
+			if executor.batchTracker, err = newBatchTracker(executor.batchRecords, executor.l1Client, config, logger); err != nil {
+				return nil, err
+			}
+			executor.batchTracker.Start()
+		}
 	}
 
 	if config.DevSequencer {
-		executor.syncer, err = executor.newSyncerFunc()
+		executor.syncer, err = executor.newSyncerFunc(executor.l1Client)
 		if err != nil {
 			return nil, err
 		}
@@ -154,25 +187,15 @@ func (e *Executor) RequestBlockData(height int64) (txs [][]byte, blockMeta []byt
 		return
 	}
 	e.logger.Info("RequestBlockData request", "height", height)
-	// read the l1 messages
-	fromIndex := e.nextL1MsgIndex
-	l1Messages := e.l1MsgReader.ReadL1MessagesInRange(fromIndex, fromIndex+e.maxL1MsgNumPerBlock-1)
-	transactions := make(eth.Transactions, len(l1Messages))
-
-	if len(l1Messages) > 0 {
-		queueIndex := fromIndex
-		for i, l1Message := range l1Messages {
-			transaction := eth.NewTx(&l1Message.L1MessageTx)
-			transactions[i] = transaction
-			if queueIndex != l1Message.QueueIndex {
-				e.logger.Error("unexpected l1message queue index", "expected", queueIndex, "actual", l1Message.QueueIndex)
-				err = types.ErrInvalidL1MessageOrder
-				return
-			}
-			queueIndex++
-		}
-		collectedL1Msgs = true
+	l1Messages, included, err := e.selectL1Messages(L1MessageBlockContext{Now: time.Now(), GasLimit: e.latestGasLimit})
+	if err != nil {
+		return
 	}
+	transactions := make(eth.Transactions, len(included))
+	for i, l1Message := range included {
+		transactions[i] = eth.NewTx(&l1Message.L1MessageTx)
+	}
+	collectedL1Msgs = len(l1Messages) > 0
 
 	l2Block, err := e.l2Client.AssembleL2Block(context.Background(), big.NewInt(height), transactions)
 	if err != nil {
@@ -325,15 +348,14 @@ func (e *Executor) DeliverBlock(txs [][]byte, metaData []byte, consensusData l2n
 	}
 
 	// end block
+	e.observeL1MessageLatency(l2Block)
 	e.updateNextL1MessageIndex(l2Block)
+	e.latestGasLimit = l2Block.GasLimit
 
 	var newValidatorSet = consensusData.ValidatorSet
 	var newBatchParams *tmproto.BatchParams
 	if !e.devSequencer {
-		if newValidatorSet, err = e.updateSequencerSet(&l2Block.Number); err != nil {
-			return nil, nil, err
-		}
-		if newBatchParams, err = e.batchParamsUpdates(l2Block.Number); err != nil {
+		if newBatchParams, newValidatorSet, err = e.consensusUpdates(wrappedBlock); err != nil {
 			return nil, nil, err
 		}
 	}
@@ -398,7 +420,11 @@ func (e *Executor) getParamsAndValsAtHeight(height int64) (*tmproto.BatchParams,
 		e.logger.Error("failed to call GetSequencerInfos", "previous", false, "height", height, "err", err)
 		return nil, nil, err
 	}
-	newValidators, _, err := e.convertSequencerSet(sequencersInfo)
+	infos := make([]types.SequencerInfo, len(sequencersInfo))
+	for i, info := range sequencersInfo {
+		infos[i] = types.SequencerInfo(info)
+	}
+	newValidators, _, err := e.convertSequencerSet(infos)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -410,6 +436,11 @@ func (e *Executor) getParamsAndValsAtHeight(height int64) (*tmproto.BatchParams,
 	}, newValidators, nil
 }
 
+// Stop stops tracking the sealed batches on L1.
+func (e *Executor) Stop() {
+	e.batchTracker.Stop()
+}
+
 func (e *Executor) L2Client() *types.RetryableClient {
 	return e.l2Client
 }
@@ -16,6 +16,43 @@ func (e *Executor) updateNextL1MessageIndex(l2Block *catalyst.ExecutableL2Data)
 
 }
 
+// selectL1Messages reads the next L1 messages of the queue, at most maxL1MsgNumPerBlock of them, and the ones
+// the inclusion policy picks for the block. All the candidates are collected in the block meta,
+// so that other nodes can check the skipped ones are genuine.
+func (e *Executor) selectL1Messages(block L1MessageBlockContext) (candidates, included []types.L1Message, err error) {
+	fromIndex := e.nextL1MsgIndex
+	candidates = e.l1MsgReader.ReadL1MessagesInRange(fromIndex, fromIndex+e.maxL1MsgNumPerBlock-1)
+	for i, l1Message := range candidates {
+		if expected := fromIndex + uint64(i); l1Message.QueueIndex != expected {
+			e.logger.Error("unexpected l1message queue index", "expected", expected, "actual", l1Message.QueueIndex)
+			return nil, nil, types.ErrInvalidL1MessageOrder
+		}
+	}
+	included = candidates
+	if e.l1MsgPolicy != nil && len(candidates) > 0 {
+		included = e.l1MsgPolicy.Select(candidates, block)
+		if len(included) < len(candidates) {
+			e.logger.Info("L1 messages left out by the inclusion policy", "candidates", len(candidates), "included", len(included), "blockGasLimit", block.GasLimit)
+		}
+	}
+	return candidates, included, nil
+}
+
+// observeL1MessageLatency records how long the L1 messages consumed by l2Block waited since their L1 block.
+// Only the messages synced by the local syncer are known, so nodes without a syncer record nothing.
+func (e *Executor) observeL1MessageLatency(l2Block *catalyst.ExecutableL2Data) {
+	if e.syncer == nil {
+		return
+	}
+	for index := e.nextL1MsgIndex; index < l2Block.NextL1MessageIndex; index++ {
+		l1Time, ok := e.syncer.TakeL1MessageTime(index)
+		if !ok || l1Time > l2Block.Timestamp {
+			continue
+		}
+		e.metrics.L1MessageInclusionLatency.Observe(float64(l2Block.Timestamp - l1Time))
+	}
+}
+
 // validateL1Messages has the constraints
 // 1. all the collected L1 messages are valid(compared to the L1Message on layer1).
 // 2. the collected L1 messages are sequenced correctly.
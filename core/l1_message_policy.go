@@ -0,0 +1,90 @@
+package node
+
+import (
+	"time"
+
+	"github.com/morph-l2/node/types"
+)
+
+// DefaultL1MessageGasLimitFraction is the share of the block gas limit L1 messages may use by default,
+// 0 leaves the share unlimited until operators opt in.
+const DefaultL1MessageGasLimitFraction = 0
+
+// L1MessageBlockContext describes the block the L1 messages are selected for.
+type L1MessageBlockContext struct {
+	Now time.Time
+	// GasLimit of the block, as of the latest L2 header. 0 if unknown.
+	GasLimit uint64
+}
+
+// L1MessagePolicy decides which of the queued L1 messages the sequencer includes in the block it proposes.
+type L1MessagePolicy interface {
+	// Select returns the messages to include, in queue order, out of candidates, the next messages of the queue.
+	// A message left out before an included one is skipped: it is never executed and is marked in the
+	// skipped L1 message bitmap of the batch. Messages after the last included one stay in the queue.
+	Select(candidates []types.L1Message, block L1MessageBlockContext) []types.L1Message
+}
+
+// GasBudgetPolicy includes L1 messages in queue order as long as their summed gas limits fit in the budget of a block.
+// The budget is GasBudget, capped by GasLimitFraction of the block gas limit, so that a burst of high-gas
+// deposits does not crowd out L2 transactions.
+// A message whose gas limit exceeds GasBudget can never be included and is skipped. A message exceeding only
+// the share of the block gas limit is included alone in a block.
+// A message older than MaxAge is included regardless of the budget, so that no message is delayed forever.
+type GasBudgetPolicy struct {
+	// GasBudget of 0 does not limit the gas of L1 messages.
+	GasBudget uint64
+	// GasLimitFraction of 0 does not limit the share of the block gas limit.
+	GasLimitFraction float64
+	// MaxAge of 0 never forces the inclusion.
+	MaxAge time.Duration
+}
+
+func NewGasBudgetPolicy(gasBudget uint64, gasLimitFraction float64, maxAge time.Duration) *GasBudgetPolicy {
+	return &GasBudgetPolicy{
+		GasBudget:        gasBudget,
+		GasLimitFraction: gasLimitFraction,
+		MaxAge:           maxAge,
+	}
+}
+
+func (p *GasBudgetPolicy) Select(candidates []types.L1Message, block L1MessageBlockContext) []types.L1Message {
+	var (
+		selected []types.L1Message
+		gasUsed  uint64
+		budget   = p.budget(block.GasLimit)
+	)
+	for _, msg := range candidates {
+		if !p.forced(msg, block.Now) {
+			if p.GasBudget > 0 && msg.Gas > p.GasBudget {
+				continue
+			}
+			if budget > 0 && gasUsed > 0 && gasUsed+msg.Gas > budget {
+				break
+			}
+		}
+		selected = append(selected, msg)
+		gasUsed += msg.Gas
+	}
+	return selected
+}
+
+// budget returns the gas L1 messages may use in a block of gasLimit, 0 means unlimited.
+func (p *GasBudgetPolicy) budget(gasLimit uint64) uint64 {
+	budget := p.GasBudget
+	if p.GasLimitFraction > 0 && gasLimit > 0 {
+		share := uint64(p.GasLimitFraction * float64(gasLimit))
+		if budget == 0 || share < budget {
+			budget = share
+		}
+	}
+	return budget
+}
+
+// forced reports whether msg waited longer than MaxAge since its L1 block.
+func (p *GasBudgetPolicy) forced(msg types.L1Message, now time.Time) bool {
+	if p.MaxAge == 0 || msg.L1BlockTime == 0 {
+		return false
+	}
+	return now.Sub(time.Unix(int64(msg.L1BlockTime), 0)) >= p.MaxAge
+}
@@ -0,0 +1,182 @@
+package node
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/morph-l2/node/db"
+	"github.com/morph-l2/node/sync"
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/eth/catalyst"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmtypes "github.com/tendermint/tendermint/types"
+)
+
+func testPolicyMessages(l1BlockTime uint64, gasLimits ...uint64) []types.L1Message {
+	to := common.BigToAddress(big.NewInt(1))
+	msgs := make([]types.L1Message, len(gasLimits))
+	for i, gas := range gasLimits {
+		msgs[i] = types.L1Message{
+			L1MessageTx: eth.L1MessageTx{
+				QueueIndex: uint64(i),
+				Gas:        gas,
+				To:         &to,
+				Value:      big.NewInt(0),
+				Sender:     common.BigToAddress(big.NewInt(int64(i))),
+			},
+			L1BlockTime: l1BlockTime,
+		}
+	}
+	return msgs
+}
+
+func queueIndexes(msgs []types.L1Message) []uint64 {
+	indexes := make([]uint64, len(msgs))
+	for i, msg := range msgs {
+		indexes[i] = msg.QueueIndex
+	}
+	return indexes
+}
+
+func TestGasBudgetPolicy(t *testing.T) {
+	now := time.Unix(10000, 0)
+	candidates := testPolicyMessages(uint64(now.Unix())-60, 30000, 200000, 40000, 50000, 10000)
+
+	// no budget, everything is included
+	require.Equal(t, []uint64{0, 1, 2, 3, 4}, queueIndexes(NewGasBudgetPolicy(0, 0, 0).Select(candidates, L1MessageBlockContext{Now: now})))
+
+	// 1 never fits and is skipped, 3 does not fit anymore and waits for the next block with 4
+	require.Equal(t, []uint64{0, 2}, queueIndexes(NewGasBudgetPolicy(100000, 0, 0).Select(candidates, L1MessageBlockContext{Now: now})))
+
+	// not old enough to be forced
+	require.Equal(t, []uint64{0, 2}, queueIndexes(NewGasBudgetPolicy(100000, 0, time.Hour).Select(candidates, L1MessageBlockContext{Now: now})))
+
+	// old messages are included regardless of the budget
+	require.Equal(t, []uint64{0, 1, 2, 3, 4}, queueIndexes(NewGasBudgetPolicy(100000, 0, time.Minute).Select(candidates, L1MessageBlockContext{Now: now})))
+
+	// the age of messages with an unknown L1 block time is unknown, they are never forced
+	unknown := testPolicyMessages(0, 30000, 200000)
+	require.Equal(t, []uint64{0}, queueIndexes(NewGasBudgetPolicy(100000, 0, time.Minute).Select(unknown, L1MessageBlockContext{Now: now})))
+}
+
+func TestGasBudgetPolicyGasLimitFraction(t *testing.T) {
+	now := time.Unix(10000, 0)
+	block := L1MessageBlockContext{Now: now, GasLimit: 200000}
+	candidates := testPolicyMessages(uint64(now.Unix())-60, 30000, 40000, 50000)
+
+	// half of the block gas limit
+	require.Equal(t, []uint64{0, 1}, queueIndexes(NewGasBudgetPolicy(0, 0.5, 0).Select(candidates, block)))
+	// the smaller of the budget and the share applies
+	require.Equal(t, []uint64{0}, queueIndexes(NewGasBudgetPolicy(50000, 0.5, 0).Select(candidates, block)))
+	// the share does not apply if the gas limit is unknown
+	require.Equal(t, []uint64{0, 1, 2}, queueIndexes(NewGasBudgetPolicy(0, 0.5, 0).Select(candidates, L1MessageBlockContext{Now: now})))
+	// forced messages ignore the share as well
+	require.Equal(t, []uint64{0, 1, 2}, queueIndexes(NewGasBudgetPolicy(0, 0.5, time.Minute).Select(candidates, block)))
+
+	// a message exceeding the share alone is not skipped but included on its own
+	large := testPolicyMessages(0, 150000, 10000)
+	require.Equal(t, []uint64{0}, queueIndexes(NewGasBudgetPolicy(0, 0.5, 0).Select(large, block)))
+	require.Equal(t, []uint64{1}, queueIndexes(NewGasBudgetPolicy(0, 0.5, 0).Select(large[1:], block)))
+}
+
+func TestSelectL1MessagesWithFakeSyncer(t *testing.T) {
+	store := db.NewMemoryStore()
+	msgs := testPolicyMessages(0, 400000, 500000, 300000, 100000, 20000000)
+	require.NoError(t, store.WriteSyncedL1Messages(msgs, 1))
+
+	e := &Executor{
+		maxL1MsgNumPerBlock: 4,
+		l1MsgReader:         sync.NewFakeSyncer(store),
+		l1MsgPolicy:         NewGasBudgetPolicy(10000000, 0.5, 0),
+		logger:              tmlog.NewNopLogger(),
+	}
+	block := L1MessageBlockContext{Now: time.Now(), GasLimit: 2000000}
+
+	// a burst of deposits is capped at half of the 2M gas limit, at most 4 messages are candidates
+	candidates, included, err := e.selectL1Messages(block)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 1, 2, 3}, queueIndexes(candidates))
+	require.Equal(t, []uint64{0, 1}, queueIndexes(included))
+
+	e.nextL1MsgIndex = 2
+	_, included, err = e.selectL1Messages(block)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2, 3}, queueIndexes(included))
+
+	// 4 exceeds the absolute budget and is skipped once a later message is included
+	require.NoError(t, store.WriteSyncedL1Messages(testPolicyMessages(0, 0, 0, 0, 0, 0, 30000)[5:], 2))
+	e.nextL1MsgIndex = 4
+	_, included, err = e.selectL1Messages(block)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{5}, queueIndexes(included))
+
+	// nothing queued
+	e.nextL1MsgIndex = 6
+	candidates, included, err = e.selectL1Messages(block)
+	require.NoError(t, err)
+	require.Empty(t, candidates)
+	require.Empty(t, included)
+}
+
+func TestSelectL1MessagesWithHeldSync(t *testing.T) {
+	// the deposit of queue index 2 is malformed: the syncer keeps the messages before its L1 block only
+	store := db.NewMemoryStore()
+	msgs := testPolicyMessages(0, 21000, 21000, 21000, 21000)
+	require.NoError(t, store.WriteSyncedL1Messages(msgs[:2], 4))
+
+	e := &Executor{
+		maxL1MsgNumPerBlock: 4,
+		l1MsgReader:         sync.NewFakeSyncer(store),
+		logger:              tmlog.NewNopLogger(),
+	}
+	block := L1MessageBlockContext{Now: time.Now()}
+
+	candidates, included, err := e.selectL1Messages(block)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{0, 1}, queueIndexes(included))
+	txs := make([][]byte, len(included))
+	for i, msg := range included {
+		txs[i], err = eth.NewTx(&msg.L1MessageTx).MarshalBinary()
+		require.NoError(t, err)
+	}
+	require.NoError(t, e.validateL1Messages(&catalyst.ExecutableL2Data{NextL1MessageIndex: 2, Transactions: txs}, candidates))
+
+	// the following blocks are built without L1 messages until the deposit is synced
+	e.nextL1MsgIndex = 2
+	candidates, included, err = e.selectL1Messages(block)
+	require.NoError(t, err)
+	require.Empty(t, included)
+	require.NoError(t, e.validateL1Messages(&catalyst.ExecutableL2Data{NextL1MessageIndex: 2}, candidates))
+
+	// a hole in the queue would stop the block production instead
+	require.NoError(t, store.WriteSyncedL1Messages(msgs[3:], 6))
+	_, _, err = e.selectL1Messages(block)
+	require.ErrorIs(t, err, types.ErrInvalidL1MessageOrder)
+}
+
+func TestGasBudgetPolicySkippedBitmap(t *testing.T) {
+	// 1 and 3 exceed the budget on their own
+	candidates := testPolicyMessages(0, 30000, 200000, 40000, 300000, 10000, 500000)
+	included := NewGasBudgetPolicy(100000, 0, 0).Select(candidates, L1MessageBlockContext{Now: time.Now()})
+	require.Equal(t, []uint64{0, 2, 4}, queueIndexes(included))
+
+	txs := make(tmtypes.Txs, len(included))
+	for i, msg := range included {
+		bz, err := eth.NewTx(&msg.L1MessageTx).MarshalBinary()
+		require.NoError(t, err)
+		txs[i] = bz
+	}
+	_, _, totalL1MessagePopped, skippedBitmap, l2TxNum, err := ParsingTxs(txs, 0, 0, nil)
+	require.NoError(t, err)
+	require.Zero(t, l2TxNum)
+	// 5 is after the last included message, it stays in the queue
+	require.EqualValues(t, 5, totalL1MessagePopped)
+	require.Equal(t, 1, skippedBitmap.Words())
+	require.Equal(t, 2, skippedBitmap.Count())
+	require.True(t, skippedBitmap.Test(1))
+	require.True(t, skippedBitmap.Test(3))
+}
@@ -32,13 +32,78 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "next_l1_message_queue_index",
 			Help:      "",
 		}, labels).With(labelsAndValues...),
+		L1MessageInclusionLatency: prometheus.NewHistogramFrom(stdprometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "l1_message_inclusion_latency",
+			Help:      "Seconds from the L1 block emitting an L1 message to the L2 block including it.",
+
+			Buckets: stdprometheus.ExponentialBucketsRange(1, 7200, 16),
+		}, labels).With(labelsAndValues...),
+		ChunkClosed: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "chunk_closed",
+			Help:      "Number of chunks closed by a block breaking their limits, by limit and sub-circuit.",
+		}, append(labels, "reason", "circuit")).With(labelsAndValues...),
+		SealedBatchMismatch: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "sealed_batch_mismatch",
+			Help:      "Number of batches the self-check refused to seal, as they do not decode back into the packed blocks.",
+		}, labels).With(labelsAndValues...),
+		BatchIndex: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "batch_index",
+			Help:      "Index of the latest batch sealed by the sequencer reaching each status.",
+		}, append(labels, "status")).With(labelsAndValues...),
+		BatchHashMismatch: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "batch_hash_mismatch",
+			Help:      "Number of batches committed or finalized on L1 with another hash than the one sealed.",
+		}, labels).With(labelsAndValues...),
+		BlsSignatureCollected: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "bls_signature_collected",
+			Help:      "Number of BLS batch signatures verified and collected.",
+		}, labels).With(labelsAndValues...),
+		BlsSignatureRejected: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "bls_signature_rejected",
+			Help:      "Number of BLS batch signatures rejected, by reason: invalid, unknown_signer or duplicate.",
+		}, append(labels, "reason")).With(labelsAndValues...),
+		BatchesWithoutQuorum: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "batches_without_quorum",
+			Help:      "Number of recent batch hashes whose BLS signatures have not reached the quorum.",
+		}, labels).With(labelsAndValues...),
+		ConsensusParamsRefreshed: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "consensus_params_refreshed",
+			Help:      "Number of consensus parameter refreshes from the L2 contracts, by contract: sequencer or gov.",
+		}, append(labels, "contract")).With(labelsAndValues...),
 	}
 }
 
 func NopMetrics() *Metrics {
 	return &Metrics{
-		Height:                  discard.NewGauge(),
-		BatchPointHeight:        discard.NewGauge(),
-		NextL1MessageQueueIndex: discard.NewGauge(),
+		Height:                    discard.NewGauge(),
+		BatchPointHeight:          discard.NewGauge(),
+		NextL1MessageQueueIndex:   discard.NewGauge(),
+		L1MessageInclusionLatency: discard.NewHistogram(),
+		ChunkClosed:               discard.NewCounter(),
+		SealedBatchMismatch:       discard.NewCounter(),
+		BatchIndex:                discard.NewGauge(),
+		BatchHashMismatch:         discard.NewCounter(),
+		BlsSignatureCollected:     discard.NewCounter(),
+		BlsSignatureRejected:      discard.NewCounter(),
+		BatchesWithoutQuorum:      discard.NewGauge(),
+		ConsensusParamsRefreshed:  discard.NewCounter(),
 	}
 }
@@ -14,4 +14,22 @@ type Metrics struct {
 	Height                  metrics.Gauge
 	BatchPointHeight        metrics.Gauge
 	NextL1MessageQueueIndex metrics.Gauge
+	// Seconds from the L1 block emitting an L1 message to the L2 block including it.
+	L1MessageInclusionLatency metrics.Histogram `metrics_buckettype:"exprange" metrics_bucketsizes:"1, 7200, 16"`
+	// Number of chunks closed by a block breaking their limits, by limit and sub-circuit.
+	ChunkClosed metrics.Counter `metrics_labels:"reason, circuit"`
+	// Number of batches the self-check refused to seal, as they do not decode back into the packed blocks.
+	SealedBatchMismatch metrics.Counter
+	// Index of the latest batch sealed by the sequencer reaching each status.
+	BatchIndex metrics.Gauge `metrics_labels:"status"`
+	// Number of batches committed or finalized on L1 with another hash than the one sealed.
+	BatchHashMismatch metrics.Counter
+	// Number of BLS batch signatures verified and collected.
+	BlsSignatureCollected metrics.Counter
+	// Number of BLS batch signatures rejected, by reason: invalid, unknown_signer or duplicate.
+	BlsSignatureRejected metrics.Counter `metrics_labels:"reason"`
+	// Number of recent batch hashes whose BLS signatures have not reached the quorum.
+	BatchesWithoutQuorum metrics.Gauge
+	// Number of consensus parameter refreshes from the L2 contracts, by contract: sequencer or gov.
+	ConsensusParamsRefreshed metrics.Counter `metrics_labels:"contract"`
 }
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/morph-l2/bindings/bindings"
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
 	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/crypto/bls12381"
 	"github.com/tendermint/tendermint/blssignatures"
@@ -21,36 +23,73 @@ type sequencerKey struct {
 	blsPubKey blssignatures.PublicKey
 }
 
-func (e *Executor) getBlsPubKeyByTmKey(tmPubKey []byte, height *uint64) *sequencerKey {
+// sequencerSetReader is the part of the L2Sequencer contract the sequencer sets are read from.
+type sequencerSetReader interface {
+	CurrentVersion(opts *bind.CallOpts) (*big.Int, error)
+	CurrentVersionHeight(opts *bind.CallOpts) (*big.Int, error)
+	PreVersionHeight(opts *bind.CallOpts) (*big.Int, error)
+	GetSequencerInfos(opts *bind.CallOpts, previous bool) ([]bindings.TypesSequencerInfo, error)
+}
+
+// sequencerSetAt returns the sequencer set taking part in the consensus at the height, nil if it is not known.
+func (e *Executor) sequencerSetAt(height uint64) *SequencerSetInfo {
+	if e.currentSequencerSet == nil {
+		return nil
+	}
+	if height >= e.currentSequencerSet.startHeight {
+		return e.currentSequencerSet
+	}
+	// a sequencer set takes part until the next one starts
+	for i := len(e.previousSequencerSet) - 1; i >= 0; i-- {
+		if height >= e.previousSequencerSet[i].startHeight {
+			return &e.previousSequencerSet[i]
+		}
+	}
+	return nil
+}
+
+// getBlsPubKeyByTmKey returns the key of the sequencer in the sequencer set of the height,
+// or in any known sequencer set, the most recent first, if the height is nil. The set the key is found in is returned as well.
+func (e *Executor) getBlsPubKeyByTmKey(tmPubKey []byte, height *uint64) (*sequencerKey, *SequencerSetInfo) {
+	if e.currentSequencerSet == nil {
+		return nil, nil
+	}
 	var pk [tmKeySize]byte
 	copy(pk[:], tmPubKey)
 
-	if e.currentSequencerSet != nil && (height == nil || *height >= e.currentSequencerSet.startHeight) {
-		seqKey, ok := e.currentSequencerSet.sequencerSet[pk]
-		if ok {
-			return &seqKey
+	if height != nil {
+		set := e.sequencerSetAt(*height)
+		if set == nil {
+			return nil, nil
 		}
-	}
-	if e.currentSequencerSet.startHeight <= 1 { // means no previous sequencer set
-		return nil
+		seqKey, ok := set.sequencerSet[pk]
+		if !ok {
+			return nil, nil
+		}
+		return &seqKey, set
 	}
 
-	nextEndHeight := e.currentSequencerSet.startHeight - 1
-	if len(e.previousSequencerSet) > 0 {
-		for i := len(e.previousSequencerSet) - 1; i >= 0; i-- {
-			if height == nil || (*height >= e.previousSequencerSet[i].startHeight && *height <= nextEndHeight) {
-				seqKey, ok := e.previousSequencerSet[i].sequencerSet[pk]
-				if ok {
-					return &seqKey
-				}
-				nextEndHeight = e.previousSequencerSet[i].startHeight - 1
-			}
+	if seqKey, ok := e.currentSequencerSet.sequencerSet[pk]; ok {
+		return &seqKey, e.currentSequencerSet
+	}
+	for i := len(e.previousSequencerSet) - 1; i >= 0; i-- {
+		if seqKey, ok := e.previousSequencerSet[i].sequencerSet[pk]; ok {
+			return &seqKey, &e.previousSequencerSet[i]
 		}
 	}
-	return nil
+	return nil, nil
 }
 
+// errNotSequencer is returned when the signer is not in the sequencer set the signature is verified against.
+var errNotSequencer = errors.New("it is not a valid sequencer")
+
 func (e *Executor) VerifySignature(tmPubKey []byte, messageHash []byte, blsSig []byte) (bool, error) {
+	return e.verifySignatureAt(tmPubKey, messageHash, blsSig, nil)
+}
+
+// verifySignatureAt verifies the BLS signature with the key the sequencer has in the sequencer set of the height,
+// the key the signature is handed to geth with. If the height is nil, the most recent key of the sequencer is used.
+func (e *Executor) verifySignatureAt(tmPubKey []byte, messageHash []byte, blsSig []byte, height *uint64) (bool, error) {
 	if e.devSequencer {
 		e.logger.Info("we are in dev mode, do not verify the bls signature")
 		return true, nil
@@ -59,9 +98,9 @@ func (e *Executor) VerifySignature(tmPubKey []byte, messageHash []byte, blsSig [
 		return false, errors.New("no available sequencers found in layer2")
 	}
 
-	seqKey := e.getBlsPubKeyByTmKey(tmPubKey, nil)
+	seqKey, _ := e.getBlsPubKeyByTmKey(tmPubKey, height)
 	if seqKey == nil {
-		return false, errors.New("it is not a valid sequencer")
+		return false, errNotSequencer
 	}
 
 	sig, err := blssignatures.SignatureFromBytes(blsSig)
@@ -82,62 +121,22 @@ func (e *Executor) sequencerSetUpdates(curHeight *uint64) ([][]byte, error) {
 	}
 
 	// found new version sequencerSet
-	// move current sequencer set to previous sequencer set
-	if e.currentSequencerSet != nil && curHeight != nil {
-		e.previousSequencerSet = append(e.previousSequencerSet, *e.currentSequencerSet)
-		if len(e.previousSequencerSet) > 2 {
-			e.previousSequencerSet = e.previousSequencerSet[len(e.previousSequencerSet)-2:] // only reserves 2 elements
-		}
-	} else if currentVersion.Uint64() != 0 { // first time to fetch sequencer set, and it is not the first version
-		preSequencerInfo, err := e.sequencerContract.GetSequencerInfos(nil, true)
-		if err != nil {
-			e.logger.Error("failed to call GetSequencerInfos", "previous", true, "err", err)
-			return nil, err
-		}
-		_, preSequencerSet, err := e.convertSequencerSet(preSequencerInfo)
-		if err != nil {
-			return nil, err
-		}
-		preVersionHeight, err := e.sequencerContract.PreVersionHeight(nil)
-		if err != nil {
-			e.logger.Error("failed to call PreVersionHeight", "err", err)
-			return nil, err
-		}
-		var preStartHeight uint64
-		if preVersionHeight.Sign() > 0 {
-			preStartHeight = preVersionHeight.Uint64() + 2
-		}
-		e.previousSequencerSet = []SequencerSetInfo{{
-			version:      currentVersion.Uint64() - 1,
-			startHeight:  preStartHeight,
-			sequencerSet: preSequencerSet,
-		}}
-	}
-
-	// fetch current sequencerSet info
-	sequencersInfo, err := e.sequencerContract.GetSequencerInfos(nil, false)
+	current, err := e.fetchSequencerSet(nil, false)
 	if err != nil {
-		e.logger.Error("failed to call GetSequencerInfos", "previous", false, "err", err)
 		return nil, err
 	}
-	newValidators, newSequencerSet, err := e.convertSequencerSet(sequencersInfo)
+	newValidators, currentSequencerSet, err := e.newSequencerSetInfo(current)
 	if err != nil {
 		return nil, err
 	}
-	var currentStartHeight uint64
-	curVersionHeight, err := e.sequencerContract.CurrentVersionHeight(nil)
-	if err != nil {
-		e.logger.Error("failed to call CurrentVersionHeight", "err", err)
+	if e.currentSequencerSet != nil {
+		// move current sequencer set to previous sequencer set
+		e.previousSequencerSet = append(e.previousSequencerSet, *e.currentSequencerSet)
+	} else if e.previousSequencerSet, err = e.loadSequencerSetHistory(current); err != nil {
 		return nil, err
 	}
-	if curVersionHeight.Uint64() != 0 {
-		currentStartHeight = curVersionHeight.Uint64() + 2
-	}
-	e.currentSequencerSet = &SequencerSetInfo{
-		version:      currentVersion.Uint64(),
-		startHeight:  currentStartHeight,
-		sequencerSet: newSequencerSet,
-	}
+	e.storeSequencerSet(current)
+	e.currentSequencerSet = currentSequencerSet
 	e.nextValidators = newValidators
 
 	var before string
@@ -148,7 +147,113 @@ func (e *Executor) sequencerSetUpdates(curHeight *uint64) ([][]byte, error) {
 	return newValidators, nil
 }
 
-func (e *Executor) convertSequencerSet(sequencersInfo []bindings.TypesSequencerInfo) ([][]byte, map[[32]byte]sequencerKey, error) {
+// fetchSequencerSet reads the current sequencer set from the L2Sequencer contract, or the previous one,
+// at the height of the call options.
+func (e *Executor) fetchSequencerSet(opts *bind.CallOpts, previous bool) (types.SequencerSet, error) {
+	version, err := e.sequencerContract.CurrentVersion(opts)
+	if err != nil {
+		e.logger.Error("failed to call CurrentVersion", "err", err)
+		return types.SequencerSet{}, err
+	}
+	var versionHeight *big.Int
+	if previous {
+		if version.Sign() == 0 {
+			return types.SequencerSet{}, errors.New("no sequencer set before the first version")
+		}
+		version.Sub(version, big.NewInt(1))
+		versionHeight, err = e.sequencerContract.PreVersionHeight(opts)
+	} else {
+		versionHeight, err = e.sequencerContract.CurrentVersionHeight(opts)
+	}
+	if err != nil {
+		e.logger.Error("failed to get the height of the sequencer set version", "previous", previous, "err", err)
+		return types.SequencerSet{}, err
+	}
+	sequencersInfo, err := e.sequencerContract.GetSequencerInfos(opts, previous)
+	if err != nil {
+		e.logger.Error("failed to call GetSequencerInfos", "previous", previous, "err", err)
+		return types.SequencerSet{}, err
+	}
+
+	set := types.SequencerSet{Version: version.Uint64()}
+	// the sequencers of a version take part in the consensus 2 blocks after it is set
+	if versionHeight.Sign() > 0 {
+		set.StartHeight = versionHeight.Uint64() + 2
+	}
+	for _, info := range sequencersInfo {
+		set.Sequencers = append(set.Sequencers, types.SequencerInfo(info))
+	}
+	return set, nil
+}
+
+// loadSequencerSetHistory returns the sequencer sets of all the versions before the current one, in version order.
+// The versions missing from the store are backfilled from the L2Sequencer contract at the height right before
+// the next version was set, which needs the state of that height. If it is pruned, the history only goes back
+// to the versions found.
+func (e *Executor) loadSequencerSetHistory(current types.SequencerSet) ([]SequencerSetInfo, error) {
+	stored := make(map[uint64]types.SequencerSet)
+	if e.sequencerSets != nil {
+		for _, set := range e.sequencerSets.ReadSequencerSets() {
+			stored[set.Version] = set
+		}
+	}
+
+	var history []types.SequencerSet
+	for next := current; next.Version > 0 && next.StartHeight > 0; {
+		set, ok := stored[next.Version-1]
+		if !ok {
+			var err error
+			if next.Version == current.Version {
+				// the contract keeps the previous sequencer set
+				set, err = e.fetchSequencerSet(nil, true)
+			} else {
+				set, err = e.fetchSequencerSet(&bind.CallOpts{BlockNumber: new(big.Int).SetUint64(next.StartHeight - 3)}, false)
+			}
+			if err != nil {
+				e.logger.Error("failed to backfill the sequencer set history", "version", next.Version-1, "err", err)
+				break
+			}
+			if set.Version >= next.Version {
+				e.logger.Error("unexpected sequencer set version in the history", "version", set.Version, "next", next.Version)
+				break
+			}
+			e.storeSequencerSet(set)
+		}
+		history = append(history, set)
+		next = set
+	}
+
+	infos := make([]SequencerSetInfo, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		_, info, err := e.newSequencerSetInfo(history[i])
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, *info)
+	}
+	e.logger.Info("sequencer set history loaded", "versions", len(infos))
+	return infos, nil
+}
+
+func (e *Executor) storeSequencerSet(set types.SequencerSet) {
+	if e.sequencerSets != nil {
+		e.sequencerSets.WriteSequencerSet(set)
+	}
+}
+
+func (e *Executor) newSequencerSetInfo(set types.SequencerSet) ([][]byte, *SequencerSetInfo, error) {
+	validators, sequencerSet, err := e.convertSequencerSet(set.Sequencers)
+	if err != nil {
+		return nil, nil, err
+	}
+	return validators, &SequencerSetInfo{
+		version:      set.Version,
+		startHeight:  set.StartHeight,
+		sequencerSet: sequencerSet,
+	}, nil
+}
+
+func (e *Executor) convertSequencerSet(sequencersInfo []types.SequencerInfo) ([][]byte, map[[32]byte]sequencerKey, error) {
 	newValidators := make([][]byte, 0)
 	newSequencerSet := make(map[[tmKeySize]byte]sequencerKey)
 	for i := range sequencersInfo {
@@ -222,7 +327,7 @@ func (e *Executor) updateSequencerSet(curHeight *uint64) ([][]byte, error) {
 	if !e.isSequencer && isSequencer {
 		e.logger.Info("I am a sequencer, start to launch syncer")
 		if e.syncer == nil {
-			syncer, err := e.newSyncerFunc()
+			syncer, err := e.newSyncerFunc(e.l1Client)
 			if err != nil {
 				e.logger.Error("failed to create syncer", "error", err)
 				return nil, err
@@ -0,0 +1,170 @@
+package node
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/morph-l2/bindings/bindings"
+	"github.com/morph-l2/node/db"
+	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
+	"github.com/scroll-tech/go-ethereum/crypto/bls12381"
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/l2node"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// testSequencerContract is an L2Sequencer contract whose sequencer set version changes at the given heights.
+type testSequencerContract struct {
+	// versionHeights[v] is the height version v was set at, infos[v] its sequencers
+	versionHeights []uint64
+	infos          [][]bindings.TypesSequencerInfo
+	latest         uint64
+	// the state below prunedBelow is not available
+	prunedBelow uint64
+	calls       int
+}
+
+func (c *testSequencerContract) version(opts *bind.CallOpts) (int, error) {
+	c.calls++
+	height := c.latest
+	if opts != nil && opts.BlockNumber != nil {
+		height = opts.BlockNumber.Uint64()
+	}
+	if height < c.prunedBelow {
+		return 0, errors.New("missing trie node")
+	}
+	version := 0
+	for v, h := range c.versionHeights {
+		if h <= height {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+func (c *testSequencerContract) CurrentVersion(opts *bind.CallOpts) (*big.Int, error) {
+	v, err := c.version(opts)
+	return big.NewInt(int64(v)), err
+}
+
+func (c *testSequencerContract) CurrentVersionHeight(opts *bind.CallOpts) (*big.Int, error) {
+	v, err := c.version(opts)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetUint64(c.versionHeights[v]), nil
+}
+
+func (c *testSequencerContract) PreVersionHeight(opts *bind.CallOpts) (*big.Int, error) {
+	v, err := c.version(opts)
+	if err != nil || v == 0 {
+		return new(big.Int), err
+	}
+	return new(big.Int).SetUint64(c.versionHeights[v-1]), nil
+}
+
+func (c *testSequencerContract) GetSequencerInfos(opts *bind.CallOpts, previous bool) ([]bindings.TypesSequencerInfo, error) {
+	v, err := c.version(opts)
+	if err != nil {
+		return nil, err
+	}
+	if previous {
+		v--
+	}
+	return c.infos[v], nil
+}
+
+func TestSequencerSetHistory(t *testing.T) {
+	// version v is made of sequencers[v], sequencers[v+1]
+	_, sequencers := testSequencerSet(t, 5)
+	contract := &testSequencerContract{versionHeights: []uint64{0, 10, 20, 30}, latest: 35}
+	for v := range contract.versionHeights {
+		var infos []bindings.TypesSequencerInfo
+		for _, s := range sequencers[v : v+2] {
+			infos = append(infos, bindings.TypesSequencerInfo{TmKey: s.tmKey, BlsKey: new(bls12381.G2).EncodePoint(s.blsPub.Key)})
+		}
+		contract.infos = append(contract.infos, infos)
+	}
+	store := db.NewMemoryStore()
+	newExecutor := func() *Executor {
+		return &Executor{
+			sequencerContract: contract,
+			sequencerSets:     store,
+			logger:            tmlog.NewNopLogger(),
+			metrics:           NopMetrics(),
+		}
+	}
+
+	e := newExecutor()
+	// no sequencer set yet
+	seqKey, _ := e.getBlsPubKeyByTmKey(sequencers[0].tmKey[:], nil)
+	require.Nil(t, seqKey)
+	_, err := e.VerifySignature(sequencers[0].tmKey[:], []byte{1}, nil)
+	require.Error(t, err)
+
+	_, err = e.updateSequencerSet(nil)
+	require.NoError(t, err)
+	require.Len(t, e.previousSequencerSet, 3)
+	require.Len(t, store.ReadSequencerSets(), 4)
+
+	// version v takes part from the height it was set at + 2
+	for _, tc := range []struct {
+		height    uint64
+		sequencer int
+		index     uint64
+	}{
+		{height: 1, sequencer: 0, index: 0},
+		{height: 11, sequencer: 1, index: 1},
+		{height: 12, sequencer: 1, index: 0},
+		{height: 22, sequencer: 2, index: 0},
+		{height: 31, sequencer: 3, index: 1},
+		{height: 32, sequencer: 4, index: 1},
+	} {
+		seqKey, set := e.getBlsPubKeyByTmKey(sequencers[tc.sequencer].tmKey[:], &tc.height)
+		require.NotNil(t, seqKey, "height %d", tc.height)
+		require.Equal(t, tc.index, seqKey.index, "height %d", tc.height)
+		require.Equal(t, e.sequencerSetAt(tc.height), set, "height %d", tc.height)
+	}
+	height := uint64(12)
+	seqKey, _ = e.getBlsPubKeyByTmKey(sequencers[0].tmKey[:], &height)
+	require.Nil(t, seqKey)
+	// signatures of old heights are converted with the version of the sequencer set of the height
+	batchSig, err := e.ConvertBlsData(l2node.BlsData{Signer: sequencers[0].tmKey[:]}, 5)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, batchSig.Signer)
+	require.EqualValues(t, 0, batchSig.Version)
+	batchSig, err = e.ConvertBlsData(l2node.BlsData{Signer: sequencers[2].tmKey[:]}, 22)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, batchSig.Version)
+	require.NotEqual(t, e.currentSequencerSet.version, batchSig.Version)
+
+	// after a restart, the history is read from the store
+	contract.calls = 0
+	e = newExecutor()
+	_, err = e.updateSequencerSet(nil)
+	require.NoError(t, err)
+	require.Len(t, e.previousSequencerSet, 3)
+	require.Equal(t, 4, contract.calls) // the current set only
+	seqKey, _ = e.getBlsPubKeyByTmKey(sequencers[0].tmKey[:], new(uint64))
+	require.NotNil(t, seqKey)
+
+	// a new version is appended to the history
+	contract.versionHeights = append(contract.versionHeights, 40)
+	contract.infos = append(contract.infos, contract.infos[0])
+	contract.latest = 40
+	height = 40
+	_, err = e.updateSequencerSet(&height)
+	require.NoError(t, err)
+	require.Len(t, e.previousSequencerSet, 4)
+	require.Len(t, store.ReadSequencerSets(), 5)
+
+	// the state of the old heights is pruned, the history goes back to the previous version only
+	pruned := &testSequencerContract{versionHeights: contract.versionHeights, infos: contract.infos, latest: 40, prunedBelow: 35}
+	e = &Executor{sequencerContract: pruned, logger: tmlog.NewNopLogger(), metrics: NopMetrics()}
+	_, err = e.updateSequencerSet(nil)
+	require.NoError(t, err)
+	require.Len(t, e.previousSequencerSet, 1)
+	require.EqualValues(t, 3, e.previousSequencerSet[0].version)
+	require.EqualValues(t, 32, e.previousSequencerSet[0].startHeight)
+}
@@ -4,25 +4,29 @@ import (
 	"context"
 	"fmt"
 
+	nodecommon "github.com/morph-l2/node/common"
 	"github.com/morph-l2/node/db"
 	"github.com/morph-l2/node/sync"
 	"github.com/urfave/cli"
 )
 
-func NewSyncer(ctx *cli.Context, home string, config *Config) (*sync.Syncer, error) {
-	// configure store
-	dbConfig := db.DefaultConfig()
-	dbConfig.SetCliContext(ctx)
-	store, err := db.NewStore(dbConfig, home)
-	if err != nil {
-		return nil, err
-	}
+// NewSyncer creates the syncer of the sequencer. It reads L1 through l1Client if one is given,
+// the one of the executor, and dials the L1 providers itself otherwise.
+func NewSyncer(ctx *cli.Context, store *db.Store, config *Config, l1Client *nodecommon.QuorumClient) (*sync.Syncer, error) {
 	// launch syncer
 	syncConfig := sync.DefaultConfig()
-	if err = syncConfig.SetCliContext(ctx); err != nil {
+	if err := syncConfig.SetCliContext(ctx); err != nil {
 		return nil, err
 	}
-	syncer, err := sync.NewSyncer(context.Background(), store, syncConfig, config.Logger)
+	var (
+		syncer *sync.Syncer
+		err    error
+	)
+	if l1Client != nil {
+		syncer, err = sync.NewSyncerWithL1Client(context.Background(), store, syncConfig, l1Client, config.Logger)
+	} else {
+		syncer, err = sync.NewSyncer(context.Background(), store, syncConfig, config.Logger)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create syncer, error: %v", err)
 	}
@@ -5,7 +5,6 @@ import (
 	"github.com/morph-l2/node/types"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/log"
-	"github.com/scroll-tech/go-ethereum/rlp"
 )
 
 // L1MessageIterator is a wrapper around ethdb.Iterator that
@@ -52,8 +51,8 @@ func (it *L1MessageIterator) EnqueueIndex() uint64 {
 // L1Message returns the current L1 message.
 func (it *L1MessageIterator) L1Message() types.L1Message {
 	data := it.inner.Value()
-	var l1Msg types.L1Message
-	if err := rlp.DecodeBytes(data, &l1Msg); err != nil {
+	l1Msg, err := decodeL1Message(data)
+	if err != nil {
 		log.Crit("Invalid L1 message RLP", "data", data, "err", err)
 	}
 	return l1Msg
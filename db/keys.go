@@ -8,6 +8,17 @@ var (
 
 	derivationL1HeightKey = []byte("LastDerivationL1Height")
 	latestBatchBlsKey     = []byte("latestBatchBlsKey")
+
+	syncFetchBlockRangeKey       = []byte("SyncFetchBlockRange")
+	derivationFetchBlockRangeKey = []byte("DerivationFetchBlockRange")
+
+	quarantinedL1EventPrefix = []byte("qe")
+
+	batchRecordPrefix              = []byte("br")
+	batchTrackerL1HeightKey        = []byte("BatchTrackerL1Height")
+	batchTrackerFetchBlockRangeKey = []byte("BatchTrackerFetchBlockRange")
+
+	sequencerSetPrefix = []byte("ss")
 )
 
 // encodeBlockNumber encodes an L1 enqueue index as big endian uint64
@@ -21,3 +32,19 @@ func encodeEnqueueIndex(index uint64) []byte {
 func L1MessageKey(enqueueIndex uint64) []byte {
 	return append(L1MessagePrefix, encodeEnqueueIndex(enqueueIndex)...)
 }
+
+// quarantinedL1EventKey = quarantinedL1EventPrefix + blockNumber (uint64 big endian) + logIndex (uint64 big endian)
+func quarantinedL1EventKey(blockNumber, logIndex uint64) []byte {
+	key := append(append([]byte{}, quarantinedL1EventPrefix...), encodeEnqueueIndex(blockNumber)...)
+	return append(key, encodeEnqueueIndex(logIndex)...)
+}
+
+// batchRecordKey = batchRecordPrefix + batchIndex (uint64 big endian)
+func batchRecordKey(batchIndex uint64) []byte {
+	return append(append([]byte{}, batchRecordPrefix...), encodeEnqueueIndex(batchIndex)...)
+}
+
+// sequencerSetKey = sequencerSetPrefix + version (uint64 big endian)
+func sequencerSetKey(version uint64) []byte {
+	return append(append([]byte{}, sequencerSetPrefix...), encodeEnqueueIndex(version)...)
+}
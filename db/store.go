@@ -6,7 +6,9 @@ import (
 	"path/filepath"
 
 	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/ethdb"
 	"github.com/scroll-tech/go-ethereum/rlp"
 	"github.com/syndtr/goleveldb/leveldb"
@@ -114,8 +116,8 @@ func (s *Store) ReadL1MessageByIndex(index uint64) *types.L1Message {
 	if len(data) == 0 {
 		return nil
 	}
-	var l1Msg types.L1Message
-	if err := rlp.DecodeBytes(data, &l1Msg); err != nil {
+	l1Msg, err := decodeL1Message(data)
+	if err != nil {
 		panic(fmt.Sprintf("invalid L1 message RLP, err: %v", err))
 
 	}
@@ -141,7 +143,7 @@ func (s *Store) WriteSyncedL1Messages(messages []types.L1Message, latestSynced u
 	}
 	batch := s.db.NewBatch()
 	for _, msg := range messages {
-		bytes, err := rlp.EncodeToBytes(msg)
+		bytes, err := encodeL1Message(msg)
 		if err != nil {
 			panic(fmt.Sprintf("failed to RLP encode L1 message, err: %v", err))
 		}
@@ -156,6 +158,198 @@ func (s *Store) WriteSyncedL1Messages(messages []types.L1Message, latestSynced u
 	return batch.Write()
 }
 
+func (s *Store) ReadSyncFetchBlockRange() *uint64 {
+	return s.readUint64(syncFetchBlockRangeKey)
+}
+
+func (s *Store) WriteSyncFetchBlockRange(blockRange uint64) {
+	s.writeUint64(syncFetchBlockRangeKey, blockRange)
+}
+
+func (s *Store) ReadDerivationFetchBlockRange() *uint64 {
+	return s.readUint64(derivationFetchBlockRangeKey)
+}
+
+func (s *Store) WriteDerivationFetchBlockRange(blockRange uint64) {
+	s.writeUint64(derivationFetchBlockRangeKey, blockRange)
+}
+
+// WriteQuarantinedL1Event stores a quarantined L1 event, keyed by its position on L1.
+// It returns false if the event was quarantined before, the stored one is kept then.
+func (s *Store) WriteQuarantinedL1Event(event types.QuarantinedL1Event) bool {
+	key := quarantinedL1EventKey(event.BlockNumber, event.LogIndex)
+	has, err := s.db.Has(key)
+	if err != nil {
+		panic(fmt.Sprintf("failed to read quarantined L1 event from database, err: %v", err))
+	}
+	if has {
+		return false
+	}
+	bytes, err := rlp.EncodeToBytes(event)
+	if err != nil {
+		panic(fmt.Sprintf("failed to RLP encode quarantined L1 event, err: %v", err))
+	}
+	if err := s.db.Put(key, bytes); err != nil {
+		panic(fmt.Sprintf("failed to store quarantined L1 event, err: %v", err))
+	}
+	return true
+}
+
+// ReadQuarantinedL1Events returns at most limit quarantined L1 events emitted from the L1 block fromBlock on, in L1 order.
+func (s *Store) ReadQuarantinedL1Events(fromBlock uint64, limit int) []types.QuarantinedL1Event {
+	it := s.db.NewIterator(quarantinedL1EventPrefix, encodeEnqueueIndex(fromBlock))
+	defer it.Release()
+
+	var events []types.QuarantinedL1Event
+	for len(events) < limit && it.Next() {
+		var event types.QuarantinedL1Event
+		if err := rlp.DecodeBytes(it.Value(), &event); err != nil {
+			panic(fmt.Sprintf("invalid quarantined L1 event RLP, err: %v", err))
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// WriteBatchRecord stores the record of a batch sealed by the sequencer, replacing the one of the same index.
+func (s *Store) WriteBatchRecord(record types.BatchRecord) {
+	bytes, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		panic(fmt.Sprintf("failed to RLP encode batch record, err: %v", err))
+	}
+	if err := s.db.Put(batchRecordKey(record.Index), bytes); err != nil {
+		panic(fmt.Sprintf("failed to store batch record, err: %v", err))
+	}
+}
+
+// ReadBatchRecord returns the record of the batch, nil if the batch was not sealed by the sequencer.
+func (s *Store) ReadBatchRecord(batchIndex uint64) *types.BatchRecord {
+	data, err := s.db.Get(batchRecordKey(batchIndex))
+	if err != nil && !isNotFoundErr(err) {
+		panic(fmt.Sprintf("failed to read batch record from database, err: %v", err))
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	var record types.BatchRecord
+	if err := rlp.DecodeBytes(data, &record); err != nil {
+		panic(fmt.Sprintf("invalid batch record RLP, err: %v", err))
+	}
+	return &record
+}
+
+// ReadBatchRecords returns at most limit batch records from the batch index fromIndex on, in batch index order.
+func (s *Store) ReadBatchRecords(fromIndex uint64, limit int) []types.BatchRecord {
+	it := s.db.NewIterator(batchRecordPrefix, encodeEnqueueIndex(fromIndex))
+	defer it.Release()
+
+	var records []types.BatchRecord
+	for len(records) < limit && it.Next() {
+		var record types.BatchRecord
+		if err := rlp.DecodeBytes(it.Value(), &record); err != nil {
+			panic(fmt.Sprintf("invalid batch record RLP, err: %v", err))
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// ReadBatchTrackerL1Height returns the last L1 height the batch events were tracked up to.
+func (s *Store) ReadBatchTrackerL1Height() *uint64 {
+	return s.readUint64(batchTrackerL1HeightKey)
+}
+
+func (s *Store) WriteBatchTrackerL1Height(height uint64) {
+	s.writeUint64(batchTrackerL1HeightKey, height)
+}
+
+func (s *Store) ReadBatchTrackerFetchBlockRange() *uint64 {
+	return s.readUint64(batchTrackerFetchBlockRangeKey)
+}
+
+func (s *Store) WriteBatchTrackerFetchBlockRange(blockRange uint64) {
+	s.writeUint64(batchTrackerFetchBlockRangeKey, blockRange)
+}
+
+// WriteSequencerSet stores a version of the sequencer set, replacing the one of the same version.
+func (s *Store) WriteSequencerSet(set types.SequencerSet) {
+	bytes, err := rlp.EncodeToBytes(set)
+	if err != nil {
+		panic(fmt.Sprintf("failed to RLP encode sequencer set, err: %v", err))
+	}
+	if err := s.db.Put(sequencerSetKey(set.Version), bytes); err != nil {
+		panic(fmt.Sprintf("failed to store sequencer set, err: %v", err))
+	}
+}
+
+// ReadSequencerSets returns all the stored versions of the sequencer set, in version order.
+func (s *Store) ReadSequencerSets() []types.SequencerSet {
+	it := s.db.NewIterator(sequencerSetPrefix, nil)
+	defer it.Release()
+
+	var sets []types.SequencerSet
+	for it.Next() {
+		var set types.SequencerSet
+		if err := rlp.DecodeBytes(it.Value(), &set); err != nil {
+			panic(fmt.Sprintf("invalid sequencer set RLP, err: %v", err))
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+func (s *Store) readUint64(key []byte) *uint64 {
+	data, err := s.db.Get(key)
+	if err != nil && !isNotFoundErr(err) {
+		panic(fmt.Sprintf("failed to read %s from database, err: %v", key, err))
+	}
+	if len(data) == 0 {
+		return nil
+	}
+
+	number := new(big.Int).SetBytes(data)
+	if !number.IsUint64() {
+		panic(fmt.Sprintf("unexpected %s in database, number: %d", key, number))
+	}
+
+	value := number.Uint64()
+	return &value
+}
+
+func (s *Store) writeUint64(key []byte, value uint64) {
+	if err := s.db.Put(key, new(big.Int).SetUint64(value).Bytes()); err != nil {
+		panic(fmt.Sprintf("failed to update %s, err: %v", key, err))
+	}
+}
+
+// storedL1Message is the database encoding of an L1 message.
+// It is the encoding of types.L1Message plus the L1 block time, which is not part of the block meta.
+type storedL1Message struct {
+	L1MessageTx eth.L1MessageTx
+	L1TxHash    common.Hash
+	L1BlockTime uint64 `rlp:"optional"`
+}
+
+func encodeL1Message(msg types.L1Message) ([]byte, error) {
+	return rlp.EncodeToBytes(storedL1Message{
+		L1MessageTx: msg.L1MessageTx,
+		L1TxHash:    msg.L1TxHash,
+		L1BlockTime: msg.L1BlockTime,
+	})
+}
+
+func decodeL1Message(data []byte) (types.L1Message, error) {
+	var stored storedL1Message
+	if err := rlp.DecodeBytes(data, &stored); err != nil {
+		return types.L1Message{}, err
+	}
+	return types.L1Message{
+		L1MessageTx: stored.L1MessageTx,
+		L1TxHash:    stored.L1TxHash,
+		L1BlockTime: stored.L1BlockTime,
+	}, nil
+}
+
 func isNotFoundErr(err error) bool {
 	return err.Error() == leveldb.ErrNotFound.Error() || err.Error() == types.ErrMemoryDBNotFound.Error()
 }
@@ -7,6 +7,7 @@ import (
 	"github.com/morph-l2/node/types"
 	"github.com/scroll-tech/go-ethereum/common"
 	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/rlp"
 	"github.com/stretchr/testify/require"
 )
 
@@ -53,3 +54,103 @@ func TestSyncedL1Messages(t *testing.T) {
 	msg = db.ReadL1MessageByIndex(200)
 	require.Nil(t, msg)
 }
+
+func TestQuarantinedL1Events(t *testing.T) {
+	db := NewMemoryStore()
+	for _, pos := range [][2]uint64{{20, 1}, {10, 3}, {10, 0}, {30, 0}} {
+		require.True(t, db.WriteQuarantinedL1Event(types.QuarantinedL1Event{
+			BlockNumber: pos[0],
+			LogIndex:    pos[1],
+			Topics:      []common.Hash{{1}},
+			Data:        []byte{2},
+			Error:       "malformed",
+		}))
+	}
+	// quarantined once only
+	require.False(t, db.WriteQuarantinedL1Event(types.QuarantinedL1Event{BlockNumber: 10, LogIndex: 3, Error: "again"}))
+
+	events := db.ReadQuarantinedL1Events(0, 10)
+	require.Len(t, events, 4)
+	require.EqualValues(t, 10, events[0].BlockNumber)
+	require.EqualValues(t, 0, events[0].LogIndex)
+	require.EqualValues(t, 3, events[1].LogIndex)
+	require.Equal(t, "malformed", events[1].Error)
+	require.EqualValues(t, 30, events[3].BlockNumber)
+
+	events = db.ReadQuarantinedL1Events(15, 2)
+	require.Len(t, events, 2)
+	require.EqualValues(t, 20, events[0].BlockNumber)
+	require.EqualValues(t, 30, events[1].BlockNumber)
+}
+
+func TestBatchRecords(t *testing.T) {
+	db := NewMemoryStore()
+	require.Nil(t, db.ReadBatchRecord(1))
+	for _, index := range []uint64{3, 1, 2, 256} {
+		db.WriteBatchRecord(types.BatchRecord{Index: index, Hash: common.Hash{byte(index)}, FirstBlock: index * 10, LastBlock: index*10 + 9})
+	}
+	db.WriteBatchRecord(types.BatchRecord{Index: 2, Hash: common.Hash{2}, Signatures: 3, Status: types.BatchCommitted, CommitL1Block: 100})
+
+	record := db.ReadBatchRecord(2)
+	require.NotNil(t, record)
+	require.Equal(t, types.BatchCommitted, record.Status)
+	require.EqualValues(t, 3, record.Signatures)
+	require.EqualValues(t, 100, record.CommitL1Block)
+
+	records := db.ReadBatchRecords(2, 10)
+	require.Len(t, records, 3)
+	require.EqualValues(t, 2, records[0].Index)
+	require.EqualValues(t, 3, records[1].Index)
+	require.EqualValues(t, 256, records[2].Index)
+	require.Len(t, db.ReadBatchRecords(0, 2), 2)
+
+	require.Nil(t, db.ReadBatchTrackerL1Height())
+	db.WriteBatchTrackerL1Height(50)
+	require.EqualValues(t, 50, *db.ReadBatchTrackerL1Height())
+
+	require.Nil(t, db.ReadBatchTrackerFetchBlockRange())
+	db.WriteBatchTrackerFetchBlockRange(125)
+	require.EqualValues(t, 125, *db.ReadBatchTrackerFetchBlockRange())
+}
+
+func TestSequencerSets(t *testing.T) {
+	db := NewMemoryStore()
+	require.Empty(t, db.ReadSequencerSets())
+	for _, version := range []uint64{2, 0, 1} {
+		db.WriteSequencerSet(types.SequencerSet{
+			Version:     version,
+			StartHeight: version * 100,
+			Sequencers:  []types.SequencerInfo{{Addr: common.Address{byte(version)}, TmKey: [32]byte{byte(version)}, BlsKey: []byte{1, 2}}},
+		})
+	}
+	sets := db.ReadSequencerSets()
+	require.Len(t, sets, 3)
+	for i, set := range sets {
+		require.EqualValues(t, i, set.Version)
+		require.EqualValues(t, i*100, set.StartHeight)
+		require.Equal(t, [32]byte{byte(i)}, set.Sequencers[0].TmKey)
+		require.Equal(t, []byte{1, 2}, set.Sequencers[0].BlsKey)
+	}
+}
+
+func TestL1MessageEncoding(t *testing.T) {
+	to := common.BigToAddress(big.NewInt(101))
+	msg := types.L1Message{
+		L1MessageTx: eth.L1MessageTx{QueueIndex: 7, Gas: 21000, To: &to, Value: big.NewInt(1), Sender: to},
+		L1TxHash:    common.BigToHash(big.NewInt(1111)),
+		L1BlockTime: 1700000000,
+	}
+	db := NewMemoryStore()
+	require.NoError(t, db.WriteSyncedL1Messages([]types.L1Message{msg}, 1))
+	require.EqualValues(t, msg.L1BlockTime, db.ReadL1MessageByIndex(7).L1BlockTime)
+	require.EqualValues(t, msg.L1BlockTime, db.ReadL1MessagesInRange(7, 7)[0].L1BlockTime)
+
+	// messages stored before the L1 block time was kept still decode
+	legacy, err := rlp.EncodeToBytes(msg)
+	require.NoError(t, err)
+	decoded, err := decodeL1Message(legacy)
+	require.NoError(t, err)
+	require.Equal(t, msg.L1TxHash, decoded.L1TxHash)
+	require.Equal(t, msg.QueueIndex, decoded.QueueIndex)
+	require.Zero(t, decoded.L1BlockTime)
+}
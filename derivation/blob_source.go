@@ -0,0 +1,99 @@
+package derivation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
+	geth "github.com/scroll-tech/go-ethereum/eth"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobSource serves the blobs of blob batches by their versioned hashes.
+type BlobSource interface {
+	GetBlobs(ctx context.Context, versionedHashes []common.Hash) ([]*types.Blob, error)
+}
+
+// FileBlobSource is a BlobSource backed by a directory holding one file per blob, named after its versioned hash.
+type FileBlobSource struct {
+	dir string
+}
+
+func NewFileBlobSource(dir string) (*FileBlobSource, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileBlobSource{dir: dir}, nil
+}
+
+func (s *FileBlobSource) path(versionedHash common.Hash) string {
+	return filepath.Join(s.dir, versionedHash.Hex()+".blob")
+}
+
+func (s *FileBlobSource) GetBlobs(_ context.Context, versionedHashes []common.Hash) ([]*types.Blob, error) {
+	blobs := make([]*types.Blob, len(versionedHashes))
+	for i, hash := range versionedHashes {
+		data, err := os.ReadFile(s.path(hash))
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrBlobNotFound, hash.Hex())
+		} else if err != nil {
+			return nil, err
+		}
+		if len(data) != types.BlobSize {
+			return nil, fmt.Errorf("blob file of %s has %d bytes, expected %d", hash.Hex(), len(data), types.BlobSize)
+		}
+		var blob types.Blob
+		copy(blob[:], data)
+		blobs[i] = &blob
+	}
+	return blobs, nil
+}
+
+// WriteBlob stores the blob and returns its versioned hash.
+func (s *FileBlobSource) WriteBlob(blob *types.Blob) (common.Hash, error) {
+	commitment, err := blob.Commitment()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	hash := commitment.VersionedHash()
+	return hash, os.WriteFile(s.path(hash), blob[:], 0644)
+}
+
+// fetchBlobs gets the blobs of a blob batch from the configured blob source.
+func (d *Derivation) fetchBlobs(batch geth.RPCRollupBatch) ([]*types.Blob, error) {
+	if d.blobSource == nil {
+		return nil, errors.New("no blob source configured for blob batches")
+	}
+	return FetchBlobs(d.ctx, d.blobSource, batch)
+}
+
+// FetchBlobs gets the blobs of a blob batch from the source, and checks them against the versioned hashes,
+// since the blob source is not trusted.
+func FetchBlobs(ctx context.Context, source BlobSource, batch geth.RPCRollupBatch) ([]*types.Blob, error) {
+	versionedHashes, err := BlobVersionedHashes(batch)
+	if err != nil {
+		return nil, err
+	}
+	blobs, err := source.GetBlobs(ctx, versionedHashes)
+	if err != nil {
+		return nil, err
+	}
+	if len(blobs) != len(versionedHashes) {
+		return nil, fmt.Errorf("blob source returned %d blobs, expected %d", len(blobs), len(versionedHashes))
+	}
+	for i, blob := range blobs {
+		commitment, err := blob.Commitment()
+		if err != nil {
+			return nil, err
+		}
+		if commitment.VersionedHash() != versionedHashes[i] {
+			return nil, fmt.Errorf("blob %d does not match versioned hash %s", i, versionedHashes[i].Hex())
+		}
+	}
+	return blobs, nil
+}
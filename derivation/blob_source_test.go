@@ -0,0 +1,106 @@
+package derivation
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	ethtypes "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/scroll-tech/go-ethereum/eth"
+	"github.com/stretchr/testify/require"
+)
+
+func testBlobChunks(t *testing.T) *types.Chunks {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := ethtypes.NewLondonSigner(big.NewInt(2710))
+	to := common.HexToAddress("0x01")
+
+	chunks := types.NewChunks()
+	for number := uint64(1); number <= 3; number++ {
+		var txsPayload []byte
+		for i := uint64(0); i < number; i++ {
+			tx, err := ethtypes.SignNewTx(key, signer, &ethtypes.DynamicFeeTx{
+				ChainID:   big.NewInt(2710),
+				Nonce:     number*10 + i,
+				GasTipCap: big.NewInt(1),
+				GasFeeCap: big.NewInt(1e9),
+				Gas:       21000,
+				To:        &to,
+				Value:     big.NewInt(1),
+			})
+			require.NoError(t, err)
+			bz, err := tx.MarshalBinary()
+			require.NoError(t, err)
+			txsPayload = binary.BigEndian.AppendUint32(txsPayload, uint32(len(bz)))
+			txsPayload = append(txsPayload, bz...)
+		}
+		block := types.WrappedBlock{Number: number, Timestamp: 1700000000 + number, GasLimit: 10_000_000, BaseFee: big.NewInt(1e6)}
+		chunks.Append(block.BlockContextBytes(int(number), 0), txsPayload, nil, nil)
+	}
+	return chunks
+}
+
+func TestParseBlobBatch(t *testing.T) {
+	chunks := testBlobChunks(t)
+	chunksBytes, err := chunks.EncodeWithVersion(types.BatchVersionV0)
+	require.NoError(t, err)
+	var calldataChunks []hexutil.Bytes
+	for _, ck := range chunksBytes {
+		calldataChunks = append(calldataChunks, ck)
+	}
+	calldataBatch, err := ParseBatch(eth.RPCRollupBatch{Version: uint(types.BatchVersionV0), Chunks: calldataChunks})
+	require.NoError(t, err)
+
+	blobSource, err := NewFileBlobSource(t.TempDir())
+	require.NoError(t, err)
+	blobs, err := chunks.EncodeBlobs()
+	require.NoError(t, err)
+	var versionedHashes []hexutil.Bytes
+	for _, blob := range blobs {
+		hash, err := blobSource.WriteBlob(blob)
+		require.NoError(t, err)
+		versionedHashes = append(versionedHashes, hash.Bytes())
+	}
+	batch := eth.RPCRollupBatch{Version: uint(types.BatchVersionV2), Chunks: versionedHashes}
+
+	_, err = ParseBatch(batch)
+	require.ErrorIs(t, err, types.ErrUnsupportedBatchVersion)
+
+	d := &Derivation{ctx: context.Background()}
+	_, err = d.fetchBlobs(batch)
+	require.Error(t, err, "no blob source")
+
+	d.blobSource = blobSource
+	fetched, err := d.fetchBlobs(batch)
+	require.NoError(t, err)
+	blobBatch, err := ParseBlobBatch(batch, fetched)
+	require.NoError(t, err)
+
+	require.Equal(t, calldataBatch.chunks, blobBatch.chunks)
+	require.Equal(t, calldataBatch.dataHash, blobBatch.dataHash)
+	require.Equal(t, calldataBatch.txNum, blobBatch.txNum)
+	require.EqualValues(t, 6, blobBatch.txNum)
+	require.EqualValues(t, 1, blobBatch.firstBlockNumber)
+	require.EqualValues(t, 3, blobBatch.lastBlockNumber)
+	require.Equal(t, []common.Hash{common.BytesToHash(versionedHashes[0])}, blobBatch.blobVersionedHashes)
+
+	// the blob source is not trusted
+	var other types.Blob
+	require.NoError(t, os.WriteFile(blobSource.path(common.BytesToHash(versionedHashes[0])), other[:], 0644))
+	_, err = d.fetchBlobs(batch)
+	require.ErrorContains(t, err, "does not match versioned hash")
+
+	missing := common.Hash{types.BlobCommitmentVersionKZG, 0xff}
+	_, err = d.fetchBlobs(eth.RPCRollupBatch{Version: uint(types.BatchVersionV2), Chunks: []hexutil.Bytes{missing.Bytes()}})
+	require.ErrorIs(t, err, ErrBlobNotFound)
+
+	_, err = BlobVersionedHashes(eth.RPCRollupBatch{Version: uint(types.BatchVersionV2), Chunks: []hexutil.Bytes{{0x01}}})
+	require.Error(t, err)
+}
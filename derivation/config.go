@@ -36,6 +36,7 @@ type Config struct {
 	PollInterval          time.Duration   `json:"poll_interval"`
 	LogProgressInterval   time.Duration   `json:"log_progress_interval"`
 	FetchBlockRange       uint64          `json:"fetch_block_range"`
+	BlobDir               string          `json:"blob_dir"`
 	MetricsPort           uint64          `json:"metrics_port"`
 	MetricsHostname       string          `json:"metrics_hostname"`
 	MetricsServerEnable   bool            `json:"metrics_server_enable"`
@@ -49,12 +50,15 @@ func DefaultConfig() *Config {
 		PollInterval:        DefaultPollInterval,
 		LogProgressInterval: DefaultLogProgressInterval,
 		FetchBlockRange:     DefaultFetchBlockRange,
-		L2:                  new(types.L2Config),
+		L2:                  types.DefaultL2Config(),
 	}
 }
 
 func (c *Config) SetCliContext(ctx *cli.Context) error {
-	c.L1.Addr = ctx.GlobalString(flags.L1NodeAddr.Name)
+	c.L1.Addrs = types.SplitAddrs(ctx.GlobalString(flags.L1NodeAddr.Name))
+	if ctx.GlobalIsSet(flags.L1Quorum.Name) {
+		c.L1.Quorum = ctx.GlobalInt(flags.L1Quorum.Name)
+	}
 	if ctx.GlobalIsSet(flags.L1Confirmations.Name) {
 		c.L1.Confirmations = rpc.BlockNumber(ctx.GlobalInt64(flags.L1Confirmations.Name))
 	}
@@ -92,8 +96,15 @@ func (c *Config) SetCliContext(ctx *cli.Context) error {
 		}
 	}
 
-	l2EthAddr := ctx.GlobalString(flags.L2EthAddr.Name)
-	l2EngineAddr := ctx.GlobalString(flags.L2EngineAddr.Name)
+	if ctx.GlobalIsSet(flags.DerivationBlobDir.Name) {
+		c.BlobDir = ctx.GlobalString(flags.DerivationBlobDir.Name)
+	}
+
+	l2EthAddrs := types.SplitAddrs(ctx.GlobalString(flags.L2EthAddr.Name))
+	l2EngineAddrs := types.SplitAddrs(ctx.GlobalString(flags.L2EngineAddr.Name))
+	if len(l2EthAddrs) != len(l2EngineAddrs) {
+		return fmt.Errorf("l2.eth and l2.engine must list the same number of endpoints, got %d and %d", len(l2EthAddrs), len(l2EngineAddrs))
+	}
 	fileName := ctx.GlobalString(flags.L2EngineJWTSecret.Name)
 	var secret [32]byte
 	fileName = strings.TrimSpace(fileName)
@@ -114,9 +125,12 @@ func (c *Config) SetCliContext(ctx *cli.Context) error {
 			return err
 		}
 	}
-	c.L2.EthAddr = l2EthAddr
-	c.L2.EngineAddr = l2EngineAddr
+	c.L2.EthAddrs = l2EthAddrs
+	c.L2.EngineAddrs = l2EngineAddrs
 	c.L2.JwtSecret = secret
+	if err := c.L2.Retry.SetCliContext(ctx); err != nil {
+		return err
+	}
 	c.MetricsServerEnable = ctx.GlobalBool(flags.MetricsServerEnable.Name)
 	c.MetricsHostname = ctx.GlobalString(flags.MetricsHostname.Name)
 	c.MetricsPort = ctx.GlobalUint64(flags.MetricsPort.Name)
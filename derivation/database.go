@@ -12,10 +12,12 @@ type Database interface {
 
 type Reader interface {
 	ReadLatestDerivationL1Height() *uint64
+	ReadDerivationFetchBlockRange() *uint64
 	//ReadLatestBatchBls() types.BatchBls
 }
 
 type Writer interface {
 	WriteLatestDerivationL1Height(latest uint64)
+	WriteDerivationFetchBlockRange(blockRange uint64)
 	//WriteLatestBatchBls(batchBls types.BatchBls)
 }
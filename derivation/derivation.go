@@ -6,11 +6,13 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"os"
 	"time"
 
 	"github.com/morph-l2/bindings/bindings"
+	nodecommon "github.com/morph-l2/node/common"
 	node "github.com/morph-l2/node/core"
 	"github.com/morph-l2/node/sync"
 	"github.com/morph-l2/node/types"
@@ -23,8 +25,6 @@ import (
 	"github.com/scroll-tech/go-ethereum/crypto"
 	geth "github.com/scroll-tech/go-ethereum/eth"
 	"github.com/scroll-tech/go-ethereum/eth/catalyst"
-	"github.com/scroll-tech/go-ethereum/ethclient"
-	"github.com/scroll-tech/go-ethereum/ethclient/authclient"
 	"github.com/scroll-tech/go-ethereum/rpc"
 	tmlog "github.com/tendermint/tendermint/libs/log"
 )
@@ -50,7 +50,8 @@ type BatchInfo struct {
 	firstBlockNumber uint64
 
 	root                   common.Hash
-	skippedL1MessageBitmap *big.Int
+	skippedL1MessageBitmap *types.SkippedBitmap
+	blobVersionedHashes    []common.Hash
 }
 
 func (bi *BatchInfo) FirstBlockNumber() uint64 {
@@ -69,6 +70,27 @@ func (bi *BatchInfo) TxNum() uint64 {
 	return bi.txNum
 }
 
+func (bi *BatchInfo) Version() uint64 {
+	return bi.version
+}
+
+// DataHash is the data hash of the batch as derived, see parseChunks.
+func (bi *BatchInfo) DataHash() common.Hash {
+	return bi.dataHash
+}
+
+func (bi *BatchInfo) Chunks() []*Chunk {
+	return bi.chunks
+}
+
+func (bi *BatchInfo) SkippedL1MessageBitmap() *types.SkippedBitmap {
+	return bi.skippedL1MessageBitmap
+}
+
+func (bi *BatchInfo) BlobVersionedHashes() []common.Hash {
+	return bi.blobVersionedHashes
+}
+
 type Derivation struct {
 	ctx                   context.Context
 	syncer                *sync.Syncer
@@ -79,6 +101,7 @@ type Derivation struct {
 	validator             *validator.Validator
 	logger                tmlog.Logger
 	rollup                *bindings.Rollup
+	blobSource            BlobSource
 	metrics               *Metrics
 
 	latestDerivation uint64
@@ -86,7 +109,7 @@ type Derivation struct {
 
 	cancel context.CancelFunc
 
-	fetchBlockRange     uint64
+	fetchRange          *nodecommon.AdaptiveRange
 	preBatchLastBlock   uint64
 	pollInterval        time.Duration
 	logProgressInterval time.Duration
@@ -101,17 +124,19 @@ type DeployContractBackend interface {
 }
 
 func NewDerivationClient(ctx context.Context, cfg *Config, syncer *sync.Syncer, db Database, validator *validator.Validator, rollup *bindings.Rollup, logger tmlog.Logger) (*Derivation, error) {
-	l1Client, err := ethclient.Dial(cfg.L1.Addr)
+	l1Client, err := nodecommon.DialQuorumClient(cfg.L1.Addrs, cfg.L1.Quorum, logger)
 	if err != nil {
 		return nil, err
 	}
-	aClient, err := authclient.DialContext(context.Background(), cfg.L2.EngineAddr, cfg.L2.JwtSecret)
+	l2Client, err := types.DialRetryableClient(context.Background(), cfg.L2, tmlog.NewTMLogger(tmlog.NewSyncWriter(os.Stdout)))
 	if err != nil {
 		return nil, err
 	}
-	eClient, err := ethclient.Dial(cfg.L2.EthAddr)
-	if err != nil {
-		return nil, err
+	var blobSource BlobSource
+	if cfg.BlobDir != "" {
+		if blobSource, err = NewFileBlobSource(cfg.BlobDir); err != nil {
+			return nil, err
+		}
 	}
 	ctx, cancel := context.WithCancel(ctx)
 	logger = logger.With("module", "derivation")
@@ -132,13 +157,14 @@ func NewDerivationClient(ctx context.Context, cfg *Config, syncer *sync.Syncer,
 		syncer:                syncer,
 		validator:             validator,
 		rollup:                rollup,
+		blobSource:            blobSource,
 		logger:                logger,
 		RollupContractAddress: cfg.RollupContractAddress,
 		confirmations:         cfg.L1.Confirmations,
-		l2Client:              types.NewRetryableClient(aClient, eClient, tmlog.NewTMLogger(tmlog.NewSyncWriter(os.Stdout))),
+		l2Client:              l2Client,
 		cancel:                cancel,
 		stop:                  make(chan struct{}),
-		fetchBlockRange:       cfg.FetchBlockRange,
+		fetchRange:            nodecommon.NewAdaptiveRange(cfg.FetchBlockRange, db.ReadDerivationFetchBlockRange(), db.WriteDerivationFetchBlockRange, logger),
 		pollInterval:          cfg.PollInterval,
 		logProgressInterval:   cfg.LogProgressInterval,
 		metrics:               metrics,
@@ -182,21 +208,21 @@ func (d *Derivation) Stop() {
 	d.logger.Info("Derivation service is stopped")
 }
 
+// L2Health reports whether L2 geth is available to derive the blocks into.
+func (d *Derivation) L2Health() error {
+	return d.l2Client.Health()
+}
+
 func (d *Derivation) derivationBlock(ctx context.Context) {
 	latestDerivation := d.db.ReadLatestDerivationL1Height()
 	latest := d.syncer.LatestSynced()
 	start := *latestDerivation + 1
-	end := latest
 	if latest < start {
 		d.logger.Info("latest less than or equal to start", "latest", latest, "start", start)
 		return
-	} else if latest-start >= d.fetchBlockRange {
-		end = start + d.fetchBlockRange
-	} else {
-		end = latest
 	}
-	d.logger.Info("derivation start pull rollupData form l1", "startBlock", start, "end", end)
-	logs, err := d.fetchRollupLog(ctx, start, end)
+	d.logger.Info("derivation start pull rollupData form l1", "startBlock", start, "latest", latest)
+	logs, end, err := d.fetchRollupLog(ctx, start, latest)
 	if err != nil {
 		d.logger.Error("eth_getLogs failed", "err", err)
 		return
@@ -250,18 +276,28 @@ func (d *Derivation) derivationBlock(ctx context.Context) {
 	d.metrics.SetL1SyncHeight(end)
 }
 
-func (d *Derivation) fetchRollupLog(ctx context.Context, from, to uint64) ([]eth.Log, error) {
-	query := ethereum.FilterQuery{
-		FromBlock: big.NewInt(0).SetUint64(from),
-		ToBlock:   big.NewInt(0).SetUint64(to),
-		Addresses: []common.Address{
-			d.RollupContractAddress,
-		},
-		Topics: [][]common.Hash{
-			{RollupEventTopicHash},
-		},
+// fetchRollupLog fetches the rollup logs from block from on, as far towards block to as the provider accepts in a
+// single query. It returns the logs along with the last block they cover.
+func (d *Derivation) fetchRollupLog(ctx context.Context, from, to uint64) ([]eth.Log, uint64, error) {
+	var logs []eth.Log
+	end, err := d.fetchRange.Query(from, to, func(from, end uint64) (err error) {
+		query := ethereum.FilterQuery{
+			FromBlock: big.NewInt(0).SetUint64(from),
+			ToBlock:   big.NewInt(0).SetUint64(end),
+			Addresses: []common.Address{
+				d.RollupContractAddress,
+			},
+			Topics: [][]common.Hash{
+				{RollupEventTopicHash},
+			},
+		}
+		logs, err = d.l1Client.FilterLogs(ctx, query)
+		return err
+	})
+	if err != nil {
+		return nil, 0, err
 	}
-	return d.l1Client.FilterLogs(ctx, query)
+	return logs, end, nil
 }
 
 func (d *Derivation) fetchRollupDataByTxHash(txHash common.Hash, blockNumber uint64) (*BatchInfo, error) {
@@ -272,13 +308,35 @@ func (d *Derivation) fetchRollupDataByTxHash(txHash common.Hash, blockNumber uin
 	if pending {
 		return nil, errors.New("pending transaction")
 	}
-	abi, err := bindings.RollupMetaData.GetAbi()
+	batch, err := UnpackCommitBatch(tx.Data())
 	if err != nil {
 		return nil, err
 	}
-	args, err := abi.Methods["commitBatch"].Inputs.Unpack(tx.Data()[4:])
+	rollupData, err := d.parseBatch(batch)
 	if err != nil {
-		return nil, fmt.Errorf("submitBatches Unpack error:%v", err)
+		d.logger.Error("ParseBatch failed", "txNonce", tx.Nonce(), "txHash", txHash,
+			"l1BlockNumber", blockNumber)
+		return rollupData, fmt.Errorf("ParseBatch error:%v\n", err)
+	}
+	rollupData.l1BlockNumber = blockNumber
+	rollupData.txHash = txHash
+	rollupData.nonce = tx.Nonce()
+	return rollupData, nil
+}
+
+// UnpackCommitBatch unpacks the batch from the calldata of a Rollup commitBatch call.
+func UnpackCommitBatch(calldata []byte) (geth.RPCRollupBatch, error) {
+	abi, err := bindings.RollupMetaData.GetAbi()
+	if err != nil {
+		return geth.RPCRollupBatch{}, err
+	}
+	method := abi.Methods["commitBatch"]
+	if len(calldata) < 4 || !bytes.Equal(calldata[:4], method.ID) {
+		return geth.RPCRollupBatch{}, errors.New("not a commitBatch calldata")
+	}
+	args, err := method.Inputs.Unpack(calldata[4:])
+	if err != nil {
+		return geth.RPCRollupBatch{}, fmt.Errorf("submitBatches Unpack error:%v", err)
 	}
 
 	rollupBatchData := args[0].(struct {
@@ -299,7 +357,7 @@ func (d *Derivation) fetchRollupDataByTxHash(txHash common.Hash, blockNumber uin
 	for _, chunk := range rollupBatchData.Chunks {
 		chunks = append(chunks, chunk)
 	}
-	batch := geth.RPCRollupBatch{
+	return geth.RPCRollupBatch{
 		Version:                uint(rollupBatchData.Version),
 		ParentBatchHeader:      rollupBatchData.ParentBatchHeader,
 		Chunks:                 chunks,
@@ -307,17 +365,7 @@ func (d *Derivation) fetchRollupDataByTxHash(txHash common.Hash, blockNumber uin
 		PrevStateRoot:          common.BytesToHash(rollupBatchData.PrevStateRoot[:]),
 		PostStateRoot:          common.BytesToHash(rollupBatchData.PostStateRoot[:]),
 		WithdrawRoot:           common.BytesToHash(rollupBatchData.WithdrawalRoot[:]),
-	}
-	rollupData, err := d.parseBatch(batch)
-	if err != nil {
-		d.logger.Error("ParseBatch failed", "txNonce", tx.Nonce(), "txHash", txHash,
-			"l1BlockNumber", blockNumber)
-		return rollupData, fmt.Errorf("ParseBatch error:%v\n", err)
-	}
-	rollupData.l1BlockNumber = blockNumber
-	rollupData.txHash = txHash
-	rollupData.nonce = tx.Nonce()
-	return rollupData, nil
+	}, nil
 }
 
 type Chunk struct {
@@ -327,6 +375,10 @@ type Chunk struct {
 	blockNum     int
 }
 
+func (ck *Chunk) BlockContexts() []*BlockContext {
+	return ck.blockContext
+}
+
 type BlockContext struct {
 	Number    uint64 `json:"number"`
 	Timestamp uint64 `json:"timestamp"`
@@ -338,6 +390,15 @@ type BlockContext struct {
 	SafeL2Data *catalyst.SafeL2Data
 }
 
+// TxsNum is the number of transactions of the block, L1 messages included.
+func (b *BlockContext) TxsNum() uint16 {
+	return b.txsNum
+}
+
+func (b *BlockContext) L1MsgNum() uint16 {
+	return b.l1MsgNum
+}
+
 func (b *BlockContext) Decode(bc []byte) error {
 	reader := bytes.NewReader(bc)
 	bsBaseFee := make([]byte, 32)
@@ -363,38 +424,26 @@ func (b *BlockContext) Decode(bc []byte) error {
 	return nil
 }
 
-func parseChunk(chunkBytes []byte) (*types.Chunk, error) {
-	reader := bytes.NewReader(chunkBytes)
-	var blockNum uint8
-	if err := binary.Read(reader, binary.BigEndian, &blockNum); err != nil {
-		return nil, err
-	}
-
-	blockCtx := make([]byte, 0)
-	for i := 0; i < int(blockNum); i++ {
-		bc := make([]byte, 60)
-		if err := binary.Read(reader, binary.BigEndian, &bc); err != nil {
-			return nil, err
-		}
-		blockCtx = append(blockCtx, bc...)
-	}
-	txsPayload := make([]byte, len(chunkBytes)-int(blockNum)*60-1)
-	if err := binary.Read(reader, binary.BigEndian, &txsPayload); err != nil {
-		return nil, err
-	}
-	chunk := types.NewChunk(blockCtx, txsPayload, nil, nil)
-	chunk.ResetBlockNum(int(blockNum))
-	return chunk, nil
-}
-
 func (d *Derivation) parseBatch(batch geth.RPCRollupBatch) (*BatchInfo, error) {
 	parentBatchHeader, err := types.DecodeBatchHeader(batch.ParentBatchHeader)
 	if err != nil {
 		return nil, fmt.Errorf("DecodeBatchHeader error:%v", err)
 	}
-	rollupData, err := ParseBatch(batch)
-	if err != nil {
-		return nil, fmt.Errorf("parse batch error:%v", err)
+	var rollupData *BatchInfo
+	if batch.Version == uint(types.BatchVersionV2) {
+		blobs, err := d.fetchBlobs(batch)
+		if err != nil {
+			return nil, fmt.Errorf("fetch blobs error:%v", err)
+		}
+		rollupData, err = ParseBlobBatch(batch, blobs)
+		if err != nil {
+			return nil, fmt.Errorf("parse blob batch error:%v", err)
+		}
+	} else {
+		rollupData, err = ParseBatch(batch)
+		if err != nil {
+			return nil, fmt.Errorf("parse batch error:%v", err)
+		}
 	}
 	if err := d.handleL1Message(rollupData, &parentBatchHeader); err != nil {
 		return nil, fmt.Errorf("handleL1Message error:%v", err)
@@ -403,17 +452,73 @@ func (d *Derivation) parseBatch(batch geth.RPCRollupBatch) (*BatchInfo, error) {
 	return rollupData, nil
 }
 
+// ParseBatch parses a batch committing its chunks in calldata.
 func ParseBatch(batch geth.RPCRollupBatch) (*BatchInfo, error) {
+	if batch.Version > math.MaxUint8 || !types.IsSupportedBatchVersion(uint8(batch.Version)) {
+		return nil, fmt.Errorf("%w: %d", types.ErrUnsupportedBatchVersion, batch.Version)
+	}
+	chunks := make([]*types.Chunk, len(batch.Chunks))
+	for i, chunkByte := range batch.Chunks {
+		chunk, err := types.DecodeChunk(uint8(batch.Version), chunkByte)
+		if err != nil {
+			return nil, fmt.Errorf("parse chunk error:%v", err)
+		}
+		chunks[i] = chunk
+	}
+	return parseChunks(batch, chunks)
+}
+
+// ParseBlobBatch parses a batch committing its chunks in the given blobs, see BlobVersionedHashes.
+func ParseBlobBatch(batch geth.RPCRollupBatch, blobs []*types.Blob) (*BatchInfo, error) {
+	versionedHashes, err := BlobVersionedHashes(batch)
+	if err != nil {
+		return nil, err
+	}
+	if len(blobs) != len(versionedHashes) {
+		return nil, fmt.Errorf("batch commits %d blobs, got %d", len(versionedHashes), len(blobs))
+	}
+	chunks, err := types.DecodeBlobs(blobs)
+	if err != nil {
+		return nil, fmt.Errorf("decode blobs error:%v", err)
+	}
+	rollupData, err := parseChunks(batch, chunks)
+	if err != nil {
+		return nil, err
+	}
+	rollupData.blobVersionedHashes = versionedHashes
+	return rollupData, nil
+}
+
+// BlobVersionedHashes returns the versioned hashes of the blobs of a blob batch,
+// which are listed in the chunks field of its calldata.
+func BlobVersionedHashes(batch geth.RPCRollupBatch) ([]common.Hash, error) {
+	if batch.Version != uint(types.BatchVersionV2) {
+		return nil, fmt.Errorf("%w: %d is not a blob batch version", types.ErrUnsupportedBatchVersion, batch.Version)
+	}
+	if len(batch.Chunks) == 0 || len(batch.Chunks) > types.MaxBlobsPerBatch {
+		return nil, fmt.Errorf("invalid number of blobs: %d", len(batch.Chunks))
+	}
+	versionedHashes := make([]common.Hash, len(batch.Chunks))
+	for i, hash := range batch.Chunks {
+		if len(hash) != common.HashLength || hash[0] != types.BlobCommitmentVersionKZG {
+			return nil, fmt.Errorf("invalid blob versioned hash: %x", []byte(hash))
+		}
+		versionedHashes[i] = common.BytesToHash(hash)
+	}
+	return versionedHashes, nil
+}
+
+func parseChunks(batch geth.RPCRollupBatch, decodedChunks []*types.Chunk) (*BatchInfo, error) {
 	var rollupData BatchInfo
 	rollupData.root = batch.PostStateRoot
-	rollupData.skippedL1MessageBitmap = new(big.Int).SetBytes(batch.SkippedL1MessageBitmap[:])
+	skippedL1MessageBitmap, err := types.DecodeSkippedBitmap(batch.SkippedL1MessageBitmap)
+	if err != nil {
+		return nil, err
+	}
+	rollupData.skippedL1MessageBitmap = skippedL1MessageBitmap
 	rollupData.version = uint64(batch.Version)
 	chunks := types.NewChunks()
-	for cbIndex, chunkByte := range batch.Chunks {
-		chunk, err := parseChunk(chunkByte)
-		if err != nil {
-			return nil, fmt.Errorf("parse chunk error:%v", err)
-		}
+	for cbIndex, chunk := range decodedChunks {
 		rollupData.blockNum += uint64(chunk.BlockNum())
 		chunks.Append(chunk.BlockContext(), chunk.TxsPayload(), nil, nil)
 		ck := Chunk{}
@@ -429,7 +534,7 @@ func ParseBatch(batch geth.RPCRollupBatch) (*BatchInfo, error) {
 			if cbIndex == 0 && i == 0 {
 				rollupData.firstBlockNumber = block.Number
 			}
-			if cbIndex == len(batch.Chunks)-1 && i == chunk.BlockNum()-1 {
+			if cbIndex == len(decodedChunks)-1 && i == chunk.BlockNum()-1 {
 				rollupData.lastBlockNumber = block.Number
 			}
 			var safeL2Data catalyst.SafeL2Data
@@ -472,7 +577,8 @@ func (d *Derivation) handleL1Message(rollupData *BatchInfo, parentBatchHeader *t
 		BatchIndex:             parentBatchHeader.BatchIndex + 1,
 		DataHash:               rollupData.dataHash,
 		ParentBatchHash:        parentBatchHeader.ParentBatchHash,
-		SkippedL1MessageBitmap: rollupData.skippedL1MessageBitmap.Bytes(),
+		SkippedL1MessageBitmap: rollupData.skippedL1MessageBitmap.Encode(),
+		BlobVersionedHashes:    rollupData.blobVersionedHashes,
 	}
 	var l1MessagePopped, totalL1MessagePopped uint64
 	totalL1MessagePopped = parentBatchHeader.TotalL1MessagePopped
@@ -487,7 +593,7 @@ func (d *Derivation) handleL1Message(rollupData *BatchInfo, parentBatchHeader *t
 			totalL1MessagePopped += uint64(block.l1MsgNum)
 			if len(l1Messages) > 0 {
 				for _, l1Message := range l1Messages {
-					if rollupData.skippedL1MessageBitmap.Bit(int(l1Message.QueueIndex)-int(parentBatchHeader.TotalL1MessagePopped)) == 1 {
+					if rollupData.skippedL1MessageBitmap.Test(l1Message.QueueIndex - parentBatchHeader.TotalL1MessagePopped) {
 						continue
 					}
 					transaction := eth.NewTx(&l1Message.L1MessageTx)
@@ -3,6 +3,7 @@ package derivation
 import (
 	"context"
 	"crypto/ecdsa"
+	"fmt"
 	"github.com/morph-l2/bindings/bindings"
 	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/eth"
@@ -12,6 +13,8 @@ import (
 	"strings"
 	"testing"
 
+	nodecommon "github.com/morph-l2/node/common"
+	"github.com/morph-l2/node/db"
 	"github.com/morph-l2/node/types"
 	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
@@ -19,6 +22,7 @@ import (
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/core"
 	"github.com/scroll-tech/go-ethereum/core/rawdb"
+	ethtypes "github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/ethclient"
 	"github.com/scroll-tech/go-ethereum/ethclient/authclient"
 	"github.com/scroll-tech/go-ethereum/ethdb"
@@ -59,10 +63,10 @@ func testNewDerivationClient(t *testing.T) *Derivation {
 		l1Client:              l1Client,
 		RollupContractAddress: addr,
 		confirmations:         rpc.BlockNumber(5),
-		l2Client:              types.NewRetryableClient(aClient, eClient, tmlog.NewTMLogger(tmlog.NewSyncWriter(os.Stdout))),
+		l2Client:              types.NewRetryableClient([]types.L2Endpoint{{AuthClient: aClient, EthClient: eClient}}, types.DefaultRetryConfig(), tmlog.NewTMLogger(tmlog.NewSyncWriter(os.Stdout))),
 		validator:             nil,
 		latestDerivation:      9,
-		fetchBlockRange:       100,
+		fetchRange:            nodecommon.NewAdaptiveRange(100, nil, nil, nil),
 		pollInterval:          1,
 	}
 	return &d
@@ -149,6 +153,73 @@ func TestDecodeBatch(t *testing.T) {
 		PostStateRoot:          common.BytesToHash(rollupBatchData.PostStateRoot[:]),
 		WithdrawRoot:           common.BytesToHash(rollupBatchData.WithdrawalRoot[:]),
 	}
+	batchV0, err := ParseBatch(batch)
+	require.NoError(t, err)
+
+	// the same batch committed with compressed transactions derives the same blocks
+	for i, chunk := range batch.Chunks {
+		ck, err := types.DecodeChunk(types.BatchVersionV0, chunk)
+		require.NoError(t, err)
+		batch.Chunks[i], err = ck.EncodeWithVersion(types.BatchVersionV1)
+		require.NoError(t, err)
+	}
+	batch.Version = uint(types.BatchVersionV1)
+	batchV1, err := ParseBatch(batch)
+	require.NoError(t, err)
+	require.Equal(t, batchV0.dataHash, batchV1.dataHash)
+	require.Equal(t, batchV0.txNum, batchV1.txNum)
+	require.Equal(t, batchV0.chunks, batchV1.chunks)
+	require.EqualValues(t, types.BatchVersionV1, batchV1.version)
+
+	batch.Version = 2
 	_, err = ParseBatch(batch)
+	require.ErrorIs(t, err, types.ErrUnsupportedBatchVersion)
+}
+
+// cappedL1Client serves rollup logs, one per block, and refuses queries matching more than limit logs.
+type cappedL1Client struct {
+	DeployContractBackend
+	blocks uint64
+	limit  uint64
+}
+
+func (c *cappedL1Client) FilterLogs(_ context.Context, q ethereum.FilterQuery) ([]ethtypes.Log, error) {
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	if to > c.blocks {
+		to = c.blocks
+	}
+	if to >= from && to-from+1 > c.limit {
+		return nil, fmt.Errorf("query returned more than %d results", c.limit)
+	}
+	var logs []ethtypes.Log
+	for i := from; i <= to; i++ {
+		logs = append(logs, ethtypes.Log{BlockNumber: i})
+	}
+	return logs, nil
+}
+
+func TestFetchRollupLogAdaptiveRange(t *testing.T) {
+	store := db.NewMemoryStore()
+	d := Derivation{
+		l1Client:   &cappedL1Client{blocks: 1000, limit: 30},
+		db:         store,
+		logger:     tmlog.NewNopLogger(),
+		fetchRange: nodecommon.NewAdaptiveRange(100, store.ReadDerivationFetchBlockRange(), store.WriteDerivationFetchBlockRange, nil),
+	}
+	logs, end, err := d.fetchRollupLog(context.Background(), 1, 1000)
 	require.NoError(t, err)
+	require.EqualValues(t, 25, end)
+	require.Len(t, logs, 25)
+	require.EqualValues(t, 25, *store.ReadDerivationFetchBlockRange())
+
+	// never beyond the requested block
+	logs, end, err = d.fetchRollupLog(context.Background(), 26, 30)
+	require.NoError(t, err)
+	require.EqualValues(t, 30, end)
+	require.Len(t, logs, 5)
+
+	d.l1Client = &cappedL1Client{blocks: 1000, limit: 0}
+	_, _, err = d.fetchRollupLog(context.Background(), 31, 1000)
+	require.Error(t, err)
+	require.EqualValues(t, 1, d.fetchRange.Size())
 }
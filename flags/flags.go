@@ -18,13 +18,13 @@ var (
 
 	L2EthAddr = cli.StringFlag{
 		Name:   "l2.eth",
-		Usage:  "Address of L2 Engine JSON-RPC endpoints to use (eth namespace required)",
+		Usage:  "Comma separated addresses of L2 Engine JSON-RPC endpoints to use (eth namespace required), the first one is the primary",
 		EnvVar: prefixEnvVar("L2_ETH_RPC"),
 	}
 
 	L2EngineAddr = cli.StringFlag{
 		Name:   "l2.engine",
-		Usage:  "Address of L2 Engine JSON-RPC endpoints to use (engine namespace required)",
+		Usage:  "Comma separated addresses of L2 Engine JSON-RPC endpoints to use (engine namespace required), in the same order as l2.eth",
 		EnvVar: prefixEnvVar("L2_ENGINE_RPC"),
 	}
 
@@ -36,12 +36,96 @@ var (
 		Destination: new(string),
 	}
 
+	L2RetryMaxInterval = cli.DurationFlag{
+		Name:   "l2.retry.maxInterval",
+		Usage:  "Upper bound of the wait between two attempts of a failed L2 geth call",
+		EnvVar: prefixEnvVar("L2_RETRY_MAX_INTERVAL"),
+	}
+
+	L2RetryMaxElapsedTime = cli.DurationFlag{
+		Name:   "l2.retry.maxElapsedTime",
+		Usage:  "How long a failed L2 geth call keeps being retried, 0 retries until the call is cancelled",
+		EnvVar: prefixEnvVar("L2_RETRY_MAX_ELAPSED_TIME"),
+	}
+
+	L2BreakerThreshold = cli.IntFlag{
+		Name:   "l2.breaker.threshold",
+		Usage:  "Number of consecutive connection failures after which L2 geth is marked as unavailable, 0 disables the circuit breaker",
+		EnvVar: prefixEnvVar("L2_BREAKER_THRESHOLD"),
+	}
+
+	L2BreakerCooldown = cli.DurationFlag{
+		Name:   "l2.breaker.cooldown",
+		Usage:  "How long L2 geth stays marked as unavailable before it is probed again",
+		EnvVar: prefixEnvVar("L2_BREAKER_COOLDOWN"),
+	}
+
 	MaxL1MessageNumPerBlock = cli.Uint64Flag{
 		Name:   "maxL1MessageNumPerBlock",
 		Usage:  "The max number allowed for L1 message type transactions to involve in one block",
 		EnvVar: prefixEnvVar("MAX_L1_MESSAGE_NUM_PER_BLOCK"),
 	}
 
+	L1MessageGasBudget = cli.Uint64Flag{
+		Name:   "l1MessageGasBudget",
+		Usage:  "The total gas limit of the L1 messages a sequencer includes in one block, L1 messages exceeding it on their own are skipped. 0 means no budget",
+		EnvVar: prefixEnvVar("L1_MESSAGE_GAS_BUDGET"),
+	}
+
+	L1MessageGasLimitFraction = cli.Float64Flag{
+		Name:   "l1MessageGasLimitFraction",
+		Usage:  "The share of the block gas limit L1 messages may use in one block, e.g. 0.5. 0 means no limit, the default",
+		EnvVar: prefixEnvVar("L1_MESSAGE_GAS_LIMIT_FRACTION"),
+	}
+
+	L1MessageMaxAge = cli.DurationFlag{
+		Name:   "l1MessageMaxAge",
+		Usage:  "L1 messages waiting longer than this since their L1 block are included regardless of the gas budget. 0 never forces them",
+		EnvVar: prefixEnvVar("L1_MESSAGE_MAX_AGE"),
+	}
+
+	BatchVersion = cli.UintFlag{
+		Name:   "batchVersion",
+		Usage:  "The version of the batches committed by the sequencer. 0 commits raw transactions, 1 commits zstd compressed transactions",
+		EnvVar: prefixEnvVar("BATCH_VERSION"),
+	}
+
+	BatchVersionHeight = cli.Uint64Flag{
+		Name:   "batchVersionHeight",
+		Usage:  "L2 height from which the batches are sealed with batchVersion, version 0 applies to the batches starting before. All sequencers must use the same height",
+		EnvVar: prefixEnvVar("BATCH_VERSION_HEIGHT"),
+	}
+
+	BatchSelfCheck = cli.BoolFlag{
+		Name:   "batchSelfCheck",
+		Usage:  "Decode the batch back when sealing it, and refuse to seal it if it does not match the packed blocks",
+		EnvVar: prefixEnvVar("BATCH_SELF_CHECK"),
+	}
+
+	ChunkMaxBlocks = cli.IntFlag{
+		Name:   "chunk.maxBlocks",
+		Usage:  "Maximum number of blocks in a chunk, 100 by default",
+		EnvVar: prefixEnvVar("CHUNK_MAX_BLOCKS"),
+	}
+
+	ChunkMaxRowNumber = cli.Uint64Flag{
+		Name:   "chunk.maxRowNumber",
+		Usage:  "Maximum number of rows of every sub-circuit in a chunk, 1000000 by default",
+		EnvVar: prefixEnvVar("CHUNK_MAX_ROW_NUMBER"),
+	}
+
+	ChunkMaxTxsPayloadBytes = cli.IntFlag{
+		Name:   "chunk.maxTxsPayloadBytes",
+		Usage:  "Maximum size of the L2 transactions payload of a chunk. 0 means no limit",
+		EnvVar: prefixEnvVar("CHUNK_MAX_TXS_PAYLOAD_BYTES"),
+	}
+
+	ChunkLimitsHeight = cli.Uint64Flag{
+		Name:   "chunk.limitsHeight",
+		Usage:  "L2 height from which the chunk limits apply, the default limits apply before. All sequencers must use the same height",
+		EnvVar: prefixEnvVar("CHUNK_LIMITS_HEIGHT"),
+	}
+
 	L2CrossDomainMessengerContractAddr = cli.StringFlag{
 		Name:   "l2CDMContractAddr",
 		Usage:  "L2CrossDomainMessenger contract address",
@@ -62,10 +146,16 @@ var (
 
 	L1NodeAddr = cli.StringFlag{
 		Name:   "l1.rpc",
-		Usage:  "Address of L1 User JSON-RPC endpoint to use (eth namespace required)",
+		Usage:  "Comma separated addresses of L1 User JSON-RPC endpoints to use (eth namespace required)",
 		EnvVar: prefixEnvVar("L1_ETH_RPC"),
 	}
 
+	L1Quorum = cli.IntFlag{
+		Name:   "l1.quorum",
+		Usage:  "Number of L1 endpoints which have to agree on logs, headers and receipts, a majority of l1.rpc by default",
+		EnvVar: prefixEnvVar("L1_QUORUM"),
+	}
+
 	L1ChainID = cli.Uint64Flag{
 		Name:   "l1.chain-id",
 		Usage:  "L1 Chain ID",
@@ -109,6 +199,18 @@ var (
 		EnvVar: prefixEnvVar("SYNC_FETCH_BLOCK_RANGE"),
 	}
 
+	SyncSubscribe = cli.BoolFlag{
+		Name:   "sync.subscribe",
+		Usage:  "Subscribe to new L1 heads (requires a websocket l1.rpc) to collect L1 messages as soon as they are confirmed, polling remains as a fallback",
+		EnvVar: prefixEnvVar("SYNC_SUBSCRIBE"),
+	}
+
+	SyncMode = cli.StringFlag{
+		Name:   "sync.mode",
+		Usage:  "How L1 messages are collected: logs(default) queries eth_getLogs, receipts walks L1 blocks and validates their receipts",
+		EnvVar: prefixEnvVar("SYNC_MODE"),
+	}
+
 	// db options
 	DBDataDir = cli.StringFlag{
 		Name:   "db.dir",
@@ -202,6 +304,12 @@ var (
 		Usage:  "Number of blocks that we collect in a single eth_getLogs query",
 		EnvVar: prefixEnvVar("DERIVATION_FETCH_BLOCK_RANGE"),
 	}
+
+	DerivationBlobDir = cli.StringFlag{
+		Name:   "derivation.blobDir",
+		Usage:  "Directory of the local blob store serving the blobs of blob batches, one file per blob named after its versioned hash",
+		EnvVar: prefixEnvVar("DERIVATION_BLOB_DIR"),
+	}
 	// Logger
 	LogLevel = &cli.StringFlag{
 		Name:   "log.level",
@@ -216,6 +324,12 @@ var (
 	}
 
 	// metrics
+	RPCAddr = cli.StringFlag{
+		Name:   "rpc.addr",
+		Usage:  "Listening address of the node JSON-RPC server, e.g. 127.0.0.1:26659. The server is disabled if empty",
+		EnvVar: prefixEnvVar("RPC_ADDR"),
+	}
+
 	MetricsServerEnable = cli.BoolFlag{
 		Name:   "metrics-server-enable",
 		Usage:  "Whether or not to run the embedded metrics server",
@@ -238,12 +352,27 @@ var (
 var Flags = []cli.Flag{
 	Home,
 	L1NodeAddr,
+	L1Quorum,
 	L1ChainID,
 	L1Confirmations,
 	L2EthAddr,
 	L2EngineAddr,
 	L2EngineJWTSecret,
+	L2RetryMaxInterval,
+	L2RetryMaxElapsedTime,
+	L2BreakerThreshold,
+	L2BreakerCooldown,
 	MaxL1MessageNumPerBlock,
+	L1MessageGasBudget,
+	L1MessageGasLimitFraction,
+	L1MessageMaxAge,
+	BatchVersion,
+	BatchVersionHeight,
+	BatchSelfCheck,
+	ChunkMaxBlocks,
+	ChunkMaxRowNumber,
+	ChunkMaxTxsPayloadBytes,
+	ChunkLimitsHeight,
 	L2CrossDomainMessengerContractAddr,
 	L2SequencerAddr,
 	GovAddr,
@@ -254,6 +383,8 @@ var Flags = []cli.Flag{
 	SyncPollInterval,
 	SyncLogProgressInterval,
 	SyncFetchBlockRange,
+	SyncMode,
+	SyncSubscribe,
 
 	// db options
 	DBDataDir,
@@ -276,6 +407,7 @@ var Flags = []cli.Flag{
 	DerivationPollInterval,
 	DerivationLogProgressInterval,
 	DerivationFetchBlockRange,
+	DerivationBlobDir,
 
 	// logger
 	LogLevel,
@@ -285,4 +417,6 @@ var Flags = []cli.Flag{
 	MetricsServerEnable,
 	MetricsPort,
 	MetricsHostname,
+
+	RPCAddr,
 }
@@ -0,0 +1,119 @@
+package noderpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum/rpc"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+// HealthPath is the path the health of the node is served at.
+const HealthPath = "/health"
+
+// HealthCheck returns nil while the component it checks is healthy, the reason it is not otherwise.
+type HealthCheck func() error
+
+// Server serves the JSON-RPC APIs of the node over HTTP, and its health at HealthPath.
+// APIs and health checks can be registered before or after the server is started.
+type Server struct {
+	addr   string
+	rpc    *rpc.Server
+	http   *http.Server
+	logger tmlog.Logger
+
+	mu           sync.Mutex
+	healthChecks map[string]HealthCheck
+}
+
+func NewServer(addr string, logger tmlog.Logger) *Server {
+	s := &Server{
+		addr:         addr,
+		rpc:          rpc.NewServer(),
+		logger:       logger.With("module", "rpc"),
+		healthChecks: make(map[string]HealthCheck),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthPath, s.serveHealth)
+	mux.Handle("/", s.rpc)
+	s.http = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// RegisterAPIs exposes the methods of every api service under its namespace.
+func (s *Server) RegisterAPIs(apis []rpc.API) error {
+	for _, api := range apis {
+		if err := s.rpc.RegisterName(api.Namespace, api.Service); err != nil {
+			return fmt.Errorf("failed to register %s api: %w", api.Namespace, err)
+		}
+	}
+	return nil
+}
+
+// RegisterHealthCheck adds the check of a component to the health of the node, under the name of the component.
+func (s *Server) RegisterHealthCheck(name string, check HealthCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthChecks[name] = check
+}
+
+// serveHealth runs the health checks, and answers with the status of every component:
+// 200 if all of them are healthy, 503 otherwise.
+func (s *Server) serveHealth(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	checks := make(map[string]HealthCheck, len(s.healthChecks))
+	for name, check := range s.healthChecks {
+		checks[name] = check
+	}
+	s.mu.Unlock()
+
+	status := make(map[string]string, len(checks))
+	code := http.StatusOK
+	for name, check := range checks {
+		status[name] = "ok"
+		if err := check(); err != nil {
+			status[name] = err.Error()
+			code = http.StatusServiceUnavailable
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		s.logger.Error("failed to write health status", "err", err)
+	}
+}
+
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+	s.logger.Info("rpc server started", "addr", listener.Addr())
+	go func() {
+		if err := s.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("rpc server stopped", "err", err)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) Stop() {
+	if s == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.http.Shutdown(ctx); err != nil {
+		s.logger.Error("failed to shutdown rpc server", "err", err)
+	}
+	s.rpc.Stop()
+}
@@ -0,0 +1,39 @@
+package noderpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+func TestHealth(t *testing.T) {
+	s := NewServer("127.0.0.1:0", tmlog.NewNopLogger())
+	health := func() (int, map[string]string) {
+		rec := httptest.NewRecorder()
+		s.http.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+		var status map[string]string
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		return rec.Code, status
+	}
+
+	code, status := health()
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, status)
+
+	var l2Err error
+	s.RegisterHealthCheck("l2", func() error { return l2Err })
+	s.RegisterHealthCheck("l1", func() error { return nil })
+	code, status = health()
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, map[string]string{"l1": "ok", "l2": "ok"}, status)
+
+	l2Err = errors.New("l2 geth is unavailable")
+	code, status = health()
+	require.Equal(t, http.StatusServiceUnavailable, code)
+	require.Equal(t, map[string]string{"l1": "ok", "l2": "l2 geth is unavailable"}, status)
+}
@@ -2,23 +2,32 @@ package receipt
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
 	"github.com/scroll-tech/go-ethereum/core/types"
-	"github.com/scroll-tech/go-ethereum/ethclient"
 	"github.com/scroll-tech/go-ethereum/rpc"
 )
 
+// Fetcher fetches the receipts of L1 blocks from a list of providers, in order: when a provider fails
+// or serves receipts that do not match the receipt root of the block, the next one is tried.
 type Fetcher struct {
-	l1Client  *ethclient.Client
+	providers    []*provider
+	maxBatchSize int
+}
+
+// provider is an L1 endpoint of the fetcher, with the receipts fetching jobs run against it.
+type provider struct {
 	rpcClient *rpc.Client
 
 	// cache receipts in bundles per block hash
 	// We cache the receipts fetching job to not lose progress when we have to retry the `Fetch` call
 	// common.Hash -> *receiptsFetchingJob
 	receiptsCache *lru.Cache
-	maxBatchSize  int
 }
 
 type FetcherConfig struct {
@@ -32,47 +41,93 @@ func defaultFetcherConfig() *FetcherConfig {
 	}
 }
 
-func NewFetcher(l1Addr string, config *FetcherConfig) (*Fetcher, error) {
-	c, err := rpc.DialContext(context.Background(), l1Addr)
-	if err != nil {
-		return nil, err
+func NewFetcher(l1Addrs []string, config *FetcherConfig) (*Fetcher, error) {
+	if len(l1Addrs) == 0 {
+		return nil, errors.New("no L1 address to fetch receipts from")
 	}
+	clients := make([]*rpc.Client, 0, len(l1Addrs))
+	for i, addr := range l1Addrs {
+		c, err := rpc.DialContext(context.Background(), addr)
+		if err != nil {
+			for _, client := range clients {
+				client.Close()
+			}
+			return nil, fmt.Errorf("failed to dial L1 provider %d: %w", i, err)
+		}
+		clients = append(clients, c)
+	}
+	return newFetcher(clients, config)
+}
+
+func newFetcher(clients []*rpc.Client, config *FetcherConfig) (*Fetcher, error) {
 	if config == nil {
 		config = defaultFetcherConfig()
 	}
-	cache, err := lru.New(config.ReceiptsCacheSize)
-	if err != nil {
-		return nil, err
+	providers := make([]*provider, len(clients))
+	for i, c := range clients {
+		cache, err := lru.New(config.ReceiptsCacheSize)
+		if err != nil {
+			return nil, err
+		}
+		providers[i] = &provider{
+			rpcClient: c,
+
+			receiptsCache: cache,
+		}
 	}
-	l1Client := ethclient.NewClient(c)
 	return &Fetcher{
-		l1Client:  l1Client,
-		rpcClient: c,
-
-		receiptsCache: cache,
-		maxBatchSize:  config.MaxRequestPerBatch,
+		providers:    providers,
+		maxBatchSize: config.MaxRequestPerBatch,
 	}, nil
 }
 
-func (f *Fetcher) Fetch(ctx context.Context, blockHash common.Hash) (types.Receipts, error) {
-	l1Block, err := f.l1Client.BlockByHash(ctx, blockHash)
-	if err != nil {
+// Fetch returns the receipts of the block of the header from the first provider to serve them.
+// The header comes from a trusted source, such as the quorum client: the receipts are checked against its receipt root.
+// The block hash is taken from the providers, the headers of L1 forks this client does not know can not be hashed.
+func (f *Fetcher) Fetch(ctx context.Context, header *types.Header) (types.Receipts, error) {
+	var errs []error
+	for i, p := range f.providers {
+		receipts, err := p.fetch(ctx, header, f.maxBatchSize)
+		if err == nil {
+			return receipts, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("provider %d: %w", i, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// rpcBlock is the part of a block without the transaction bodies which the receipts are fetched with.
+// The transactions are not decoded: L1 may have transaction types this client does not know.
+type rpcBlock struct {
+	Hash         common.Hash   `json:"hash"`
+	ReceiptsRoot common.Hash   `json:"receiptsRoot"`
+	Transactions []common.Hash `json:"transactions"`
+}
+
+func (p *provider) fetch(ctx context.Context, header *types.Header, maxBatchSize int) (types.Receipts, error) {
+	var block *rpcBlock
+	if err := p.rpcClient.CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.EncodeBig(header.Number), false); err != nil {
 		return nil, err
 	}
-	txs := l1Block.Transactions()
+	if block == nil {
+		return nil, ethereum.NotFound
+	}
+	// the provider may be on another fork
+	if block.ReceiptsRoot != header.ReceiptHash {
+		return nil, fmt.Errorf("block %d has receipt root %s, expected %s", header.Number, block.ReceiptsRoot, header.ReceiptHash)
+	}
 	// Try to reuse the receipts fetcher because is caches the results of intermediate calls. This means
 	// that if just one of many calls fail, we only retry the failed call rather than all of the calls.
 	// The underlying fetcher uses the receipts hash to verify receipt integrity.
 	var job *receiptsFetchingJob
-	if v, ok := f.receiptsCache.Get(blockHash); ok {
+	if v, ok := p.receiptsCache.Get(block.Hash); ok {
 		job = v.(*receiptsFetchingJob)
 	} else {
-		txHashes := make([]common.Hash, len(txs))
-		for i := 0; i < len(txs); i++ {
-			txHashes[i] = txs[i].Hash()
-		}
-		job = NewReceiptsFetchingJob(f.rpcClient, f.maxBatchSize, ToBlockID(l1Block), l1Block.ReceiptHash(), txHashes)
-		f.receiptsCache.Add(blockHash, job)
+		job = NewReceiptsFetchingJob(p.rpcClient, maxBatchSize, BlockID{Hash: block.Hash, Number: header.Number.Uint64()}, header.ReceiptHash, block.Transactions)
+		p.receiptsCache.Add(block.Hash, job)
 	}
 	return job.Fetch(ctx)
 }
@@ -0,0 +1,136 @@
+package receipt
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/common/hexutil"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/scroll-tech/go-ethereum/trie"
+	"github.com/stretchr/testify/require"
+)
+
+// blobTxType is the type of the EIP-4844 transactions, which the client does not decode.
+const blobTxType = 3
+
+// testL1 serves a single block and its receipts. tampered serves receipts which do not match the block.
+type testL1 struct {
+	header   *types.Header
+	hash     common.Hash
+	receipts types.Receipts
+	tampered bool
+}
+
+func (l *testL1) GetBlockByNumber(number hexutil.Big, full bool) (map[string]interface{}, error) {
+	if number.ToInt().Cmp(l.header.Number) != 0 {
+		return nil, nil
+	}
+	txHashes := make([]common.Hash, len(l.receipts))
+	for i, r := range l.receipts {
+		txHashes[i] = r.TxHash
+	}
+	return map[string]interface{}{
+		"hash":         l.hash,
+		"number":       (*hexutil.Big)(l.header.Number),
+		"receiptsRoot": l.header.ReceiptHash,
+		"transactions": txHashes,
+	}, nil
+}
+
+func (l *testL1) GetTransactionReceipt(txHash common.Hash) (*types.Receipt, error) {
+	for _, r := range l.receipts {
+		if r.TxHash == txHash {
+			if l.tampered {
+				tampered := *r
+				tampered.CumulativeGasUsed++
+				return &tampered, nil
+			}
+			return r, nil
+		}
+	}
+	return nil, nil
+}
+
+// testBlock returns the header of a block with a legacy and a blob transaction, the hash L1 reports for it,
+// which the client can not compute from the header, and the receipts.
+func testBlock() (*types.Header, common.Hash, types.Receipts) {
+	header := &types.Header{Number: big.NewInt(100), GasLimit: 30_000_000, GasUsed: 42000}
+	hash := common.Hash{0xb1}
+	var receipts types.Receipts
+	for i, txType := range []uint8{types.LegacyTxType, blobTxType} {
+		txHash := common.Hash{byte(i + 1)}
+		receipt := &types.Receipt{
+			Type:              txType,
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: 21000 * uint64(i+1),
+			GasUsed:           21000,
+			TxHash:            txHash,
+			BlockHash:         hash,
+			BlockNumber:       header.Number,
+			TransactionIndex:  uint(i),
+			Logs: []*types.Log{{
+				Address:     common.HexToAddress("0x2"),
+				Topics:      []common.Hash{{1}},
+				Data:        []byte{},
+				TxHash:      txHash,
+				TxIndex:     uint(i),
+				Index:       uint(i),
+				BlockHash:   hash,
+				BlockNumber: header.Number.Uint64(),
+			}},
+		}
+		receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+		receipts = append(receipts, receipt)
+	}
+	header.ReceiptHash = types.DeriveSha(consensusReceipts(receipts), trie.NewStackTrie(nil))
+	return header, hash, receipts
+}
+
+func testClient(t *testing.T, l1 *testL1) *rpc.Client {
+	server := rpc.NewServer()
+	if l1 != nil {
+		require.NoError(t, server.RegisterName("eth", l1))
+	}
+	t.Cleanup(server.Stop)
+	return rpc.DialInProc(server)
+}
+
+func TestFetcher_Fallback(t *testing.T) {
+	header, hash, receipts := testBlock()
+	require.NotEqual(t, hash, header.Hash())
+	fetcher, err := newFetcher([]*rpc.Client{
+		// does not serve the eth namespace
+		testClient(t, nil),
+		testClient(t, &testL1{header: header, hash: hash, receipts: receipts, tampered: true}),
+		testClient(t, &testL1{header: header, hash: hash, receipts: receipts}),
+	}, nil)
+	require.NoError(t, err)
+
+	got, err := fetcher.Fetch(context.Background(), header)
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	require.Equal(t, receipts[1].TxHash, got[1].TxHash)
+	require.EqualValues(t, blobTxType, got[1].Type)
+	require.Equal(t, receipts[1].CumulativeGasUsed, got[1].CumulativeGasUsed)
+
+	// no provider serves valid receipts
+	fetcher, err = newFetcher([]*rpc.Client{
+		testClient(t, nil),
+		testClient(t, &testL1{header: header, hash: hash, receipts: receipts, tampered: true}),
+	}, nil)
+	require.NoError(t, err)
+	_, err = fetcher.Fetch(context.Background(), header)
+	require.ErrorContains(t, err, "provider 0")
+	require.ErrorContains(t, err, "provider 1")
+
+	// the provider is on another fork
+	otherFork := types.CopyHeader(header)
+	otherFork.ReceiptHash = common.Hash{1}
+	fetcher, err = newFetcher([]*rpc.Client{testClient(t, &testL1{header: otherFork, hash: hash, receipts: receipts})}, nil)
+	require.NoError(t, err)
+	_, err = fetcher.Fetch(context.Background(), header)
+	require.ErrorContains(t, err, "receipt root")
+}
@@ -1,6 +1,7 @@
 package receipt
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/rlp"
 	"github.com/scroll-tech/go-ethereum/rpc"
 	"github.com/scroll-tech/go-ethereum/trie"
 )
@@ -94,13 +96,44 @@ func validateReceipts(block BlockID, receiptHash common.Hash, txHashes []common.
 	// Sanity-check: external L1-RPC sources are notorious for not returning all receipts,
 	// or returning them out-of-order. Verify the receipts against the expected receipt-hash.
 	hasher := trie.NewStackTrie(nil)
-	computed := types.DeriveSha(types.Receipts(receipts), hasher)
+	computed := types.DeriveSha(consensusReceipts(receipts), hasher)
 	if receiptHash != computed {
 		return fmt.Errorf("failed to fetch list of receipts: expected receipt root %s but computed %s from retrieved receipts", receiptHash, computed)
 	}
 	return nil
 }
 
+// consensusReceipts encodes the receipts the way they are hashed into the receipt root. Unlike types.Receipts,
+// it encodes the receipts of any typed transaction, L1 has types this client does not know, such as blob transactions.
+type consensusReceipts []*types.Receipt
+
+type receiptRLP struct {
+	PostStateOrStatus []byte
+	CumulativeGasUsed uint64
+	Bloom             types.Bloom
+	Logs              []*types.Log
+}
+
+func (rs consensusReceipts) Len() int {
+	return len(rs)
+}
+
+func (rs consensusReceipts) EncodeIndex(i int, w *bytes.Buffer) {
+	r := rs[i]
+	status := r.PostState
+	if len(status) == 0 {
+		status = []byte{}
+		if r.Status == types.ReceiptStatusSuccessful {
+			status = []byte{0x01}
+		}
+	}
+	if r.Type != types.LegacyTxType {
+		w.WriteByte(r.Type)
+	}
+	// encoding a receipt into a buffer does not fail
+	_ = rlp.Encode(w, &receiptRLP{status, r.CumulativeGasUsed, r.Bloom, r.Logs})
+}
+
 // receiptsFetchingJob runs the receipt fetching for a specific block,
 // and can re-run and adapt based on the fetching method preferences and errors communicated with the requester.
 type receiptsFetchingJob struct {
@@ -0,0 +1,104 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/tendermint/tendermint/blssignatures"
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	"github.com/tendermint/tendermint/libs/tempfile"
+)
+
+// batchSignState is the last batch hash signed, with the height and round of the precommit it was signed for.
+type batchSignState struct {
+	Height    int64            `json:"height"`
+	Round     int32            `json:"round"`
+	BatchHash tmbytes.HexBytes `json:"batch_hash,omitempty"`
+}
+
+// batchSigner signs the batch hashes with the BLS key, behind a guard against double signing.
+// It refuses to sign for a past height or round, to sign another batch hash than the one signed for the same height
+// and round, or to sign the last batch hash signed again for another height. A later round of the same height
+// may sign the same batch hash again, as the consensus precommits the block it is locked on. The last batch hash signed is
+// saved to the state file before it is signed, so a restart of the node cannot sign a conflicting one.
+type batchSigner struct {
+	blsPrivKey *blssignatures.PrivateKey
+
+	mu        sync.Mutex
+	state     batchSignState
+	stateFile string
+}
+
+// newBatchSigner creates the batch signer with the BLS key of blsKeyFile, generated if it is missing, starting from
+// the last batch hash signed saved in stateFile.
+func newBatchSigner(blsKeyFile, stateFile string) (*batchSigner, error) {
+	blsPrivKey, err := loadOrGenBLSKey(blsKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	s := &batchSigner{blsPrivKey: blsPrivKey, stateFile: stateFile}
+	stateBytes, err := os.ReadFile(stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the batch sign state: %w", err)
+	}
+	if err = json.Unmarshal(stateBytes, &s.state); err != nil {
+		return nil, fmt.Errorf("failed to decode the batch sign state %s: %w", stateFile, err)
+	}
+	return s, nil
+}
+
+func (s *batchSigner) BLSPrivKey() (*blssignatures.PrivateKey, error) {
+	return s.blsPrivKey, nil
+}
+
+func (s *batchSigner) SignBatch(height int64, round int32, batchHash []byte) ([]byte, error) {
+	if len(batchHash) == 0 {
+		return nil, errors.New("empty batch hash")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.check(height, round, batchHash); err != nil {
+		return nil, err
+	}
+	sig, err := blssignatures.SignMessage(*s.blsPrivKey, batchHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the batch hash: %w", err)
+	}
+	return blssignatures.SignatureToBytes(sig), nil
+}
+
+// check checks the batch hash against the last batch hash signed, and saves it as the last batch hash signed.
+func (s *batchSigner) check(height int64, round int32, batchHash []byte) error {
+	last := s.state
+	sameHash := bytes.Equal(batchHash, last.BatchHash)
+	switch {
+	case height < last.Height:
+		return fmt.Errorf("%w: batch height regression, got %d, last height %d", ErrDoubleSign, height, last.Height)
+	case height == last.Height && round < last.Round:
+		return fmt.Errorf("%w: batch round regression at height %d, got %d, last round %d", ErrDoubleSign, height, round, last.Round)
+	case height == last.Height && round == last.Round:
+		if !sameHash {
+			return fmt.Errorf("%w: conflicting batch hash at height %d round %d", ErrDoubleSign, height, round)
+		}
+		return nil
+	case height > last.Height && sameHash:
+		return fmt.Errorf("%w: batch hash %x signed at height %d already", ErrDoubleSign, batchHash, last.Height)
+	}
+	state := batchSignState{Height: height, Round: round, BatchHash: batchHash}
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err = tempfile.WriteFileAtomic(s.stateFile, stateBytes, 0600); err != nil {
+		return fmt.Errorf("failed to save the batch sign state: %w", err)
+	}
+	s.state = state
+	return nil
+}
@@ -0,0 +1,183 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	tmbytes "github.com/tendermint/tendermint/libs/bytes"
+	tmjson "github.com/tendermint/tendermint/libs/json"
+	"github.com/tendermint/tendermint/libs/protoio"
+	"github.com/tendermint/tendermint/libs/tempfile"
+	"github.com/tendermint/tendermint/privval"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// the steps of a round, in the order they are signed in
+const (
+	stepPropose   int8 = 1
+	stepPrevote   int8 = 2
+	stepPrecommit int8 = 3
+)
+
+var ErrDoubleSign = errors.New("refused to double sign")
+
+// signState is the last consensus message signed. SignBytes are the sign bytes of the message without the timestamp:
+// the consensus may ask to sign a message again with another timestamp only.
+type signState struct {
+	Height    int64            `json:"height"`
+	Round     int32            `json:"round"`
+	Step      int8             `json:"step"`
+	SignBytes tmbytes.HexBytes `json:"signbytes,omitempty"`
+}
+
+// doubleSignGuard forwards the consensus messages to the signer only if they follow the last message signed.
+// It refuses to sign a message of a past height, round or step, or a message other than the one signed
+// for the same height, round and step. The last message signed is saved to the state file before it is forwarded,
+// so a restart of the node cannot sign a conflicting message, whatever the remote signer keeps.
+type doubleSignGuard struct {
+	types.PrivValidator
+
+	mu        sync.Mutex
+	state     signState
+	stateFile string
+}
+
+// newDoubleSignGuard creates the guard, starting from the last message signed saved in stateFile. Without one, as when
+// the node moves from its local key to a remote signer, it starts from the last message the local key signed,
+// kept by the FilePV in filePVStateFile.
+func newDoubleSignGuard(privValidator types.PrivValidator, stateFile, filePVStateFile string) (*doubleSignGuard, error) {
+	guard := &doubleSignGuard{PrivValidator: privValidator, stateFile: stateFile}
+	stateBytes, err := os.ReadFile(stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		if guard.state, err = loadFilePVSignState(filePVStateFile); err != nil {
+			return nil, err
+		}
+		return guard, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the sign state: %w", err)
+	}
+	if err = json.Unmarshal(stateBytes, &guard.state); err != nil {
+		return nil, fmt.Errorf("failed to decode the sign state %s: %w", stateFile, err)
+	}
+	return guard, nil
+}
+
+func (g *doubleSignGuard) SignVote(chainID string, vote *tmproto.Vote) error {
+	var step int8
+	switch vote.Type {
+	case tmproto.PrevoteType:
+		step = stepPrevote
+	case tmproto.PrecommitType:
+		step = stepPrecommit
+	default:
+		return fmt.Errorf("unknown vote type %v", vote.Type)
+	}
+	noTimestamp := *vote
+	noTimestamp.Timestamp = time.Time{}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.check(vote.Height, vote.Round, step, types.VoteSignBytes(chainID, &noTimestamp)); err != nil {
+		return err
+	}
+	return g.PrivValidator.SignVote(chainID, vote)
+}
+
+func (g *doubleSignGuard) SignProposal(chainID string, proposal *tmproto.Proposal) error {
+	noTimestamp := *proposal
+	noTimestamp.Timestamp = time.Time{}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.check(proposal.Height, proposal.Round, stepPropose, types.ProposalSignBytes(chainID, &noTimestamp)); err != nil {
+		return err
+	}
+	return g.PrivValidator.SignProposal(chainID, proposal)
+}
+
+// check checks the message against the last message signed, and saves it as the last message signed.
+func (g *doubleSignGuard) check(height int64, round int32, step int8, signBytes []byte) error {
+	last := g.state
+	switch {
+	case height < last.Height:
+		return fmt.Errorf("%w: height regression, got %d, last height %d", ErrDoubleSign, height, last.Height)
+	case height == last.Height && round < last.Round:
+		return fmt.Errorf("%w: round regression at height %d, got %d, last round %d", ErrDoubleSign, height, round, last.Round)
+	case height == last.Height && round == last.Round && step < last.Step:
+		return fmt.Errorf("%w: step regression at height %d round %d, got %d, last step %d", ErrDoubleSign, height, round, step, last.Step)
+	case height == last.Height && round == last.Round && step == last.Step:
+		if !bytes.Equal(signBytes, last.SignBytes) {
+			return fmt.Errorf("%w: conflicting data at height %d round %d step %d", ErrDoubleSign, height, round, step)
+		}
+		return nil
+	}
+	state := signState{Height: height, Round: round, Step: step, SignBytes: signBytes}
+	stateBytes, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err = tempfile.WriteFileAtomic(g.stateFile, stateBytes, 0600); err != nil {
+		return fmt.Errorf("failed to save the sign state: %w", err)
+	}
+	g.state = state
+	return nil
+}
+
+// loadFilePVSignState returns the last message signed by the FilePV, an empty state if the FilePV never signed.
+// The sign bytes of the FilePV are stripped of the timestamp the way the guard keeps them. If they cannot be decoded,
+// they are left out: the guard refuses to sign at the height, round and step of the message again then.
+func loadFilePVSignState(filePVStateFile string) (signState, error) {
+	stateBytes, err := os.ReadFile(filePVStateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return signState{}, nil
+	}
+	if err != nil {
+		return signState{}, fmt.Errorf("failed to read the private validator state: %w", err)
+	}
+	var lastSignState privval.FilePVLastSignState
+	if err = tmjson.Unmarshal(stateBytes, &lastSignState); err != nil {
+		return signState{}, fmt.Errorf("failed to decode the private validator state %s: %w", filePVStateFile, err)
+	}
+	return signState{
+		Height:    lastSignState.Height,
+		Round:     lastSignState.Round,
+		Step:      lastSignState.Step,
+		SignBytes: signBytesWithoutTimestamp(lastSignState.Step, lastSignState.SignBytes),
+	}, nil
+}
+
+func signBytesWithoutTimestamp(step int8, signBytes []byte) []byte {
+	var (
+		stripped []byte
+		err      error
+	)
+	switch step {
+	case stepPropose:
+		var proposal tmproto.CanonicalProposal
+		if err = protoio.UnmarshalDelimited(signBytes, &proposal); err != nil {
+			return nil
+		}
+		proposal.Timestamp = time.Time{}
+		stripped, err = protoio.MarshalDelimited(&proposal)
+	case stepPrevote, stepPrecommit:
+		var vote tmproto.CanonicalVote
+		if err = protoio.UnmarshalDelimited(signBytes, &vote); err != nil {
+			return nil
+		}
+		vote.Timestamp = time.Time{}
+		stripped, err = protoio.MarshalDelimited(&vote)
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	return stripped
+}
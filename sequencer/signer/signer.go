@@ -0,0 +1,134 @@
+package signer
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/tendermint/tendermint/blssignatures"
+	"github.com/tendermint/tendermint/config"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	tmos "github.com/tendermint/tendermint/libs/os"
+	"github.com/tendermint/tendermint/privval"
+	"github.com/tendermint/tendermint/types"
+)
+
+const (
+	// remoteSignerRetries and remoteSignerRetryTimeout bound the retries of a request to the remote signer, 5s in total.
+	remoteSignerRetries      = 50
+	remoteSignerRetryTimeout = 100 * time.Millisecond
+
+	// signStateFile is the file the last consensus message signed through the remote signer is kept in,
+	// in the directory of the private validator state.
+	signStateFile = "remote_signer_state.json"
+	// batchSignStateFile is the file the last batch hash signed with the BLS key is kept in, next to signStateFile.
+	batchSignStateFile = "batch_sign_state.json"
+)
+
+// Signer signs the consensus messages of the sequencer: the votes and proposals with the Tendermint key,
+// and the batches with the BLS key.
+type Signer interface {
+	types.PrivValidator
+
+	// SignBatch signs the batch hash precommitted at the height and round with the BLS key, and returns the signature.
+	// It returns ErrDoubleSign instead if the batch hash conflicts with the last one signed.
+	SignBatch(height int64, round int32, batchHash []byte) ([]byte, error)
+
+	// BLSPrivKey returns the BLS key the consensus signs the batch hashes with.
+	// The Tendermint fork takes the key itself and signs them in-process, bypassing SignBatch and its guard, until
+	// its consensus signs through SignBatch. The BLS key is a local key with any signer, a remote signer only holds
+	// the Tendermint key.
+	BLSPrivKey() (*blssignatures.PrivateKey, error)
+
+	// Close releases the connection to the signer.
+	Close() error
+}
+
+// NewSigner returns the signer set up by the Tendermint config. If priv_validator_laddr is set, the node listens on it
+// for a remote signer holding the Tendermint key, such as tmkms, and signs through it. The Tendermint key is read from
+// priv_validator_key_file otherwise, generated if it is missing.
+// In both cases the BLS key is read from the BLS key file, generated if it is missing.
+func NewSigner(tmCfg *config.Config, logger tmlog.Logger) (Signer, error) {
+	stateDir := filepath.Dir(tmCfg.PrivValidatorStateFile())
+	batchSigner, err := newBatchSigner(tmCfg.BLSKeyFile(), filepath.Join(stateDir, batchSignStateFile))
+	if err != nil {
+		return nil, err
+	}
+	if tmCfg.PrivValidatorListenAddr == "" {
+		return &localSigner{
+			FilePV:      privval.LoadOrGenFilePV(tmCfg.PrivValidatorKeyFile(), tmCfg.PrivValidatorStateFile()),
+			batchSigner: batchSigner,
+		}, nil
+	}
+	genDoc, err := types.GenesisDocFromFile(tmCfg.GenesisFile())
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the genesis file: %w", err)
+	}
+	signerClient, err := newRemoteSignerClient(tmCfg.PrivValidatorListenAddr, genDoc.ChainID, logger)
+	if err != nil {
+		return nil, err
+	}
+	guard, err := newDoubleSignGuard(signerClient, filepath.Join(stateDir, signStateFile), tmCfg.PrivValidatorStateFile())
+	if err != nil {
+		signerClient.Close()
+		return nil, err
+	}
+	logger.Info("signing the consensus messages through the remote signer", "laddr", tmCfg.PrivValidatorListenAddr)
+	return &remoteSigner{
+		doubleSignGuard: guard,
+		batchSigner:     batchSigner,
+		client:          signerClient,
+	}, nil
+}
+
+// newRemoteSignerClient listens on the address for the remote signer, and waits for it to connect.
+func newRemoteSignerClient(listenAddr, chainID string, logger tmlog.Logger) (*privval.RetrySignerClient, error) {
+	endpoint, err := privval.NewSignerListener(listenAddr, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for the remote signer: %w", err)
+	}
+	signerClient, err := privval.NewSignerClient(endpoint, chainID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start the remote signer client: %w", err)
+	}
+	// the first request waits for the remote signer to connect
+	if _, err = signerClient.GetPubKey(); err != nil {
+		signerClient.Close()
+		return nil, fmt.Errorf("failed to get the public key from the remote signer: %w", err)
+	}
+	return privval.NewRetrySignerClient(signerClient, remoteSignerRetries, remoteSignerRetryTimeout), nil
+}
+
+// localSigner signs with the keys kept in the files of the Tendermint config.
+// The FilePV keeps the last consensus message signed, which protects it from double signing.
+type localSigner struct {
+	*privval.FilePV
+	*batchSigner
+}
+
+func (s *localSigner) Close() error {
+	return nil
+}
+
+// remoteSigner signs the votes and proposals through a remote signer, behind a guard against double signing.
+// The batch hashes are signed with the local BLS key, the remote signer protocol has no message for them.
+type remoteSigner struct {
+	*doubleSignGuard
+	*batchSigner
+	client *privval.RetrySignerClient
+}
+
+func (s *remoteSigner) Close() error {
+	return s.client.Close()
+}
+
+func loadOrGenBLSKey(blsKeyFile string) (*blssignatures.PrivateKey, error) {
+	if !tmos.FileExists(blsKeyFile) {
+		blssignatures.GenFileBLSKey().Save(blsKeyFile)
+	}
+	blsPrivKey, err := blssignatures.PrivateKeyFromBytes(blssignatures.LoadBLSKey(blsKeyFile).PrivKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load bls priv key: %w", err)
+	}
+	return &blsPrivKey, nil
+}
@@ -0,0 +1,188 @@
+package signer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tendermint/tendermint/blssignatures"
+	"github.com/tendermint/tendermint/config"
+	"github.com/tendermint/tendermint/crypto/ed25519"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+	"github.com/tendermint/tendermint/privval"
+	tmproto "github.com/tendermint/tendermint/proto/tendermint/types"
+	"github.com/tendermint/tendermint/types"
+)
+
+// startTestSigner starts a stand-in remote signer holding the key of the mock validator,
+// which connects to the node at the address.
+func startTestSigner(t *testing.T, addr, chainID string, pv types.PrivValidator) {
+	endpoint := privval.NewSignerDialerEndpoint(
+		tmlog.NewNopLogger(),
+		privval.DialTCPFn(addr, time.Second, ed25519.GenPrivKey()),
+		privval.SignerDialerEndpointConnRetries(100),
+		privval.SignerDialerEndpointRetryWaitInterval(50*time.Millisecond),
+	)
+	server := privval.NewSignerServer(endpoint, chainID, pv)
+	require.NoError(t, server.Start())
+	t.Cleanup(func() { _ = server.Stop() })
+}
+
+func testVote(height int64, round int32, voteType tmproto.SignedMsgType, block byte) *tmproto.Vote {
+	return &tmproto.Vote{
+		Type:      voteType,
+		Height:    height,
+		Round:     round,
+		BlockID:   tmproto.BlockID{Hash: tmhash.Sum([]byte{block}), PartSetHeader: tmproto.PartSetHeader{Total: 1, Hash: tmhash.Sum([]byte{block, 1})}},
+		Timestamp: time.Now(),
+	}
+}
+
+func TestDoubleSignGuard(t *testing.T) {
+	dir := t.TempDir()
+	stateFile, filePVStateFile := filepath.Join(dir, signStateFile), filepath.Join(dir, "priv_validator_state.json")
+	guard, err := newDoubleSignGuard(types.NewMockPV(), stateFile, filePVStateFile)
+	require.NoError(t, err)
+	chainID := "test"
+
+	require.NoError(t, guard.SignProposal(chainID, &tmproto.Proposal{Type: tmproto.ProposalType, Height: 10, Round: 0, PolRound: -1, Timestamp: time.Now()}))
+	require.NoError(t, guard.SignVote(chainID, testVote(10, 0, tmproto.PrevoteType, 1)))
+	// the same vote again, at another time
+	require.NoError(t, guard.SignVote(chainID, testVote(10, 0, tmproto.PrevoteType, 1)))
+	// another block at the same step
+	require.ErrorIs(t, guard.SignVote(chainID, testVote(10, 0, tmproto.PrevoteType, 2)), ErrDoubleSign)
+	require.NoError(t, guard.SignVote(chainID, testVote(10, 0, tmproto.PrecommitType, 1)))
+	// step, round and height regressions
+	require.ErrorIs(t, guard.SignVote(chainID, testVote(10, 0, tmproto.PrevoteType, 1)), ErrDoubleSign)
+	require.NoError(t, guard.SignVote(chainID, testVote(10, 1, tmproto.PrevoteType, 2)))
+	require.ErrorIs(t, guard.SignProposal(chainID, &tmproto.Proposal{Type: tmproto.ProposalType, Height: 10, Round: 0, PolRound: -1}), ErrDoubleSign)
+	require.NoError(t, guard.SignVote(chainID, testVote(11, 0, tmproto.PrevoteType, 3)))
+	require.ErrorIs(t, guard.SignVote(chainID, testVote(10, 2, tmproto.PrevoteType, 2)), ErrDoubleSign)
+
+	// the last message signed is kept over restarts
+	guard, err = newDoubleSignGuard(types.NewMockPV(), stateFile, filePVStateFile)
+	require.NoError(t, err)
+	require.EqualValues(t, 11, guard.state.Height)
+	require.ErrorIs(t, guard.SignVote(chainID, testVote(11, 0, tmproto.PrevoteType, 4)), ErrDoubleSign)
+	require.NoError(t, guard.SignVote(chainID, testVote(11, 0, tmproto.PrevoteType, 3)))
+}
+
+func TestDoubleSignGuardFromFilePV(t *testing.T) {
+	dir := t.TempDir()
+	stateFile, filePVStateFile := filepath.Join(dir, signStateFile), filepath.Join(dir, "priv_validator_state.json")
+	chainID := "test"
+
+	// the node signed with its local key before moving to the remote signer
+	filePV := privval.GenFilePV(filepath.Join(dir, "priv_validator_key.json"), filePVStateFile)
+	filePV.Save()
+	require.NoError(t, filePV.SignVote(chainID, testVote(10, 1, tmproto.PrevoteType, 1)))
+
+	guard, err := newDoubleSignGuard(filePV, stateFile, filePVStateFile)
+	require.NoError(t, err)
+	require.EqualValues(t, 10, guard.state.Height)
+	require.EqualValues(t, 1, guard.state.Round)
+	require.Equal(t, stepPrevote, guard.state.Step)
+	// another block at the step the local key signed at, and a regression
+	require.ErrorIs(t, guard.SignVote(chainID, testVote(10, 1, tmproto.PrevoteType, 2)), ErrDoubleSign)
+	require.ErrorIs(t, guard.SignVote(chainID, testVote(10, 0, tmproto.PrecommitType, 1)), ErrDoubleSign)
+	// the vote the local key signed, at another time
+	require.NoError(t, guard.SignVote(chainID, testVote(10, 1, tmproto.PrevoteType, 1)))
+	require.NoError(t, guard.SignVote(chainID, testVote(10, 1, tmproto.PrecommitType, 1)))
+
+	// the state of the guard takes over from then on
+	guard, err = newDoubleSignGuard(filePV, stateFile, filePVStateFile)
+	require.NoError(t, err)
+	require.Equal(t, stepPrecommit, guard.state.Step)
+}
+
+func TestBatchSigner(t *testing.T) {
+	dir := t.TempDir()
+	blsKeyFile, stateFile := filepath.Join(dir, "bls_key.json"), filepath.Join(dir, batchSignStateFile)
+	s, err := newBatchSigner(blsKeyFile, stateFile)
+	require.NoError(t, err)
+	blsPubKey, err := blssignatures.PublicKeyFromPrivateKey(*s.blsPrivKey)
+	require.NoError(t, err)
+	hashA, hashB := tmhash.Sum([]byte{1}), tmhash.Sum([]byte{2})
+
+	sig, err := s.SignBatch(10, 0, hashA)
+	require.NoError(t, err)
+	blsSig, err := blssignatures.SignatureFromBytes(sig)
+	require.NoError(t, err)
+	valid, err := blssignatures.VerifySignature(blsSig, hashA, blsPubKey)
+	require.NoError(t, err)
+	require.True(t, valid)
+	// the same batch hash again, and at a later round the consensus is locked on it
+	_, err = s.SignBatch(10, 0, hashA)
+	require.NoError(t, err)
+	_, err = s.SignBatch(10, 1, hashA)
+	require.NoError(t, err)
+	// another batch hash at the same round, a regression, and the batch hash again at another height
+	_, err = s.SignBatch(10, 1, hashB)
+	require.ErrorIs(t, err, ErrDoubleSign)
+	_, err = s.SignBatch(10, 0, hashB)
+	require.ErrorIs(t, err, ErrDoubleSign)
+	_, err = s.SignBatch(11, 0, hashA)
+	require.ErrorIs(t, err, ErrDoubleSign)
+	_, err = s.SignBatch(11, 0, hashB)
+	require.NoError(t, err)
+
+	// the last batch hash signed and the BLS key are kept over restarts
+	restarted, err := newBatchSigner(blsKeyFile, stateFile)
+	require.NoError(t, err)
+	require.Equal(t, s.blsPrivKey, restarted.blsPrivKey)
+	_, err = restarted.SignBatch(11, 0, hashA)
+	require.ErrorIs(t, err, ErrDoubleSign)
+	_, err = restarted.SignBatch(11, 0, hashB)
+	require.NoError(t, err)
+}
+
+func TestLocalSigner(t *testing.T) {
+	tmCfg := config.ResetTestRoot("local_signer")
+	t.Cleanup(func() { _ = os.RemoveAll(tmCfg.RootDir) })
+	s, err := NewSigner(tmCfg, tmlog.NewNopLogger())
+	require.NoError(t, err)
+	_, ok := s.(*localSigner)
+	require.True(t, ok)
+	blsKey, err := s.BLSPrivKey()
+	require.NoError(t, err)
+	// the BLS key generated is kept
+	blsKeyAgain, err := s.BLSPrivKey()
+	require.NoError(t, err)
+	require.Equal(t, blsKey, blsKeyAgain)
+	require.NoError(t, s.Close())
+}
+
+func TestRemoteSigner(t *testing.T) {
+	tmCfg := config.ResetTestRoot("remote_signer")
+	t.Cleanup(func() { _ = os.RemoveAll(tmCfg.RootDir) })
+	addr := privval.GetFreeLocalhostAddrPort()
+	tmCfg.PrivValidatorListenAddr = "tcp://" + addr
+	genDoc, err := types.GenesisDocFromFile(tmCfg.GenesisFile())
+	require.NoError(t, err)
+
+	pv := types.NewMockPV()
+	startTestSigner(t, addr, genDoc.ChainID, pv)
+	s, err := NewSigner(tmCfg, tmlog.NewNopLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	pubKey, err := s.GetPubKey()
+	require.NoError(t, err)
+	require.Equal(t, pv.PrivKey.PubKey(), pubKey)
+
+	vote := testVote(5, 0, tmproto.PrevoteType, 1)
+	require.NoError(t, s.SignVote(genDoc.ChainID, vote))
+	require.True(t, pubKey.VerifySignature(types.VoteSignBytes(genDoc.ChainID, vote), vote.Signature))
+	// the guard stops a conflicting vote before the remote signer
+	conflicting := testVote(5, 0, tmproto.PrevoteType, 2)
+	require.ErrorIs(t, s.SignVote(genDoc.ChainID, conflicting), ErrDoubleSign)
+	require.Empty(t, conflicting.Signature)
+
+	_, err = s.BLSPrivKey()
+	require.NoError(t, err)
+	_, err = s.SignBatch(5, 0, tmhash.Sum([]byte{1}))
+	require.NoError(t, err)
+}
@@ -8,18 +8,16 @@ import (
 
 	"github.com/morph-l2/node/core"
 	"github.com/morph-l2/node/flags"
+	"github.com/morph-l2/node/sequencer/signer"
 	nodetypes "github.com/morph-l2/node/types"
 	"github.com/spf13/viper"
 	tmtypes "github.com/tendermint/tendermint/abci/types"
-	"github.com/tendermint/tendermint/blssignatures"
 	"github.com/tendermint/tendermint/config"
 	tmflags "github.com/tendermint/tendermint/libs/cli/flags"
 	tmlog "github.com/tendermint/tendermint/libs/log"
-	tmos "github.com/tendermint/tendermint/libs/os"
 	tmnode "github.com/tendermint/tendermint/node"
 	"github.com/tendermint/tendermint/p2p"
 	"github.com/tendermint/tendermint/proxy"
-	"github.com/tendermint/tendermint/types"
 	"github.com/urfave/cli"
 )
 
@@ -52,7 +50,7 @@ func LoadTmConfig(ctx *cli.Context, home string) (*config.Config, error) {
 	return tmCfg, nil
 }
 
-func SetupNode(tmCfg *config.Config, privValidator types.PrivValidator, executor *node.Executor, logger tmlog.Logger) (*tmnode.Node, error) {
+func SetupNode(tmCfg *config.Config, tmSigner signer.Signer, executor *node.Executor, logger tmlog.Logger) (*tmnode.Node, error) {
 	if tmCfg.LogFormat == config.LogFormatJSON {
 		logger = tmlog.NewTMJSONLogger(tmlog.NewSyncWriter(os.Stdout))
 	}
@@ -67,20 +65,23 @@ func SetupNode(tmCfg *config.Config, privValidator types.PrivValidator, executor
 		return nil, err
 	}
 
-	if !tmos.FileExists(tmCfg.BLSKeyFile()) {
-		blssignatures.GenFileBLSKey().Save(tmCfg.BLSKeyFile())
-	}
-	blsPrivKey, err := blssignatures.PrivateKeyFromBytes(blssignatures.LoadBLSKey(tmCfg.BLSKeyFile()).PrivKey)
+	// TODO: the consensus of the Tendermint fork signs the batch hashes with the raw BLS key, outside the guard of
+	// tmSigner.SignBatch. Hand it tmSigner to sign them through once the fork takes a batch signer.
+	blsPrivKey, err := tmSigner.BLSPrivKey()
 	if err != nil {
-		return nil, fmt.Errorf("failed to load bls priv key")
+		return nil, err
 	}
 
+	// the signer connects to the remote signer already, the node must not listen for it again
+	nodeCfg := *tmCfg
+	nodeCfg.BaseConfig.PrivValidatorListenAddr = ""
+
 	//var app types.Application
 	n, err := tmnode.NewNode(
-		tmCfg,
+		&nodeCfg,
 		executor,
-		privValidator,
-		&blsPrivKey,
+		tmSigner,
+		blsPrivKey,
 		nodeKey,
 		proxy.NewLocalClientCreator(NewApplication(tmtypes.NewBaseApplication(), executor.L2Client())),
 		tmnode.DefaultGenesisDocProviderFunc(tmCfg),
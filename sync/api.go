@@ -0,0 +1,48 @@
+package sync
+
+import (
+	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum/rpc"
+)
+
+const (
+	// defaultQuarantineLimit is the number of quarantined events returned when no limit is given.
+	defaultQuarantineLimit = 100
+
+	// maxQuarantineLimit caps the number of quarantined events returned by one call.
+	maxQuarantineLimit = 1000
+)
+
+// API exposes the state of the syncer under the "sync" namespace.
+type API struct {
+	db Reader
+}
+
+func NewAPI(db Reader) *API {
+	return &API{db: db}
+}
+
+// APIs returns the RPC APIs of the syncer.
+func (s *Syncer) APIs() []rpc.API {
+	return []rpc.API{{
+		Namespace: "sync",
+		Service:   NewAPI(s.db),
+	}}
+}
+
+// QuarantinedEvents (sync_quarantinedEvents) lists the malformed L1 deposit events
+// emitted from the L1 block fromBlock on, at most limit of them.
+func (api *API) QuarantinedEvents(fromBlock uint64, limit *int) []types.QuarantinedL1Event {
+	n := defaultQuarantineLimit
+	if limit != nil && *limit > 0 {
+		n = *limit
+	}
+	if n > maxQuarantineLimit {
+		n = maxQuarantineLimit
+	}
+	events := api.db.ReadQuarantinedL1Events(fromBlock, n)
+	if events == nil {
+		events = []types.QuarantinedL1Event{}
+	}
+	return events
+}
@@ -2,28 +2,60 @@ package sync
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"time"
 
 	"github.com/morph-l2/bindings/bindings"
 	nodecommon "github.com/morph-l2/node/common"
+	"github.com/morph-l2/node/receipt"
 	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
 	"github.com/scroll-tech/go-ethereum/common"
 	eth "github.com/scroll-tech/go-ethereum/core/types"
-	"github.com/scroll-tech/go-ethereum/ethclient"
 	"github.com/scroll-tech/go-ethereum/rpc"
 	tmlog "github.com/tendermint/tendermint/libs/log"
 )
 
+// L1Client is the part of an L1 client the BridgeClient needs.
+type L1Client interface {
+	bind.ContractFilterer
+	nodecommon.L1HeightReader
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*eth.Receipt, error)
+}
+
+// Quarantine keeps the L1 events which could not be turned into L1 messages.
+type Quarantine interface {
+	WriteQuarantinedL1Event(event types.QuarantinedL1Event) bool
+}
+
+// QuarantinedEventError reports the first L1 block holding a quarantined deposit event.
+// The L1 messages returned with it are the ones emitted before that block: the event takes a queue index,
+// so the messages after it would leave a hole in the queue.
+type QuarantinedEventError struct {
+	BlockNumber uint64
+}
+
+func (e *QuarantinedEventError) Error() string {
+	return fmt.Sprintf("quarantined deposit event in L1 block %d", e.BlockNumber)
+}
+
 type BridgeClient struct {
-	l1Client           *ethclient.Client
+	l1Client           L1Client
+	receiptFetcher     *receipt.Fetcher
+	quarantine         Quarantine
+	metrics            *Metrics
 	filter             *bindings.MorphPortalFilterer
 	morphPortalAddress common.Address
 	confirmations      rpc.BlockNumber
 	logger             tmlog.Logger
 }
 
-func NewBridgeClient(l1Client *ethclient.Client, morphPortalAddress common.Address, confirmations rpc.BlockNumber, logger tmlog.Logger) (*BridgeClient, error) {
+// NewBridgeClient creates a BridgeClient. receiptFetcher is only needed to collect L1 messages from receipts, and may be nil.
+// Malformed events are written to quarantine.
+func NewBridgeClient(l1Client L1Client, receiptFetcher *receipt.Fetcher, quarantine Quarantine, morphPortalAddress common.Address, confirmations rpc.BlockNumber, metrics *Metrics, logger tmlog.Logger) (*BridgeClient, error) {
 	logger = logger.With("module", "bridge")
 	filter, err := bindings.NewMorphPortalFilterer(morphPortalAddress, l1Client)
 	if err != nil {
@@ -31,6 +63,9 @@ func NewBridgeClient(l1Client *ethclient.Client, morphPortalAddress common.Addre
 	}
 	return &BridgeClient{
 		l1Client:           l1Client,
+		receiptFetcher:     receiptFetcher,
+		quarantine:         quarantine,
+		metrics:            metrics,
 		filter:             filter,
 		morphPortalAddress: morphPortalAddress,
 		confirmations:      confirmations,
@@ -38,33 +73,88 @@ func NewBridgeClient(l1Client *ethclient.Client, morphPortalAddress common.Addre
 	}, nil
 }
 
+// L1Messages collects the L1 messages emitted in [from, to].
+// A malformed event is quarantined, and the messages emitted before its block are returned with a QuarantinedEventError.
 func (c *BridgeClient) L1Messages(ctx context.Context, from, to uint64) ([]types.L1Message, error) {
-	opts := bind.FilterOpts{
-		Start:   from,
-		End:     &to,
-		Context: ctx,
-	}
-	it, err := c.filter.FilterQueueTransaction(&opts, nil, nil)
+	logs, err := c.l1Client.FilterLogs(ctx, ethereum.FilterQuery{
+		FromBlock: new(big.Int).SetUint64(from),
+		ToBlock:   new(big.Int).SetUint64(to),
+		Addresses: []common.Address{c.morphPortalAddress},
+		Topics:    [][]common.Hash{{DepositEventABIHash}},
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	txs := make([]types.L1Message, 0)
-	for it.Next() {
-		event := it.Event
-		c.logger.Debug("Received new L1 QueueTransaction event", "event", event)
+	blockTimes := make(map[uint64]uint64)
+	blockStart := 0
+	for i, lg := range logs {
+		if i == 0 || lg.BlockNumber != logs[i-1].BlockNumber {
+			blockStart = len(txs)
+		}
+		msg, err := c.decodeL1Message(lg)
+		if err != nil {
+			c.quarantineLog(lg, err)
+			return txs[:blockStart], &QuarantinedEventError{BlockNumber: lg.BlockNumber}
+		}
+		c.logger.Debug("Received new L1 deposit event", "queueIndex", msg.QueueIndex, "txHash", lg.TxHash)
 
-		if !event.GasLimit.IsUint64() {
-			return nil, fmt.Errorf("invalid QueueTransaction event: QueueIndex = %v, GasLimit = %v", event.QueueIndex, event.GasLimit)
+		blockTime, ok := blockTimes[lg.BlockNumber]
+		if !ok {
+			header, err := c.l1Client.HeaderByNumber(ctx, new(big.Int).SetUint64(lg.BlockNumber))
+			if err != nil {
+				return nil, err
+			}
+			blockTime = header.Time
+			blockTimes[lg.BlockNumber] = blockTime
 		}
 		txs = append(txs, types.L1Message{
-			L1MessageTx: L1MessageTxFromEvent(event),
-			L1TxHash:    event.Raw.TxHash,
+			L1MessageTx: *msg,
+			L1TxHash:    lg.TxHash,
+			L1BlockTime: blockTime,
 		})
 	}
 	return txs, nil
 }
 
+// L1MessagesFromReceipts walks the L1 blocks in [from, to] and derives the L1 messages from their receipts.
+// Unlike L1Messages it does not rely on eth_getLogs, which some providers silently truncate:
+// receipts are validated against the receipt root of the block header, so events can not be dropped.
+// Blocks whose bloom filter can not contain a deposit event are skipped.
+func (c *BridgeClient) L1MessagesFromReceipts(ctx context.Context, from, to uint64) ([]types.L1Message, error) {
+	if c.receiptFetcher == nil {
+		return nil, errors.New("receipt fetcher is not configured")
+	}
+	txs := make([]types.L1Message, 0)
+	for number := from; number <= to; number++ {
+		header, err := c.l1Client.HeaderByNumber(ctx, new(big.Int).SetUint64(number))
+		if err != nil {
+			return nil, err
+		}
+		if !eth.BloomLookup(header.Bloom, c.morphPortalAddress) || !eth.BloomLookup(header.Bloom, DepositEventABIHash) {
+			continue
+		}
+		receipts, err := c.receiptFetcher.Fetch(ctx, header)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch receipts of block %d: %w", number, err)
+		}
+		msgs, err := c.deriveFromReceipt(receipts)
+		if err != nil {
+			var quarantined *QuarantinedEventError
+			if errors.As(err, &quarantined) {
+				quarantined.BlockNumber = number
+			}
+			return txs, err
+		}
+		for i := range msgs {
+			msgs[i].L1BlockTime = header.Time
+		}
+		txs = append(txs, msgs...)
+	}
+	return txs, nil
+}
+
 func (c *BridgeClient) L1MessagesFromTxHash(ctx context.Context, txHash common.Hash) ([]types.L1Message, error) {
 	receipt, err := c.l1Client.TransactionReceipt(ctx, txHash)
 	if err != nil {
@@ -82,6 +172,46 @@ func (c *BridgeClient) L1MessagesFromTxHash(ctx context.Context, txHash common.H
 	return c.deriveFromReceipt([]*eth.Receipt{receipt})
 }
 
+// decodeL1Message decodes a deposit log into an L1 message, rejecting events which can not form a valid one.
+// Versioned deposit events carry the version as their third indexed topic and are decoded by the
+// DepositDecoder registered for it, the others are plain QueueTransaction events.
+func (c *BridgeClient) decodeL1Message(lg eth.Log) (*eth.L1MessageTx, error) {
+	if len(lg.Topics) == 4 {
+		return UnmarshalDepositLogEvent(&lg)
+	}
+	event, err := c.filter.ParseQueueTransaction(lg)
+	if err != nil {
+		return nil, err
+	}
+	if !event.GasLimit.IsUint64() {
+		return nil, fmt.Errorf("invalid QueueTransaction event: QueueIndex = %v, GasLimit = %v", event.QueueIndex, event.GasLimit)
+	}
+	msg := L1MessageTxFromEvent(event)
+	return &msg, nil
+}
+
+// quarantineLog keeps a log which could not be decoded, so that the sync goes on and operators can look into it.
+func (c *BridgeClient) quarantineLog(lg eth.Log, decodeErr error) {
+	c.logger.Error("quarantining malformed L1 deposit event", "blockNumber", lg.BlockNumber, "txHash", lg.TxHash, "logIndex", lg.Index, "err", decodeErr)
+	if c.quarantine == nil {
+		return
+	}
+	isNew := c.quarantine.WriteQuarantinedL1Event(types.QuarantinedL1Event{
+		BlockNumber:   lg.BlockNumber,
+		BlockHash:     lg.BlockHash,
+		TxHash:        lg.TxHash,
+		LogIndex:      uint64(lg.Index),
+		Address:       lg.Address,
+		Topics:        lg.Topics,
+		Data:          lg.Data,
+		Error:         decodeErr.Error(),
+		QuarantinedAt: uint64(time.Now().Unix()),
+	})
+	if isNew && c.metrics != nil {
+		c.metrics.QuarantinedL1Events.Add(1)
+	}
+}
+
 func (c *BridgeClient) getLatestConfirmedBlockNumber(ctx context.Context) (uint64, error) {
 	return nodecommon.GetLatestConfirmedBlockNumber(ctx, c.l1Client, c.confirmations)
 }
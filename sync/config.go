@@ -2,6 +2,7 @@ package sync
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/morph-l2/node/flags"
@@ -20,6 +21,13 @@ const (
 
 	// DefaultLogProgressInterval is the frequency at which we log progress.
 	DefaultLogProgressInterval = time.Second * 10
+
+	// SyncModeLogs collects L1 messages with eth_getLogs queries.
+	SyncModeLogs = "logs"
+
+	// SyncModeReceipts collects L1 messages by walking L1 blocks and deriving them from receipts,
+	// which are validated against the receipt root of the block header.
+	SyncModeReceipts = "receipts"
 )
 
 type Config struct {
@@ -29,6 +37,9 @@ type Config struct {
 	PollInterval           time.Duration   `json:"poll_interval"`
 	LogProgressInterval    time.Duration   `json:"log_progress_interval"`
 	FetchBlockRange        uint64          `json:"fetch_block_range"`
+	Mode                   string          `json:"mode"`
+	// Subscribe triggers a sync on every new L1 head, it needs a websocket endpoint as first L1 address.
+	Subscribe bool `json:"subscribe"`
 }
 
 func DefaultConfig() *Config {
@@ -39,11 +50,15 @@ func DefaultConfig() *Config {
 		PollInterval:        DefaultPollInterval,
 		LogProgressInterval: DefaultLogProgressInterval,
 		FetchBlockRange:     DefaultFetchBlockRange,
+		Mode:                SyncModeLogs,
 	}
 }
 
 func (c *Config) SetCliContext(ctx *cli.Context) error {
-	c.L1.Addr = ctx.GlobalString(flags.L1NodeAddr.Name)
+	c.L1.Addrs = types.SplitAddrs(ctx.GlobalString(flags.L1NodeAddr.Name))
+	if ctx.GlobalIsSet(flags.L1Quorum.Name) {
+		c.L1.Quorum = ctx.GlobalInt(flags.L1Quorum.Name)
+	}
 	if ctx.GlobalIsSet(flags.L1Confirmations.Name) {
 		c.L1.Confirmations = rpc.BlockNumber(ctx.GlobalInt64(flags.L1Confirmations.Name))
 	}
@@ -81,6 +96,13 @@ func (c *Config) SetCliContext(ctx *cli.Context) error {
 			return errors.New("invalid fetchBlockRange")
 		}
 	}
+	if ctx.GlobalIsSet(flags.SyncMode.Name) {
+		c.Mode = ctx.GlobalString(flags.SyncMode.Name)
+		if c.Mode != SyncModeLogs && c.Mode != SyncModeReceipts {
+			return fmt.Errorf("invalid sync mode %q, expected %q or %q", c.Mode, SyncModeLogs, SyncModeReceipts)
+		}
+	}
+	c.Subscribe = ctx.GlobalBool(flags.SyncSubscribe.Name)
 
 	return nil
 }
@@ -11,9 +11,13 @@ type Reader interface {
 	ReadLatestSyncedL1Height() *uint64
 	ReadL1MessagesInRange(start, end uint64) []types.L1Message
 	ReadL1MessageByIndex(index uint64) *types.L1Message
+	ReadSyncFetchBlockRange() *uint64
+	ReadQuarantinedL1Events(fromBlock uint64, limit int) []types.QuarantinedL1Event
 }
 
 type Writer interface {
 	WriteLatestSyncedL1Height(latest uint64)
 	WriteSyncedL1Messages(messages []types.L1Message, latest uint64) error
+	WriteSyncFetchBlockRange(blockRange uint64)
+	WriteQuarantinedL1Event(event types.QuarantinedL1Event) bool
 }
@@ -1,11 +1,11 @@
 package sync
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
 
-	"github.com/hashicorp/go-multierror"
 	"github.com/holiman/uint256"
 	"github.com/morph-l2/bindings/bindings"
 	"github.com/morph-l2/node/types"
@@ -19,9 +19,26 @@ var (
 	DepositEventABI              = "QueueTransaction(address,address,uint256,uint64,uint256,bytes)"
 	DepositEventABIHash          = crypto.Keccak256Hash([]byte(DepositEventABI))
 	DepositEventVersion0         = common.Hash{}
+	DepositEventVersion1         = common.BigToHash(common.Big1)
 	L2CrossDomainMessengerABI, _ = bindings.L2CrossDomainMessengerMetaData.GetAbi()
 )
 
+// DepositDecoder decodes the opaqueData of a deposit event of one version into an L1 message.
+// to is the indexed recipient of the event, the sender is filled in by the caller.
+type DepositDecoder func(to common.Address, opaqueData []byte) (*eth.L1MessageTx, error)
+
+// depositDecoders maps a deposit event version to its decoder.
+var depositDecoders = map[common.Hash]DepositDecoder{
+	DepositEventVersion0: unmarshalDepositVersion0,
+	DepositEventVersion1: unmarshalDepositVersion1,
+}
+
+// RegisterDepositDecoder registers the decoder of a deposit event version, replacing the existing one if any.
+// It is meant to be called during initialization.
+func RegisterDepositDecoder(version common.Hash, decoder DepositDecoder) {
+	depositDecoders[version] = decoder
+}
+
 func L1MessageTxFromEvent(event *bindings.MorphPortalQueueTransaction) eth.L1MessageTx {
 	return eth.L1MessageTx{
 		QueueIndex: event.QueueIndex,
@@ -33,58 +50,29 @@ func L1MessageTxFromEvent(event *bindings.MorphPortalQueueTransaction) eth.L1Mes
 	}
 }
 
+// deriveFromReceipt collects the L1 messages emitted in receipts. A malformed event is quarantined and
+// a QuarantinedEventError returned instead, a deposit not sent by the L1CrossDomainMessenger as well rather than dropped.
 func (c *BridgeClient) deriveFromReceipt(receipts []*eth.Receipt) ([]types.L1Message, error) {
 	var out []types.L1Message
-	var result error
-	for i, rec := range receipts {
+	for _, rec := range receipts {
 		if rec.Status != eth.ReceiptStatusSuccessful {
 			continue
 		}
-		for j, lg := range rec.Logs {
+		for _, lg := range rec.Logs {
 			if lg.Address == c.morphPortalAddress && len(lg.Topics) > 0 && lg.Topics[0] == DepositEventABIHash {
-				event, err := c.filter.ParseQueueTransaction(*lg)
-				if err != nil {
-					result = multierror.Append(result, fmt.Errorf("malformatted L1 deposit log in receipt %d, log %d: %w", i, j, err))
-				} else {
-					if event == nil {
-						continue
-					}
-					out = append(out, types.L1Message{
-						L1MessageTx: L1MessageTxFromEvent(event),
-						L1TxHash:    lg.TxHash,
-					})
-				}
-			}
-		}
-	}
-	return out, result
-}
-
-func deriveFromReceipt(receipts []*eth.Receipt, depositContractAddr common.Address) ([]types.L1Message, error) {
-	var out []types.L1Message
-	var result error
-	for i, rec := range receipts {
-		if rec.Status != eth.ReceiptStatusSuccessful {
-			continue
-		}
-		for j, lg := range rec.Logs {
-			if lg.Address == depositContractAddr && len(lg.Topics) > 0 && lg.Topics[0] == DepositEventABIHash {
-				msg, err := UnmarshalDepositLogEvent(lg)
+				msg, err := c.decodeL1Message(*lg)
 				if err != nil {
-					result = multierror.Append(result, fmt.Errorf("malformatted L1 deposit log in receipt %d, log %d: %w", i, j, err))
-				} else {
-					if msg == nil {
-						continue
-					}
-					out = append(out, types.L1Message{
-						L1MessageTx: *msg,
-						L1TxHash:    lg.TxHash,
-					})
+					c.quarantineLog(*lg, err)
+					return nil, &QuarantinedEventError{BlockNumber: lg.BlockNumber}
 				}
+				out = append(out, types.L1Message{
+					L1MessageTx: *msg,
+					L1TxHash:    lg.TxHash,
+				})
 			}
 		}
 	}
-	return out, result
+	return out, nil
 }
 
 // UnmarshalDepositLogEvent decodes an EVM log entry emitted by the deposit contract into typed deposit data.
@@ -98,7 +86,10 @@ func deriveFromReceipt(receipts []*eth.Receipt, depositContractAddr common.Addre
 //	    bytes opaqueData
 //	);
 //
-// Additionally, the event log-index and
+// The opaqueData is decoded by the DepositDecoder registered for the version.
+// A version 0 deposit carries its queue index in the relayMessage calldata, so one not sent by the
+// L1CrossDomainMessenger can not be decoded: types.ErrNotFromCrossDomainMessenger is returned for it,
+// callers must not treat it as a deposit which does not exist.
 func UnmarshalDepositLogEvent(ev *eth.Log) (*eth.L1MessageTx, error) {
 	if len(ev.Topics) != 4 {
 		return nil, fmt.Errorf("expected 4 event topics (event identity, indexed from, indexed to, indexed version), got %d", len(ev.Topics))
@@ -141,26 +132,47 @@ func UnmarshalDepositLogEvent(ev *eth.Log) (*eth.L1MessageTx, error) {
 	// and then padded to 32 bytes by the EVM.
 	opaqueData := ev.Data[64 : 64+opaqueContentLength.Uint64()]
 
-	var tx *eth.L1MessageTx
-	var err error
-	switch version {
-	case DepositEventVersion0:
-		tx, err = unmarshalDepositVersion0(to, opaqueData)
-	default:
+	decode, ok := depositDecoders[version]
+	if !ok {
 		return nil, fmt.Errorf("invalid deposit version, got %s", version)
 	}
+	tx, err := decode(to, opaqueData)
 	if err != nil {
-		if err == types.ErrNotFromCrossDomainMessenger {
-			log.Warn("found the message not sent by L1CrossDomainMessenger, ignore it for now")
-			return nil, nil
-		}
 		return nil, fmt.Errorf("failed to decode deposit (version %s): %w", version, err)
 	}
 	tx.Sender = from
 	return tx, nil
 }
 
+// unmarshalDepositVersion0 decodes
+//
+//	abi.encodePacked(uint256 mint, uint256 value, uint64 gasLimit, uint8 isCreation, bytes data)
+//
+// The queue index is the nonce of the relayMessage call in data.
 func unmarshalDepositVersion0(to common.Address, opaqueData []byte) (*eth.L1MessageTx, error) {
+	message, err := unmarshalDepositFields(to, opaqueData)
+	if err != nil {
+		return nil, err
+	}
+
+	// NOTE: version 0 events do not expose the nonce, it is parsed from the relayMessage input,
+	// which means only the cross messages formed as relayMessage can be decoded.
+	relayMessage, err := unpackRelayMessage(message.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", types.ErrNotFromCrossDomainMessenger, err)
+	}
+	message.QueueIndex, err = types.DecodeNonce(relayMessage.nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// unmarshalDepositFields decodes the fields shared by all deposit versions:
+//
+//	abi.encodePacked(uint256 mint, uint256 value, uint64 gasLimit, uint8 isCreation, bytes data)
+func unmarshalDepositFields(to common.Address, opaqueData []byte) (*eth.L1MessageTx, error) {
 	var message eth.L1MessageTx
 	if len(opaqueData) < 32+32+8+1 {
 		return nil, fmt.Errorf("unexpected opaqueData length: %d", len(opaqueData))
@@ -200,19 +212,29 @@ func unmarshalDepositVersion0(to common.Address, opaqueData []byte) (*eth.L1Mess
 	// remaining bytes fill the data
 	message.Data = opaqueData[offset : offset+txDataLen]
 
-	// NOTE: currently we acquire the nonce from relayMessage input parsed from event data,
-	// which means we only allow the cross message data which are formed as relayMessage for now.
-	// in the future, the `nonce` is supposed to be exposed as one of the event fields
-	relayMessage, err := unpackRelayMessage(message.Data)
-	if err != nil {
-		return nil, types.ErrNotFromCrossDomainMessenger
+	return &message, nil
+}
+
+// unmarshalDepositVersion1 decodes
+//
+//	abi.encodePacked(uint64 nonce, uint256 mint, uint256 value, uint64 gasLimit, uint8 isCreation, bytes data)
+//
+// The queue index is the nonce field, the data does not have to be a relayMessage call.
+func unmarshalDepositVersion1(to common.Address, opaqueData []byte) (*eth.L1MessageTx, error) {
+	if len(opaqueData) < 8 {
+		return nil, fmt.Errorf("unexpected opaqueData length: %d", len(opaqueData))
 	}
-	message.QueueIndex, err = types.DecodeNonce(relayMessage.nonce)
+	// uint64 nonce
+	queueIndex := new(big.Int).SetBytes(opaqueData[:8]).Uint64()
+	log.Trace("Unmarshalling deposit log", "nonce", queueIndex)
+
+	// the remainder is laid out as in version 0
+	message, err := unmarshalDepositFields(to, opaqueData[8:])
 	if err != nil {
 		return nil, err
 	}
-
-	return &message, nil
+	message.QueueIndex = queueIndex
+	return message, nil
 }
 
 type relayMessageData struct {
@@ -230,6 +252,9 @@ func unpackRelayMessage(data []byte) (*relayMessageData, error) {
 	if !ok {
 		return nil, errors.New("can not find the method of relayMessage")
 	}
+	if len(data) < 4 || !bytes.Equal(data[:4], method.ID) {
+		return nil, errors.New("not a relayMessage call")
+	}
 	args := method.Inputs
 	unpacked, err := args.Unpack(data[4:])
 	if err != nil {
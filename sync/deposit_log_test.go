@@ -5,8 +5,12 @@ import (
 	"testing"
 
 	"github.com/morph-l2/bindings/bindings"
+	"github.com/morph-l2/node/db"
+	"github.com/morph-l2/node/types"
 	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
 )
 
 func TestParseRelayMessage(t *testing.T) {
@@ -32,3 +36,135 @@ func TestParseRelayMessage(t *testing.T) {
 	require.EqualValues(t, gasLimit.Int64(), unpacked.minGasLimit.Int64())
 	require.EqualValues(t, message, unpacked.message)
 }
+
+var (
+	testDepositFrom = common.HexToAddress("0x1111")
+	testDepositTo   = common.HexToAddress("0x2222")
+)
+
+// depositLogFixture builds a deposit event log of version carrying opaqueData.
+func depositLogFixture(version common.Hash, opaqueData []byte) *eth.Log {
+	data := make([]byte, 64, 64+len(opaqueData)+31)
+	data[31] = 32
+	new(big.Int).SetUint64(uint64(len(opaqueData))).FillBytes(data[32:64])
+	data = append(data, opaqueData...)
+	if rem := len(opaqueData) % 32; rem != 0 {
+		data = append(data, make([]byte, 32-rem)...)
+	}
+	return &eth.Log{
+		Topics: []common.Hash{
+			DepositEventABIHash,
+			common.BytesToHash(testDepositFrom.Bytes()),
+			common.BytesToHash(testDepositTo.Bytes()),
+			version,
+		},
+		Data: data,
+	}
+}
+
+// depositFields packs abi.encodePacked(uint256 mint, uint256 value, uint64 gasLimit, uint8 isCreation, bytes data).
+func depositFields(value, gasLimit uint64, isCreation bool, txData []byte) []byte {
+	out := make([]byte, 32+32+8+1)
+	new(big.Int).SetUint64(value).FillBytes(out[32:64])
+	new(big.Int).SetUint64(gasLimit).FillBytes(out[64:72])
+	if isCreation {
+		out[72] = 1
+	}
+	return append(out, txData...)
+}
+
+func TestUnmarshalDepositLogEvent(t *testing.T) {
+	relayMessage, err := L2CrossDomainMessengerABI.Pack("relayMessage", types.EncodeNonce(7), testDepositFrom, testDepositTo, big.NewInt(1), big.NewInt(100000), []byte("abcd"))
+	require.NoError(t, err)
+	nonce := make([]byte, 8)
+	nonce[7] = 9
+
+	for _, tc := range []struct {
+		name       string
+		log        *eth.Log
+		queueIndex uint64
+		data       []byte
+		to         *common.Address
+		err        error
+	}{
+		{
+			name:       "version 0",
+			log:        depositLogFixture(DepositEventVersion0, depositFields(1, 21000, false, relayMessage)),
+			queueIndex: 7,
+			data:       relayMessage,
+			to:         &testDepositTo,
+		},
+		{
+			name: "version 0 not sent by L1CrossDomainMessenger",
+			log:  depositLogFixture(DepositEventVersion0, depositFields(1, 21000, false, []byte("abcd"))),
+			err:  types.ErrNotFromCrossDomainMessenger,
+		},
+		{
+			name:       "version 1",
+			log:        depositLogFixture(DepositEventVersion1, append(nonce, depositFields(1, 21000, false, relayMessage)...)),
+			queueIndex: 9,
+			data:       relayMessage,
+			to:         &testDepositTo,
+		},
+		{
+			name:       "version 1 not sent by L1CrossDomainMessenger",
+			log:        depositLogFixture(DepositEventVersion1, append(nonce, depositFields(1, 21000, true, []byte("abcd"))...)),
+			queueIndex: 9,
+			data:       []byte("abcd"),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			msg, err := UnmarshalDepositLogEvent(tc.log)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.queueIndex, msg.QueueIndex)
+			require.Equal(t, tc.data, msg.Data)
+			require.Equal(t, tc.to, msg.To)
+			require.Equal(t, testDepositFrom, msg.Sender)
+			require.EqualValues(t, 1, msg.Value.Uint64())
+			require.EqualValues(t, 21000, msg.Gas)
+		})
+	}
+
+	_, err = UnmarshalDepositLogEvent(depositLogFixture(common.BigToHash(big.NewInt(2)), depositFields(1, 21000, false, nil)))
+	require.ErrorContains(t, err, "invalid deposit version")
+}
+
+func TestDeriveFromReceiptQuarantinesNonMessengerDeposits(t *testing.T) {
+	relayMessage, err := L2CrossDomainMessengerABI.Pack("relayMessage", types.EncodeNonce(3), testDepositFrom, testDepositTo, big.NewInt(0), big.NewInt(100000), []byte{})
+	require.NoError(t, err)
+	portal := common.HexToAddress("0x3333")
+	nonce := make([]byte, 8)
+	nonce[7] = 4
+	valid := depositLogFixture(DepositEventVersion0, depositFields(0, 21000, false, relayMessage))
+	versioned := depositLogFixture(DepositEventVersion1, append(nonce, depositFields(0, 21000, false, []byte("abcd"))...))
+	foreign := depositLogFixture(DepositEventVersion0, depositFields(0, 21000, false, []byte("abcd")))
+	valid.Address, versioned.Address, foreign.Address = portal, portal, portal
+	foreign.BlockNumber = 5
+
+	filter, err := bindings.NewMorphPortalFilterer(portal, nil)
+	require.NoError(t, err)
+	store := db.NewMemoryStore()
+	client := &BridgeClient{quarantine: store, filter: filter, morphPortalAddress: portal, logger: tmlog.NewNopLogger()}
+
+	msgs, err := client.deriveFromReceipt([]*eth.Receipt{{Status: eth.ReceiptStatusSuccessful, Logs: []*eth.Log{valid, versioned}}})
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.EqualValues(t, 3, msgs[0].QueueIndex)
+	// the versioned event is decoded by the registered decoder
+	require.EqualValues(t, 4, msgs[1].QueueIndex)
+	require.Equal(t, testDepositFrom, msgs[1].Sender)
+
+	// the deposit not sent by the L1CrossDomainMessenger is kept for operators rather than dropped
+	_, err = client.deriveFromReceipt([]*eth.Receipt{{Status: eth.ReceiptStatusSuccessful, Logs: []*eth.Log{valid, foreign}}})
+	var quarantined *QuarantinedEventError
+	require.ErrorAs(t, err, &quarantined)
+	require.EqualValues(t, 5, quarantined.BlockNumber)
+	events := store.ReadQuarantinedL1Events(0, 10)
+	require.Len(t, events, 1)
+	require.EqualValues(t, 5, events[0].BlockNumber)
+	require.Contains(t, events[0].Error, types.ErrNotFromCrossDomainMessenger.Error())
+}
@@ -32,6 +32,18 @@ func PrometheusMetrics(namespace string, labelsAndValues ...string) *Metrics {
 			Name:      "message_count",
 			Help:      "",
 		}, labels).With(labelsAndValues...),
+		HeadSubscribed: prometheus.NewGaugeFrom(stdprometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "head_subscribed",
+			Help:      "Whether the syncer is subscribed to new L1 heads, 0 means it is polling.",
+		}, labels).With(labelsAndValues...),
+		QuarantinedL1Events: prometheus.NewCounterFrom(stdprometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: MetricsSubsystem,
+			Name:      "quarantined_events",
+			Help:      "Number of malformed L1 deposit events written to the quarantine.",
+		}, labels).With(labelsAndValues...),
 	}
 }
 
@@ -40,5 +52,7 @@ func NopMetrics() *Metrics {
 		SyncedL1Height:       discard.NewGauge(),
 		SyncedL1MessageNonce: discard.NewGauge(),
 		SyncedL1MessageCount: discard.NewCounter(),
+		HeadSubscribed:       discard.NewGauge(),
+		QuarantinedL1Events:  discard.NewCounter(),
 	}
 }
@@ -14,4 +14,8 @@ type Metrics struct {
 	SyncedL1Height       metrics.Gauge   `metrics_name:"l1height"`
 	SyncedL1MessageNonce metrics.Gauge   `metrics_name:"message_nonce"`
 	SyncedL1MessageCount metrics.Counter `metrics_name:"message_count"`
+	// Whether the syncer is subscribed to new L1 heads, 0 means it is polling.
+	HeadSubscribed metrics.Gauge `metrics_name:"head_subscribed"`
+	// Number of malformed L1 deposit events written to the quarantine.
+	QuarantinedL1Events metrics.Counter `metrics_name:"quarantined_events"`
 }
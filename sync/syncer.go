@@ -3,41 +3,71 @@ package sync
 import (
 	"context"
 	"errors"
+	"sync"
 	"time"
 
+	nodecommon "github.com/morph-l2/node/common"
+	"github.com/morph-l2/node/receipt"
 	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/common"
-	"github.com/scroll-tech/go-ethereum/ethclient"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
 	tmlog "github.com/tendermint/tendermint/libs/log"
 )
 
+// maxTrackedL1MessageTimes bounds the L1 block times kept for the inclusion latency metric.
+const maxTrackedL1MessageTimes = 10000
+
 type Syncer struct {
 	ctx          context.Context
 	cancel       context.CancelFunc
+	l1Client     *nodecommon.QuorumClient
 	bridgeClient *BridgeClient
 	latestSynced uint64
 	db           Database
 	logger       tmlog.Logger
 	metrics      *Metrics
 
-	fetchBlockRange     uint64
+	fetchRange          *nodecommon.AdaptiveRange
+	mode                string
+	subscribe           bool
 	pollInterval        time.Duration
 	logProgressInterval time.Duration
 	stop                chan struct{}
 	isFake              bool
+
+	// queue index -> timestamp of the L1 block which emitted the message
+	l1MessageTimesMu sync.Mutex
+	l1MessageTimes   map[uint64]uint64
 }
 
 func NewSyncer(ctx context.Context, db Database, config *Config, logger tmlog.Logger) (*Syncer, error) {
-	l1Client, err := ethclient.Dial(config.L1.Addr)
+	l1Client, err := nodecommon.DialQuorumClient(config.L1.Addrs, config.L1.Quorum, logger)
 	if err != nil {
 		return nil, err
 	}
+	return NewSyncerWithL1Client(ctx, db, config, l1Client, logger)
+}
 
+// NewSyncerWithL1Client creates a syncer reading the L1 heads and logs through l1Client, which may be shared
+// with other L1 readers of the node.
+func NewSyncerWithL1Client(ctx context.Context, db Database, config *Config, l1Client *nodecommon.QuorumClient, logger tmlog.Logger) (*Syncer, error) {
+	var err error
 	if config.DepositContractAddress == nil {
 		return nil, errors.New("deposit contract address cannot be nil")
 	}
 
-	bridgeClient, err := NewBridgeClient(l1Client, *config.DepositContractAddress, config.L1.Confirmations, logger)
+	var receiptFetcher *receipt.Fetcher
+	if config.Mode == SyncModeReceipts {
+		// headers come from the quorum client, the fetcher only has to serve bodies and receipts matching them,
+		// from any of the providers
+		if receiptFetcher, err = receipt.NewFetcher(config.L1.Addrs, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	metrics := PrometheusMetrics("morphnode")
+	bridgeClient, err := NewBridgeClient(l1Client, receiptFetcher, db, *config.DepositContractAddress, config.L1.Confirmations, metrics, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -54,13 +84,13 @@ func NewSyncer(ctx context.Context, db Database, config *Config, logger tmlog.Lo
 		h := config.StartHeight - 1
 		latestSynced = &h
 	}
-	metrics := PrometheusMetrics("morphnode")
 	metrics.SyncedL1Height.Set(float64(*latestSynced))
 
 	ctx, cancel := context.WithCancel(ctx)
 	return &Syncer{
 		ctx:          ctx,
 		cancel:       cancel,
+		l1Client:     l1Client,
 		bridgeClient: bridgeClient,
 		latestSynced: *latestSynced,
 		db:           db,
@@ -68,7 +98,9 @@ func NewSyncer(ctx context.Context, db Database, config *Config, logger tmlog.Lo
 		logger:       logger,
 		metrics:      metrics,
 
-		fetchBlockRange:     config.FetchBlockRange,
+		fetchRange:          nodecommon.NewAdaptiveRange(config.FetchBlockRange, db.ReadSyncFetchBlockRange(), db.WriteSyncFetchBlockRange, logger),
+		mode:                config.Mode,
+		subscribe:           config.Subscribe,
 		pollInterval:        config.PollInterval,
 		logProgressInterval: config.LogProgressInterval,
 	}, nil
@@ -87,21 +119,80 @@ func (s *Syncer) Start() {
 		t := time.NewTicker(s.pollInterval)
 		defer t.Stop()
 
+		// in subscription mode new heads trigger the sync, the ticker keeps polling in case the subscription drops,
+		// and paces the resubscriptions
+		var (
+			sub   ethereum.Subscription
+			heads chan *eth.Header
+		)
+		defer func() {
+			if sub != nil {
+				sub.Unsubscribe()
+			}
+		}()
+
 		for {
 			// don't wait for ticker during startup
 			s.fetchL1Messages()
 
+			if s.subscribe && sub == nil {
+				sub, heads = s.subscribeNewHeads()
+			}
+			var subErr <-chan error
+			if sub != nil {
+				subErr = sub.Err()
+			}
+
 			select {
 			case <-s.ctx.Done():
 				close(s.stop)
 				return
 			case <-t.C:
 				continue
+			case <-heads:
+				// heads which arrived while syncing are covered by the next fetch as well
+				drainHeads(heads)
+				continue
+			case err := <-subErr:
+				s.logger.Error("L1 new heads subscription dropped, falling back to polling", "err", err)
+				sub.Unsubscribe()
+				sub, heads = nil, nil
+				s.metrics.HeadSubscribed.Set(0)
+				// a provider which drops subscriptions right away would be resubscribed in a busy loop otherwise
+				select {
+				case <-s.ctx.Done():
+					close(s.stop)
+					return
+				case <-t.C:
+				}
 			}
 		}
 	}()
 }
 
+// subscribeNewHeads subscribes to new heads of the first L1 provider, it returns a nil subscription on failure.
+func (s *Syncer) subscribeNewHeads() (ethereum.Subscription, chan *eth.Header) {
+	heads := make(chan *eth.Header, 16)
+	sub, err := s.l1Client.SubscribeNewHead(s.ctx, heads)
+	if err != nil {
+		s.logger.Error("failed to subscribe to L1 new heads, polling instead", "err", err)
+		return nil, nil
+	}
+	s.logger.Info("subscribed to L1 new heads")
+	s.metrics.HeadSubscribed.Set(1)
+	return sub, heads
+}
+
+func drainHeads(heads chan *eth.Header) {
+	for {
+		select {
+		case <-heads:
+		default:
+			return
+		}
+	}
+}
+
 func (s *Syncer) Stop() {
 	if s == nil {
 		return
@@ -122,12 +213,16 @@ func (s *Syncer) fetchL1Messages() {
 		s.logger.Error("failed to get latest confirmed block number", "err", err)
 		return
 	}
+	s.fetchL1MessagesUpTo(latestConfirmed)
+}
 
+func (s *Syncer) fetchL1MessagesUpTo(latestConfirmed uint64) {
 	// ticker for logging progress
 	t := time.NewTicker(s.logProgressInterval)
+	defer t.Stop()
 	numMessagesCollected := 0
 	// query in batches
-	for from := s.latestSynced + 1; from <= latestConfirmed; from += s.fetchBlockRange {
+	for from := s.latestSynced + 1; from <= latestConfirmed; from = s.latestSynced + 1 {
 		select {
 		case <-s.ctx.Done():
 			return
@@ -137,17 +232,30 @@ func (s *Syncer) fetchL1Messages() {
 		default:
 		}
 
-		to := from + s.fetchBlockRange - 1
-
-		if to > latestConfirmed {
-			to = latestConfirmed
-		}
-
-		l1Messages, err := s.bridgeClient.L1Messages(s.ctx, from, to)
+		var l1Messages []types.L1Message
+		var quarantined *QuarantinedEventError
+		to, err := s.fetchRange.Query(from, latestConfirmed, func(from, to uint64) (err error) {
+			quarantined = nil
+			if s.mode == SyncModeReceipts {
+				l1Messages, err = s.bridgeClient.L1MessagesFromReceipts(s.ctx, from, to)
+			} else {
+				l1Messages, err = s.bridgeClient.L1Messages(s.ctx, from, to)
+			}
+			if errors.As(err, &quarantined) {
+				return nil
+			}
+			return err
+		})
 		if err != nil {
 			s.logger.Error("failed to fetch L1 messages", "fromBlock", from, "toBlock", to, "err", err)
 			return
 		}
+		if quarantined != nil {
+			// going past the block would leave a hole in the queue, which stops the inclusion of L1 messages
+			// for good. The sync is held before it instead, and the block is fetched again on the next round.
+			s.logger.Error("L1 message sync is held at a quarantined deposit event", "blockNumber", quarantined.BlockNumber)
+			to = quarantined.BlockNumber - 1
+		}
 
 		if len(l1Messages) > 0 {
 			s.logger.Debug("Received new L1 events", "fromBlock", from, "toBlock", to, "count", len(l1Messages))
@@ -157,6 +265,7 @@ func (s *Syncer) fetchL1Messages() {
 				return
 			}
 			numMessagesCollected += len(l1Messages)
+			s.trackL1MessageTimes(l1Messages)
 
 			s.metrics.SyncedL1MessageCount.Add(float64(len(l1Messages)))
 			s.metrics.SyncedL1MessageNonce.Set(float64(l1Messages[len(l1Messages)-1].QueueIndex))
@@ -166,6 +275,9 @@ func (s *Syncer) fetchL1Messages() {
 		s.latestSynced = to
 
 		s.metrics.SyncedL1Height.Set(float64(to))
+		if quarantined != nil {
+			return
+		}
 	}
 }
 
@@ -195,3 +307,40 @@ func (s *Syncer) ReadL1MessagesInRange(start, end uint64) []types.L1Message {
 func (s *Syncer) LatestSynced() uint64 {
 	return s.latestSynced
 }
+
+func (s *Syncer) trackL1MessageTimes(l1Messages []types.L1Message) {
+	s.l1MessageTimesMu.Lock()
+	defer s.l1MessageTimesMu.Unlock()
+	if s.l1MessageTimes == nil {
+		s.l1MessageTimes = make(map[uint64]uint64)
+	}
+	for _, msg := range l1Messages {
+		if len(s.l1MessageTimes) >= maxTrackedL1MessageTimes && msg.QueueIndex > maxTrackedL1MessageTimes/2 {
+			// messages this node does not include are never taken, forget about the oldest ones
+			s.pruneL1MessageTimes(msg.QueueIndex - maxTrackedL1MessageTimes/2)
+		}
+		if msg.L1BlockTime > 0 {
+			s.l1MessageTimes[msg.QueueIndex] = msg.L1BlockTime
+		}
+	}
+}
+
+func (s *Syncer) pruneL1MessageTimes(below uint64) {
+	for index := range s.l1MessageTimes {
+		if index < below {
+			delete(s.l1MessageTimes, index)
+		}
+	}
+}
+
+// TakeL1MessageTime returns the timestamp of the L1 block which emitted the message at index and forgets it.
+// It returns false if the message was not synced by this syncer since it started.
+func (s *Syncer) TakeL1MessageTime(index uint64) (uint64, bool) {
+	s.l1MessageTimesMu.Lock()
+	defer s.l1MessageTimesMu.Unlock()
+	t, ok := s.l1MessageTimes[index]
+	if ok {
+		delete(s.l1MessageTimes, index)
+	}
+	return t, ok
+}
@@ -3,15 +3,20 @@ package sync
 import (
 	"context"
 	"flag"
+	"fmt"
 	tmlog "github.com/tendermint/tendermint/libs/log"
 	"math/big"
 	"os"
 	"testing"
 
+	"github.com/morph-l2/bindings/bindings"
+	nodecommon "github.com/morph-l2/node/common"
 	"github.com/morph-l2/node/db"
 	"github.com/morph-l2/node/types"
+	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/common"
 	gethTypes "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/event"
 	"github.com/stretchr/testify/require"
 	"github.com/urfave/cli"
 )
@@ -80,3 +85,156 @@ func PrepareContext() *cli.Context {
 	ctx := cli.NewContext(nil, flagSet, nil)
 	return ctx
 }
+
+// cappedFilterer serves QueueTransaction logs, one per block, and refuses queries matching more than limit logs.
+type cappedFilterer struct {
+	logs    []gethTypes.Log
+	limit   int
+	queries int
+}
+
+func newCappedFilterer(t *testing.T, portal common.Address, blocks uint64, limit int) *cappedFilterer {
+	abi, err := bindings.MorphPortalMetaData.GetAbi()
+	require.NoError(t, err)
+	ev := abi.Events["QueueTransaction"]
+	f := &cappedFilterer{limit: limit}
+	for i := uint64(1); i <= blocks; i++ {
+		data, err := ev.Inputs.NonIndexed().Pack(big.NewInt(0), i-1, big.NewInt(21000), []byte{})
+		require.NoError(t, err)
+		f.logs = append(f.logs, gethTypes.Log{
+			Address:     portal,
+			Topics:      []common.Hash{ev.ID, {}, {}},
+			Data:        data,
+			BlockNumber: i,
+			TxHash:      common.BigToHash(new(big.Int).SetUint64(i)),
+		})
+	}
+	return f
+}
+
+func (f *cappedFilterer) FilterLogs(_ context.Context, q ethereum.FilterQuery) ([]gethTypes.Log, error) {
+	f.queries++
+	var ret []gethTypes.Log
+	for _, lg := range f.logs {
+		if lg.BlockNumber >= q.FromBlock.Uint64() && lg.BlockNumber <= q.ToBlock.Uint64() {
+			ret = append(ret, lg)
+		}
+	}
+	if len(ret) > f.limit {
+		return nil, fmt.Errorf("query returned more than %d results", f.limit)
+	}
+	return ret, nil
+}
+
+func (f *cappedFilterer) BlockNumber(context.Context) (uint64, error) {
+	return uint64(len(f.logs)), nil
+}
+
+// HeaderByNumber serves headers 12 seconds apart.
+func (f *cappedFilterer) HeaderByNumber(_ context.Context, number *big.Int) (*gethTypes.Header, error) {
+	return &gethTypes.Header{Number: number, Time: 12 * number.Uint64()}, nil
+}
+
+func (f *cappedFilterer) TransactionReceipt(context.Context, common.Hash) (*gethTypes.Receipt, error) {
+	return nil, ethereum.NotFound
+}
+
+func (f *cappedFilterer) SubscribeFilterLogs(context.Context, ethereum.FilterQuery, chan<- gethTypes.Log) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(<-chan struct{}) error { return nil }), nil
+}
+
+func TestSyncer_AdaptiveFetchRange(t *testing.T) {
+	portal := common.BigToAddress(big.NewInt(1))
+	provider := newCappedFilterer(t, portal, 100, 10)
+	filter, err := bindings.NewMorphPortalFilterer(portal, provider)
+	require.NoError(t, err)
+
+	store := db.NewMemoryStore()
+	syncer := NewFakeSyncer(store)
+	syncer.ctx = context.Background()
+	syncer.metrics = NopMetrics()
+	syncer.logProgressInterval = DefaultLogProgressInterval
+	syncer.fetchRange = nodecommon.NewAdaptiveRange(64, store.ReadSyncFetchBlockRange(), store.WriteSyncFetchBlockRange, nil)
+	syncer.bridgeClient = &BridgeClient{l1Client: provider, filter: filter, morphPortalAddress: portal, logger: tmlog.NewNopLogger()}
+
+	syncer.fetchL1MessagesUpTo(100)
+	require.EqualValues(t, 100, syncer.LatestSynced())
+	require.Len(t, store.ReadL1MessagesInRange(0, 99), 100)
+	// the L1 block time is tracked for the inclusion latency
+	require.EqualValues(t, 12*42, store.ReadL1MessageByIndex(41).L1BlockTime)
+	l1Time, ok := syncer.TakeL1MessageTime(41)
+	require.True(t, ok)
+	require.EqualValues(t, 12*42, l1Time)
+	_, ok = syncer.TakeL1MessageTime(41)
+	require.False(t, ok)
+	// 64 -> 32 -> 16 -> 8 fit under the cap
+	require.EqualValues(t, 8, syncer.fetchRange.Size())
+	require.EqualValues(t, 8, *store.ReadSyncFetchBlockRange())
+
+	// a restarted syncer starts from the tuned range
+	require.EqualValues(t, 8, nodecommon.NewAdaptiveRange(64, store.ReadSyncFetchBlockRange(), nil, nil).Size())
+
+	// after enough successful queries the range grows again, as long as the provider accepts it
+	provider.limit = 1000
+	provider.logs = append(provider.logs, newCappedFilterer(t, portal, 200, 0).logs[100:]...)
+	syncer.fetchL1MessagesUpTo(200)
+	require.EqualValues(t, 200, syncer.LatestSynced())
+	require.Greater(t, syncer.fetchRange.Size(), uint64(8))
+}
+
+func TestSyncer_QuarantineMalformedEvents(t *testing.T) {
+	portal := common.BigToAddress(big.NewInt(1))
+	provider := newCappedFilterer(t, portal, 10, 100)
+	// a truncated event in block 5 and one whose gas limit does not fit in uint64 in block 7
+	provider.logs[4].Data = provider.logs[4].Data[:40]
+	abi, err := bindings.MorphPortalMetaData.GetAbi()
+	require.NoError(t, err)
+	// the event L1Messages filters on
+	require.Equal(t, abi.Events["QueueTransaction"].ID, DepositEventABIHash)
+	provider.logs[6].Data, err = abi.Events["QueueTransaction"].Inputs.NonIndexed().Pack(big.NewInt(0), uint64(6), new(big.Int).Lsh(common.Big1, 64), []byte{})
+	require.NoError(t, err)
+	filter, err := bindings.NewMorphPortalFilterer(portal, provider)
+	require.NoError(t, err)
+
+	store := db.NewMemoryStore()
+	syncer := NewFakeSyncer(store)
+	syncer.ctx = context.Background()
+	syncer.metrics = NopMetrics()
+	syncer.logProgressInterval = DefaultLogProgressInterval
+	syncer.fetchRange = nodecommon.NewAdaptiveRange(100, nil, nil, nil)
+	syncer.bridgeClient = &BridgeClient{l1Client: provider, quarantine: store, metrics: syncer.metrics, filter: filter, morphPortalAddress: portal, logger: tmlog.NewNopLogger()}
+
+	// the sync is held before the first malformed event: the messages after it would leave a hole in the queue
+	syncer.fetchL1MessagesUpTo(10)
+	require.EqualValues(t, 4, syncer.LatestSynced())
+	require.EqualValues(t, 4, *store.ReadLatestSyncedL1Height())
+	require.Len(t, store.ReadL1MessagesInRange(0, 9), 4)
+	// the block is fetched again on the next round, the event is quarantined once
+	syncer.fetchL1MessagesUpTo(10)
+	require.EqualValues(t, 4, syncer.LatestSynced())
+
+	limit := 10
+	events := NewAPI(store).QuarantinedEvents(0, &limit)
+	require.Len(t, events, 1)
+	require.EqualValues(t, 5, events[0].BlockNumber)
+	require.Equal(t, provider.logs[4].Data, events[0].Data)
+	require.NotEmpty(t, events[0].Error)
+
+	// once the provider serves the event correctly the sync resumes up to the next malformed one
+	provider.logs[4] = newCappedFilterer(t, portal, 10, 100).logs[4]
+	syncer.fetchL1MessagesUpTo(10)
+	require.EqualValues(t, 6, syncer.LatestSynced())
+	events = NewAPI(store).QuarantinedEvents(0, &limit)
+	require.Len(t, events, 2)
+	require.EqualValues(t, 7, events[1].BlockNumber)
+	require.Contains(t, events[1].Error, "GasLimit")
+
+	// the queue has no hole: the messages read for the next L2 block are contiguous
+	msgs := syncer.ReadL1MessagesInRange(0, 9)
+	require.Len(t, msgs, 6)
+	for i, msg := range msgs {
+		require.EqualValues(t, i, msg.QueueIndex)
+	}
+
+	require.Empty(t, NewAPI(store).QuarantinedEvents(8, nil))
+}
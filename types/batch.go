@@ -18,24 +18,38 @@ type BatchHeader struct {
 	DataHash               common.Hash
 	ParentBatchHash        common.Hash
 	SkippedL1MessageBitmap hexutil.Bytes
+	// Encoded in blob batches only, from BatchVersionV2 on
+	BlobVersionedHashes []common.Hash
 
 	//cache
 	Bytes hexutil.Bytes
 }
 
 // Encode encodes the BatchHeader into RollupV2 BatchHeaderV0Codec Encoding.
+// From BatchVersionV2 on, the number of blobs (1 byte) and their versioned hashes are encoded
+// between ParentBatchHash and SkippedL1MessageBitmap.
 func (b *BatchHeader) Encode() []byte {
 	if len(b.Bytes) > 0 {
 		return b.Bytes
 	}
-	batchBytes := make([]byte, 89+len(b.SkippedL1MessageBitmap))
+	bitmapOffset := 89
+	if b.Version >= BatchVersionV2 {
+		bitmapOffset += 1 + 32*len(b.BlobVersionedHashes)
+	}
+	batchBytes := make([]byte, bitmapOffset+len(b.SkippedL1MessageBitmap))
 	batchBytes[0] = b.Version
 	binary.BigEndian.PutUint64(batchBytes[1:], b.BatchIndex)
 	binary.BigEndian.PutUint64(batchBytes[9:], b.L1MessagePopped)
 	binary.BigEndian.PutUint64(batchBytes[17:], b.TotalL1MessagePopped)
 	copy(batchBytes[25:], b.DataHash[:])
 	copy(batchBytes[57:], b.ParentBatchHash[:])
-	copy(batchBytes[89:], b.SkippedL1MessageBitmap[:])
+	if b.Version >= BatchVersionV2 {
+		batchBytes[89] = byte(len(b.BlobVersionedHashes))
+		for i, hash := range b.BlobVersionedHashes {
+			copy(batchBytes[90+32*i:], hash[:])
+		}
+	}
+	copy(batchBytes[bitmapOffset:], b.SkippedL1MessageBitmap[:])
 	b.Bytes = batchBytes
 	return batchBytes
 }
@@ -56,14 +70,25 @@ func DecodeBatchHeader(data []byte) (BatchHeader, error) {
 	b := BatchHeader{
 		Version: data[0],
 
-		BatchIndex:             binary.BigEndian.Uint64(data[1:9]),
-		L1MessagePopped:        binary.BigEndian.Uint64(data[9:17]),
-		TotalL1MessagePopped:   binary.BigEndian.Uint64(data[17:25]),
-		DataHash:               common.BytesToHash(data[25:57]),
-		ParentBatchHash:        common.BytesToHash(data[57:89]),
-		SkippedL1MessageBitmap: data[89:],
+		BatchIndex:           binary.BigEndian.Uint64(data[1:9]),
+		L1MessagePopped:      binary.BigEndian.Uint64(data[9:17]),
+		TotalL1MessagePopped: binary.BigEndian.Uint64(data[17:25]),
+		DataHash:             common.BytesToHash(data[25:57]),
+		ParentBatchHash:      common.BytesToHash(data[57:89]),
 
 		Bytes: data,
 	}
+	bitmapOffset := 89
+	if b.Version >= BatchVersionV2 {
+		if len(data) < 90 || len(data) < 90+32*int(data[89]) {
+			return BatchHeader{}, fmt.Errorf("insufficient data for blob versioned hashes")
+		}
+		b.BlobVersionedHashes = make([]common.Hash, data[89])
+		for i := range b.BlobVersionedHashes {
+			b.BlobVersionedHashes[i] = common.BytesToHash(data[90+32*i : 122+32*i])
+		}
+		bitmapOffset = 90 + 32*len(b.BlobVersionedHashes)
+	}
+	b.SkippedL1MessageBitmap = data[bitmapOffset:]
 	return b, nil
 }
@@ -0,0 +1,110 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// BatchVersionV0 carries the L2 transactions of a chunk as raw length-prefixed RLP.
+	BatchVersionV0 uint8 = 0
+	// BatchVersionV1 carries the L2 transactions of a chunk as a zstd frame of the V0 payload.
+	BatchVersionV1 uint8 = 1
+	// BatchVersionV2 carries the chunks in blobs, see Chunks.EncodeBlobs. The calldata of the batch lists
+	// the versioned hashes of the blobs in place of the chunks.
+	// Derivation reads V2 batches, but the sequencer does not seal them: it has no blob commit path yet.
+	BatchVersionV2 uint8 = 2
+
+	blockContextLength = 60
+	// maxTxsPayloadSize bounds the memory used to decompress the transactions of one chunk.
+	maxTxsPayloadSize = 64 << 20
+)
+
+var ErrUnsupportedBatchVersion = errors.New("unsupported batch version")
+
+var (
+	// the encoder and decoder are safe for concurrent use with EncodeAll and DecodeAll
+	txsPayloadEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression), zstd.WithEncoderConcurrency(1))
+	txsPayloadDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxTxsPayloadSize), zstd.WithDecoderConcurrency(0))
+)
+
+// IsSupportedBatchVersion reports whether the chunks of the batch version are committed in calldata,
+// and can be encoded and decoded by Chunk.EncodeWithVersion and DecodeChunk.
+// These are the versions the sequencer can seal batches with, BatchVersionV2 is not one of them.
+func IsSupportedBatchVersion(version uint8) bool {
+	return version == BatchVersionV0 || version == BatchVersionV1
+}
+
+// EncodeWithVersion encodes the chunk in the format of the given batch version.
+// V1 shares the layout of V0 except that l2Transactions is zstd compressed, an empty payload is kept empty.
+func (ck *Chunk) EncodeWithVersion(version uint8) ([]byte, error) {
+	switch version {
+	case BatchVersionV0:
+		return ck.Encode()
+	case BatchVersionV1:
+		if ck == nil || ck.blockNum == 0 {
+			return []byte{}, nil
+		}
+		if ck.blockNum > 255 {
+			return nil, errors.New("number of blocks exceeds 1 byte")
+		}
+		chunkBytes := make([]byte, 0, 1+len(ck.blockContext)+len(ck.txsPayload)/2)
+		chunkBytes = append(chunkBytes, byte(ck.blockNum))
+		chunkBytes = append(chunkBytes, ck.blockContext...)
+		if len(ck.txsPayload) > 0 {
+			chunkBytes = txsPayloadEncoder.EncodeAll(ck.txsPayload, chunkBytes)
+		}
+		return chunkBytes, nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedBatchVersion, version)
+	}
+}
+
+// EncodeWithVersion encodes all the chunks in the format of the given batch version.
+func (cks *Chunks) EncodeWithVersion(version uint8) ([][]byte, error) {
+	var bytes [][]byte
+	for _, ck := range cks.data {
+		ckBytes, err := ck.EncodeWithVersion(version)
+		if err != nil {
+			return nil, err
+		}
+		bytes = append(bytes, ckBytes)
+	}
+	return bytes, nil
+}
+
+// DecodeChunk decodes a chunk committed in a batch of the given version.
+// The returned chunk has no transaction hashes and row consumption, they are not part of the encoding.
+func DecodeChunk(version uint8, chunkBytes []byte) (*Chunk, error) {
+	if !IsSupportedBatchVersion(version) {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedBatchVersion, version)
+	}
+	if len(chunkBytes) == 0 {
+		return nil, errors.New("empty chunk")
+	}
+	blockNum := int(chunkBytes[0])
+	contextEnd := 1 + blockNum*blockContextLength
+	if len(chunkBytes) < contextEnd {
+		return nil, fmt.Errorf("insufficient data for %d block contexts", blockNum)
+	}
+	blockContext := make([]byte, blockNum*blockContextLength)
+	copy(blockContext, chunkBytes[1:contextEnd])
+
+	txsPayload := make([]byte, 0, len(chunkBytes)-contextEnd)
+	switch version {
+	case BatchVersionV0:
+		txsPayload = append(txsPayload, chunkBytes[contextEnd:]...)
+	case BatchVersionV1:
+		if len(chunkBytes) > contextEnd {
+			var err error
+			if txsPayload, err = txsPayloadDecoder.DecodeAll(chunkBytes[contextEnd:], nil); err != nil {
+				return nil, fmt.Errorf("decompress transactions payload error: %w", err)
+			}
+		}
+	}
+	chunk := NewChunk(blockContext, txsPayload, nil, nil)
+	chunk.ResetBlockNum(blockNum)
+	return chunk, nil
+}
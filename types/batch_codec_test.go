@@ -0,0 +1,129 @@
+package types
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/crypto"
+	"github.com/stretchr/testify/require"
+)
+
+// testTxsPayload builds the V0 transactions payload of txNum ERC20 transfers signed by a few accounts.
+func testTxsPayload(t *testing.T, txNum int) []byte {
+	signer := types.NewLondonSigner(big.NewInt(2710))
+	token := common.HexToAddress("0x5300000000000000000000000000000000000004")
+	var payload []byte
+	for i := 0; i < txNum; i++ {
+		key, err := crypto.ToECDSA(common.LeftPadBytes(big.NewInt(int64(i%4+1)).Bytes(), 32))
+		require.NoError(t, err)
+		// transfer(address,uint256)
+		data := common.FromHex("a9059cbb")
+		data = append(data, common.LeftPadBytes(big.NewInt(int64(1000+i%16)).Bytes(), 32)...)
+		data = append(data, common.LeftPadBytes(big.NewInt(int64(i+1)*1e15).Bytes(), 32)...)
+		tx, err := types.SignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   big.NewInt(2710),
+			Nonce:     uint64(i / 4),
+			GasTipCap: big.NewInt(1e6),
+			GasFeeCap: big.NewInt(1e9),
+			Gas:       60000,
+			To:        &token,
+			Data:      data,
+		})
+		require.NoError(t, err)
+		bz, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		var txLen [4]byte
+		binary.BigEndian.PutUint32(txLen[:], uint32(len(bz)))
+		payload = append(payload, txLen[:]...)
+		payload = append(payload, bz...)
+	}
+	return payload
+}
+
+func testBlockContext(number uint64) []byte {
+	bc := make([]byte, blockContextLength)
+	binary.BigEndian.PutUint64(bc, number)
+	binary.BigEndian.PutUint64(bc[8:], 1700000000+number*3)
+	return bc
+}
+
+func TestChunkCodecRoundTrip(t *testing.T) {
+	for _, version := range []uint8{BatchVersionV0, BatchVersionV1} {
+		chunks := NewChunks()
+		chunks.Append(testBlockContext(1), testTxsPayload(t, 10), nil, nil)
+		chunks.Append(testBlockContext(2), nil, nil, nil)
+		chunks.Append(testBlockContext(3), testTxsPayload(t, 3), nil, nil)
+		chunks.data = append(chunks.data, NewChunk(testBlockContext(4), nil, nil, nil))
+
+		encoded, err := chunks.EncodeWithVersion(version)
+		require.NoError(t, err)
+		require.Len(t, encoded, 2)
+
+		for i, ckBytes := range encoded {
+			ck, err := DecodeChunk(version, ckBytes)
+			require.NoError(t, err)
+			require.Equal(t, chunks.data[i].blockNum, ck.BlockNum())
+			require.Equal(t, chunks.data[i].blockContext, ck.BlockContext())
+			require.Equal(t, len(chunks.data[i].txsPayload), len(ck.TxsPayload()))
+			if len(ck.TxsPayload()) > 0 {
+				require.Equal(t, chunks.data[i].txsPayload, ck.TxsPayload())
+			}
+
+			reEncoded, err := ck.EncodeWithVersion(version)
+			require.NoError(t, err)
+			require.Equal(t, ckBytes, reEncoded)
+		}
+	}
+
+	v0, err := NewChunk(testBlockContext(1), nil, nil, nil).EncodeWithVersion(BatchVersionV0)
+	require.NoError(t, err)
+	v1, err := NewChunk(testBlockContext(1), nil, nil, nil).EncodeWithVersion(BatchVersionV1)
+	require.NoError(t, err)
+	require.Equal(t, v0, v1, "chunks without transactions are encoded the same in both versions")
+}
+
+func TestDecodeChunkErrors(t *testing.T) {
+	ckBytes, err := NewChunk(testBlockContext(1), testTxsPayload(t, 2), nil, nil).EncodeWithVersion(BatchVersionV1)
+	require.NoError(t, err)
+
+	_, err = DecodeChunk(2, ckBytes)
+	require.ErrorIs(t, err, ErrUnsupportedBatchVersion)
+	_, err = NewChunk(testBlockContext(1), nil, nil, nil).EncodeWithVersion(2)
+	require.ErrorIs(t, err, ErrUnsupportedBatchVersion)
+
+	_, err = DecodeChunk(BatchVersionV1, nil)
+	require.Error(t, err)
+	_, err = DecodeChunk(BatchVersionV1, ckBytes[:30])
+	require.Error(t, err, "truncated block context")
+
+	corrupted := append([]byte{}, ckBytes...)
+	corrupted = corrupted[:len(corrupted)-8]
+	_, err = DecodeChunk(BatchVersionV1, corrupted)
+	require.Error(t, err, "truncated zstd frame")
+
+	// a V0 chunk is not a valid V1 chunk
+	v0Bytes, err := NewChunk(testBlockContext(1), testTxsPayload(t, 2), nil, nil).EncodeWithVersion(BatchVersionV0)
+	require.NoError(t, err)
+	_, err = DecodeChunk(BatchVersionV1, v0Bytes)
+	require.Error(t, err)
+}
+
+func TestChunkCodecSizeRegression(t *testing.T) {
+	payload := testTxsPayload(t, 200)
+	chunk := NewChunk(testBlockContext(1), payload, nil, nil)
+
+	v0, err := chunk.EncodeWithVersion(BatchVersionV0)
+	require.NoError(t, err)
+	v1, err := chunk.EncodeWithVersion(BatchVersionV1)
+	require.NoError(t, err)
+
+	ratio := float64(len(v1)) / float64(len(v0))
+	t.Logf("v0 size: %d, v1 size: %d, ratio: %.3f", len(v0), len(v1), ratio)
+	// signatures are incompressible, the rest of the transfers compresses well
+	require.Less(t, ratio, 0.5)
+	// 15195 bytes when the codec was introduced, a larger output means the compression regressed
+	require.LessOrEqual(t, len(v1), 15500)
+}
@@ -0,0 +1,68 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/scroll-tech/go-ethereum/common"
+)
+
+// BatchStatus is the stage a batch sealed by the sequencer has reached.
+type BatchStatus uint8
+
+const (
+	// BatchSealed is a batch sealed from the packed blocks.
+	BatchSealed BatchStatus = iota
+	// BatchSigned is a batch with the BLS signatures of the sequencers collected.
+	BatchSigned
+	// BatchSubmitted is a batch handed to L2 geth, to be committed to L1.
+	BatchSubmitted
+	// BatchCommitted is a batch whose CommitBatch event is confirmed on L1.
+	BatchCommitted
+	// BatchFinalized is a batch whose FinalizeBatch event is confirmed on L1.
+	BatchFinalized
+)
+
+var batchStatusNames = [...]string{"sealed", "signed", "submitted", "committed", "finalized"}
+
+// BatchStatuses lists all the batch statuses, in order.
+var BatchStatuses = []BatchStatus{BatchSealed, BatchSigned, BatchSubmitted, BatchCommitted, BatchFinalized}
+
+func (s BatchStatus) String() string {
+	if int(s) < len(batchStatusNames) {
+		return batchStatusNames[s]
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(s))
+}
+
+func (s BatchStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *BatchStatus) UnmarshalText(text []byte) error {
+	for i, name := range batchStatusNames {
+		if name == string(text) {
+			*s = BatchStatus(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown batch status %q", text)
+}
+
+// BatchRecord tracks a batch sealed by the sequencer, from sealing to its finalization on L1.
+type BatchRecord struct {
+	Index      uint64      `json:"index"`
+	Hash       common.Hash `json:"hash"`
+	FirstBlock uint64      `json:"firstBlock"`
+	LastBlock  uint64      `json:"lastBlock"`
+	// Size is the length of the commitBatch calldata, estimated for all the sequencers signing until the batch is signed.
+	Size       uint64      `json:"size"`
+	Signatures uint64      `json:"signatures"`
+	Status     BatchStatus `json:"status"`
+	// SealedAt is the unix time the batch was sealed last.
+	SealedAt uint64 `json:"sealedAt"`
+
+	CommitL1Block   uint64      `json:"commitL1Block"`
+	CommitTxHash    common.Hash `json:"commitTxHash"`
+	FinalizeL1Block uint64      `json:"finalizeL1Block"`
+	FinalizeTxHash  common.Hash `json:"finalizeTxHash"`
+}
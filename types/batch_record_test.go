@@ -0,0 +1,20 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchStatusJSON(t *testing.T) {
+	bz, err := json.Marshal(BatchRecord{Index: 1, Status: BatchCommitted})
+	require.NoError(t, err)
+	require.Contains(t, string(bz), `"status":"committed"`)
+
+	var record BatchRecord
+	require.NoError(t, json.Unmarshal(bz, &record))
+	require.Equal(t, BatchCommitted, record.Status)
+	require.Error(t, json.Unmarshal([]byte(`{"status":"lost"}`), &record))
+	require.Equal(t, "unknown(9)", BatchStatus(9).String())
+}
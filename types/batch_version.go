@@ -0,0 +1,44 @@
+package types
+
+import (
+	"fmt"
+	"sort"
+)
+
+// BatchVersionUpgrade seals the batches with Version from the ones starting at the block Height on.
+type BatchVersionUpgrade struct {
+	Height  uint64 `json:"height"`
+	Version uint8  `json:"version"`
+}
+
+// BatchVersionSchedule tells the version of the batch starting at every height, so that all the sequencers seal identical batches.
+// Batches starting before the first upgrade are BatchVersionV0.
+type BatchVersionSchedule struct {
+	upgrades []BatchVersionUpgrade
+}
+
+func NewBatchVersionSchedule(upgrades ...BatchVersionUpgrade) (*BatchVersionSchedule, error) {
+	sorted := append([]BatchVersionUpgrade{}, upgrades...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Height < sorted[j].Height })
+	for i, upgrade := range sorted {
+		if !IsSupportedBatchVersion(upgrade.Version) {
+			return nil, fmt.Errorf("batch version at height %d: %w: %d", upgrade.Height, ErrUnsupportedBatchVersion, upgrade.Version)
+		}
+		if i > 0 && sorted[i-1].Height == upgrade.Height {
+			return nil, fmt.Errorf("duplicated batch version at height %d", upgrade.Height)
+		}
+	}
+	return &BatchVersionSchedule{upgrades: sorted}, nil
+}
+
+// At returns the version of the batch whose first block has the given height.
+func (s *BatchVersionSchedule) At(height uint64) uint8 {
+	if s == nil {
+		return BatchVersionV0
+	}
+	i := sort.Search(len(s.upgrades), func(i int) bool { return s.upgrades[i].Height > height })
+	if i == 0 {
+		return BatchVersionV0
+	}
+	return s.upgrades[i-1].Version
+}
@@ -0,0 +1,29 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchVersionSchedule(t *testing.T) {
+	var nilSchedule *BatchVersionSchedule
+	require.Equal(t, BatchVersionV0, nilSchedule.At(10))
+
+	schedule, err := NewBatchVersionSchedule(
+		BatchVersionUpgrade{Height: 200, Version: BatchVersionV0},
+		BatchVersionUpgrade{Height: 100, Version: BatchVersionV1},
+	)
+	require.NoError(t, err)
+	require.Equal(t, BatchVersionV0, schedule.At(0))
+	require.Equal(t, BatchVersionV0, schedule.At(99))
+	require.Equal(t, BatchVersionV1, schedule.At(100))
+	require.Equal(t, BatchVersionV1, schedule.At(199))
+	require.Equal(t, BatchVersionV0, schedule.At(200))
+
+	_, err = NewBatchVersionSchedule(BatchVersionUpgrade{Height: 1, Version: BatchVersionV1}, BatchVersionUpgrade{Height: 1, Version: BatchVersionV0})
+	require.Error(t, err)
+	// blob batches are not sealed by the sequencers
+	_, err = NewBatchVersionSchedule(BatchVersionUpgrade{Height: 1, Version: BatchVersionV2})
+	require.ErrorIs(t, err, ErrUnsupportedBatchVersion)
+}
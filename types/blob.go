@@ -0,0 +1,201 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	gokzg4844 "github.com/crate-crypto/go-kzg-4844"
+	"github.com/scroll-tech/go-ethereum/common"
+)
+
+const (
+	// BlobSize is the size of an EIP-4844 blob: 4096 field elements of 32 bytes.
+	BlobSize = gokzg4844.ScalarsPerBlob * gokzg4844.SerializedScalarSize
+	// MaxBlobsPerBatch is the number of blobs a single L1 transaction can carry.
+	MaxBlobsPerBatch = 6
+	// BlobCommitmentVersionKZG is the version byte of the versioned hash of a KZG commitment.
+	BlobCommitmentVersionKZG = 0x01
+
+	// every field element carries 31 bytes behind a zero byte, so that it stays below the BLS modulus
+	blobBytesPerFieldElement = gokzg4844.SerializedScalarSize - 1
+	// MaxBlobPayloadSize is the number of payload bytes a single blob carries.
+	MaxBlobPayloadSize = gokzg4844.ScalarsPerBlob * blobBytesPerFieldElement
+)
+
+type (
+	Blob          [BlobSize]byte
+	KZGCommitment [48]byte
+	KZGProof      [48]byte
+)
+
+var (
+	kzgContext     *gokzg4844.Context
+	kzgContextErr  error
+	kzgContextOnce sync.Once
+)
+
+// kzg loads the trusted setup of the 4844 ceremony on first use, it takes a while.
+func kzg() (*gokzg4844.Context, error) {
+	kzgContextOnce.Do(func() {
+		kzgContext, kzgContextErr = gokzg4844.NewContext4096Secure()
+	})
+	return kzgContext, kzgContextErr
+}
+
+// EncodeBlobs serializes the chunks into as few blobs as possible.
+// The blobs carry one byte stream, split every MaxBlobPayloadSize bytes:
+// Field           Bytes       Type            Comments
+// numChunks       2           uint16          The number of chunks in the batch
+// chunkLength[i]  4           uint32          The length of the (i+1)'th chunk
+// chunk[i]        dynamic     bytes           The (i+1)'th chunk in the BatchVersionV1 encoding
+// The rest of the last blob is zero.
+func (cks *Chunks) EncodeBlobs() ([]*Blob, error) {
+	if len(cks.data) > 0xffff {
+		return nil, errors.New("number of chunks exceeds 2 bytes")
+	}
+	payload := make([]byte, 2, 2+cks.size)
+	binary.BigEndian.PutUint16(payload, uint16(len(cks.data)))
+	for _, ck := range cks.data {
+		ckBytes, err := ck.EncodeWithVersion(BatchVersionV1)
+		if err != nil {
+			return nil, err
+		}
+		var ckLen [4]byte
+		binary.BigEndian.PutUint32(ckLen[:], uint32(len(ckBytes)))
+		payload = append(payload, ckLen[:]...)
+		payload = append(payload, ckBytes...)
+	}
+	blobNum := (len(payload) + MaxBlobPayloadSize - 1) / MaxBlobPayloadSize
+	if blobNum > MaxBlobsPerBatch {
+		return nil, fmt.Errorf("batch payload of %d bytes needs %d blobs, at most %d are allowed", len(payload), blobNum, MaxBlobsPerBatch)
+	}
+	blobs := make([]*Blob, blobNum)
+	for i := range blobs {
+		end := (i + 1) * MaxBlobPayloadSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		blobs[i] = newBlob(payload[i*MaxBlobPayloadSize : end])
+	}
+	return blobs, nil
+}
+
+func newBlob(payload []byte) *Blob {
+	var blob Blob
+	for i := 0; len(payload) > 0; i++ {
+		n := copy(blob[i*gokzg4844.SerializedScalarSize+1:(i+1)*gokzg4844.SerializedScalarSize], payload)
+		payload = payload[n:]
+	}
+	return &blob
+}
+
+// payload returns the bytes carried by the blob, including the zero padding.
+func (b *Blob) payload() ([]byte, error) {
+	payload := make([]byte, 0, MaxBlobPayloadSize)
+	for i := 0; i < gokzg4844.ScalarsPerBlob; i++ {
+		fieldElement := b[i*gokzg4844.SerializedScalarSize : (i+1)*gokzg4844.SerializedScalarSize]
+		if fieldElement[0] != 0 {
+			return nil, fmt.Errorf("field element %d of the blob has a non-zero high byte", i)
+		}
+		payload = append(payload, fieldElement[1:]...)
+	}
+	return payload, nil
+}
+
+// DecodeBlobs decodes the chunks serialized by EncodeBlobs.
+func DecodeBlobs(blobs []*Blob) ([]*Chunk, error) {
+	if len(blobs) == 0 || len(blobs) > MaxBlobsPerBatch {
+		return nil, fmt.Errorf("invalid number of blobs: %d", len(blobs))
+	}
+	var payload []byte
+	for i, blob := range blobs {
+		blobPayload, err := blob.payload()
+		if err != nil {
+			return nil, fmt.Errorf("blob %d: %w", i, err)
+		}
+		payload = append(payload, blobPayload...)
+	}
+	chunkNum := int(binary.BigEndian.Uint16(payload))
+	payload = payload[2:]
+	chunks := make([]*Chunk, chunkNum)
+	for i := range chunks {
+		if len(payload) < 4 {
+			return nil, fmt.Errorf("insufficient data for the length of chunk %d", i)
+		}
+		ckLen := binary.BigEndian.Uint32(payload)
+		payload = payload[4:]
+		if uint64(len(payload)) < uint64(ckLen) {
+			return nil, fmt.Errorf("insufficient data for chunk %d", i)
+		}
+		ck, err := DecodeChunk(BatchVersionV1, payload[:ckLen])
+		if err != nil {
+			return nil, fmt.Errorf("decode chunk %d error: %w", i, err)
+		}
+		chunks[i] = ck
+		payload = payload[ckLen:]
+	}
+	for _, b := range payload {
+		if b != 0 {
+			return nil, errors.New("non-zero bytes after the last chunk")
+		}
+	}
+	return chunks, nil
+}
+
+// Commitment computes the KZG commitment of the blob.
+func (b *Blob) Commitment() (KZGCommitment, error) {
+	ctx, err := kzg()
+	if err != nil {
+		return KZGCommitment{}, err
+	}
+	commitment, err := ctx.BlobToKZGCommitment(gokzg4844.Blob(*b), 0)
+	if err != nil {
+		return KZGCommitment{}, err
+	}
+	return KZGCommitment(commitment), nil
+}
+
+// Proof computes the KZG proof of the blob against its commitment, as required by the blob transaction sidecar.
+func (b *Blob) Proof(commitment KZGCommitment) (KZGProof, error) {
+	ctx, err := kzg()
+	if err != nil {
+		return KZGProof{}, err
+	}
+	proof, err := ctx.ComputeBlobKZGProof(gokzg4844.Blob(*b), gokzg4844.KZGCommitment(commitment), 0)
+	if err != nil {
+		return KZGProof{}, err
+	}
+	return KZGProof(proof), nil
+}
+
+// VerifyProof verifies the KZG proof of the blob against the commitment.
+func (b *Blob) VerifyProof(commitment KZGCommitment, proof KZGProof) error {
+	ctx, err := kzg()
+	if err != nil {
+		return err
+	}
+	return ctx.VerifyBlobKZGProof(gokzg4844.Blob(*b), gokzg4844.KZGCommitment(commitment), gokzg4844.KZGProof(proof))
+}
+
+// VersionedHash returns the versioned hash of the commitment, as defined by EIP-4844.
+func (c KZGCommitment) VersionedHash() common.Hash {
+	hash := common.Hash(sha256.Sum256(c[:]))
+	hash[0] = BlobCommitmentVersionKZG
+	return hash
+}
+
+// BlobVersionedHashes computes the versioned hashes of the blobs, in order.
+func BlobVersionedHashes(blobs []*Blob) ([]common.Hash, error) {
+	hashes := make([]common.Hash, len(blobs))
+	for i, blob := range blobs {
+		commitment, err := blob.Commitment()
+		if err != nil {
+			return nil, fmt.Errorf("commit to blob %d error: %w", i, err)
+		}
+		hashes[i] = commitment.VersionedHash()
+	}
+	return hashes, nil
+}
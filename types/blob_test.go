@@ -0,0 +1,123 @@
+package types
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobsRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(4844))
+	randomBytes := func(n int) []byte {
+		b := make([]byte, n)
+		rng.Read(b)
+		return b
+	}
+
+	chunks := NewChunks()
+	// random transactions do not compress, so the batch spills over into a second blob
+	chunks.data = append(chunks.data,
+		NewChunk(testBlockContext(1), randomBytes(100_000), nil, nil),
+		NewChunk(append(testBlockContext(2), testBlockContext(3)...), nil, nil, nil),
+		NewChunk(testBlockContext(4), randomBytes(50_000), nil, nil),
+	)
+	chunks.data[1].ResetBlockNum(2)
+
+	blobs, err := chunks.EncodeBlobs()
+	require.NoError(t, err)
+	require.Len(t, blobs, 2)
+
+	decoded, err := DecodeBlobs(blobs)
+	require.NoError(t, err)
+	require.Len(t, decoded, len(chunks.data))
+	for i, ck := range decoded {
+		require.Equal(t, chunks.data[i].BlockNum(), ck.BlockNum())
+		require.Equal(t, chunks.data[i].BlockContext(), ck.BlockContext())
+		require.Equal(t, len(chunks.data[i].TxsPayload()), len(ck.TxsPayload()))
+		if len(ck.TxsPayload()) > 0 {
+			require.Equal(t, chunks.data[i].TxsPayload(), ck.TxsPayload())
+		}
+	}
+
+	_, err = DecodeBlobs(blobs[:1])
+	require.Error(t, err, "the chunks continue in the missing blob")
+
+	invalid := *blobs[1]
+	invalid[32*100] = 1
+	_, err = DecodeBlobs([]*Blob{blobs[0], &invalid})
+	require.Error(t, err, "field elements must have a zero high byte")
+
+	trailing := *blobs[1]
+	trailing[BlobSize-1] = 1
+	_, err = DecodeBlobs([]*Blob{blobs[0], &trailing})
+	require.Error(t, err, "the padding must be zero")
+
+	tooLarge := NewChunks()
+	for i := 0; i < 7; i++ {
+		tooLarge.data = append(tooLarge.data, NewChunk(testBlockContext(uint64(i)), randomBytes(MaxBlobPayloadSize), nil, nil))
+	}
+	_, err = tooLarge.EncodeBlobs()
+	require.Error(t, err)
+}
+
+func TestBlobCommitment(t *testing.T) {
+	chunks := NewChunks()
+	chunks.Append(testBlockContext(1), testTxsPayload(t, 20), nil, nil)
+	blobs, err := chunks.EncodeBlobs()
+	require.NoError(t, err)
+	require.Len(t, blobs, 1)
+
+	commitment, err := blobs[0].Commitment()
+	require.NoError(t, err)
+	proof, err := blobs[0].Proof(commitment)
+	require.NoError(t, err)
+	require.NoError(t, blobs[0].VerifyProof(commitment, proof))
+
+	hashes, err := BlobVersionedHashes(blobs)
+	require.NoError(t, err)
+	require.Equal(t, []common.Hash{commitment.VersionedHash()}, hashes)
+	require.EqualValues(t, BlobCommitmentVersionKZG, hashes[0][0])
+
+	tampered := *blobs[0]
+	tampered[1] ^= 1
+	require.Error(t, tampered.VerifyProof(commitment, proof))
+	tamperedCommitment, err := tampered.Commitment()
+	require.NoError(t, err)
+	require.NotEqual(t, commitment.VersionedHash(), tamperedCommitment.VersionedHash())
+}
+
+func TestBatchHeaderBlobVersionedHashes(t *testing.T) {
+	header := BatchHeader{
+		Version:                BatchVersionV2,
+		BatchIndex:             7,
+		L1MessagePopped:        3,
+		TotalL1MessagePopped:   10,
+		DataHash:               common.HexToHash("0x01"),
+		ParentBatchHash:        common.HexToHash("0x02"),
+		SkippedL1MessageBitmap: make([]byte, 32),
+		BlobVersionedHashes:    []common.Hash{common.HexToHash("0x0103"), common.HexToHash("0x0104")},
+	}
+	header.SkippedL1MessageBitmap[31] = 5
+	encoded := header.Encode()
+	require.Len(t, encoded, 89+1+2*32+32)
+
+	decoded, err := DecodeBatchHeader(encoded)
+	require.NoError(t, err)
+	require.Equal(t, header.BlobVersionedHashes, decoded.BlobVersionedHashes)
+	require.Equal(t, header.SkippedL1MessageBitmap, decoded.SkippedL1MessageBitmap)
+	require.Equal(t, header.ParentBatchHash, decoded.ParentBatchHash)
+	require.Equal(t, header.Hash(), decoded.Hash())
+
+	_, err = DecodeBatchHeader(encoded[:100])
+	require.Error(t, err)
+
+	// calldata batches do not encode versioned hashes
+	header.Version = BatchVersionV1
+	header.Bytes = nil
+	decoded, err = DecodeBatchHeader(header.Encode())
+	require.NoError(t, err)
+	require.Empty(t, decoded.BlobVersionedHashes)
+	require.Equal(t, header.SkippedL1MessageBitmap, decoded.SkippedL1MessageBitmap)
+}
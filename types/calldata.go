@@ -0,0 +1,74 @@
+package types
+
+// The calldata of the Rollup commitBatch call is
+// selector || offset(batchData) || batchData
+// where batchData is the tuple
+// (uint8 version, bytes parentBatchHeader, bytes[] chunks, bytes skippedL1MessageBitmap,
+// bytes32 prevStateRoot, bytes32 postStateRoot, bytes32 withdrawalRoot,
+// (uint256 version, uint256[] signers, bytes signature) signature).
+// Its head holds the static fields and the offsets of the dynamic ones in 32-byte words, followed by their data.
+const (
+	abiWordSize = 32
+
+	commitBatchSelectorSize = 4
+	// the head of batchData: version, 3 offsets, 3 roots and the offset of signature
+	batchDataHeadSize = 8 * abiWordSize
+	// the head of signature: version and 2 offsets
+	batchSignatureHeadSize = 3 * abiWordSize
+)
+
+// abiPaddedSize rounds n up to a multiple of 32 bytes.
+func abiPaddedSize(n int) int {
+	return (n + abiWordSize - 1) / abiWordSize * abiWordSize
+}
+
+// abiBytesSize is the size of an ABI encoded dynamic bytes value of length n: its length and padded data.
+func abiBytesSize(n int) int {
+	return abiWordSize + abiPaddedSize(n)
+}
+
+// calldataChunkSize is the size of a chunk of length n in the ABI encoded bytes[]: its offset and its bytes.
+func calldataChunkSize(n int) int {
+	return abiWordSize + abiBytesSize(n)
+}
+
+// EncodedChunksCalldataSize returns the size of the encoded chunks in the ABI encoded calldata of commitBatch,
+// their length excluded, like Chunks.CalldataSize.
+func EncodedChunksCalldataSize(chunksBytes [][]byte) int {
+	size := 0
+	for _, ckBytes := range chunksBytes {
+		size += calldataChunkSize(len(ckBytes))
+	}
+	return size
+}
+
+// maxEncodedChunkSize is the length of a chunk encoded for the batch version, at most, given the lengths
+// of its block contexts and its transactions. V0 chunks are exactly that long. The transactions of V1 chunks
+// are zstd compressed, which may grow incompressible data up to zstdCompressBound.
+func maxEncodedChunkSize(version uint8, blockContextLen, txsPayloadLen int) int {
+	if version == BatchVersionV1 && txsPayloadLen > 0 {
+		return 1 + blockContextLen + zstdCompressBound(txsPayloadLen)
+	}
+	return 1 + blockContextLen + txsPayloadLen
+}
+
+// zstdCompressBound is the length of a zstd frame of n bytes, at most, as ZSTD_COMPRESSBOUND of the reference library.
+func zstdCompressBound(n int) int {
+	bound := n + n>>8
+	if n < 128<<10 {
+		bound += (128<<10 - n) >> 11
+	}
+	return bound
+}
+
+// CommitBatchCalldataSize returns the length of the commitBatch calldata.
+// chunksCalldataSize is given by Chunks.CalldataSize or EncodedChunksCalldataSize, the signature is signed
+// by signerNum sequencers. The length is exact as long as chunksCalldataSize is.
+func CommitBatchCalldataSize(parentBatchHeaderLen, chunksCalldataSize, skippedBitmapLen, signerNum, signatureLen int) int {
+	return commitBatchSelectorSize + abiWordSize +
+		batchDataHeadSize +
+		abiBytesSize(parentBatchHeaderLen) +
+		abiWordSize + chunksCalldataSize +
+		abiBytesSize(skippedBitmapLen) +
+		batchSignatureHeadSize + abiWordSize + signerNum*abiWordSize + abiBytesSize(signatureLen)
+}
@@ -0,0 +1,124 @@
+package types
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/morph-l2/bindings/bindings"
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitBatchCalldataSize(t *testing.T) {
+	rollupABI, err := bindings.RollupMetaData.GetAbi()
+	require.NoError(t, err)
+	rng := rand.New(rand.NewSource(39))
+	randomBytes := func(n int) []byte {
+		b := make([]byte, n)
+		rng.Read(b)
+		return b
+	}
+
+	for round := 0; round < 20; round++ {
+		chunks := NewChunks()
+		parentBatchHeader := randomBytes(89 + 32*rng.Intn(3))
+		skippedBitmap := randomBytes(32 * rng.Intn(3))
+		signerNum := rng.Intn(5)
+		signature := randomBytes(96 + rng.Intn(64))
+
+		blockNum := rng.Intn(250)
+		for i := 0; i <= blockNum; i++ {
+			blockContext := randomBytes(60)
+			txsPayload := randomBytes(rng.Intn(300))
+			// every now and then the block does not fit the row limit of the chunk
+			rc := types.RowConsumption{{Name: "a", RowNumber: uint64(rng.Intn(NormalizedRowLimit / 4))}}
+
+			expectedV0 := chunks.CalldataSizeWithBlock(BatchVersionV0, blockContext, txsPayload, rc)
+			expectedV1 := chunks.CalldataSizeWithBlock(BatchVersionV1, blockContext, txsPayload, rc)
+			chunks.Append(blockContext, txsPayload, nil, rc)
+			require.Equal(t, expectedV0, chunks.CalldataSize(BatchVersionV0))
+			require.Equal(t, expectedV1, chunks.CalldataSize(BatchVersionV1))
+		}
+
+		signers := make([]*big.Int, signerNum)
+		for i := range signers {
+			signers[i] = big.NewInt(int64(i))
+		}
+		pack := func(version uint8) int {
+			chunksBytes, err := chunks.EncodeWithVersion(version)
+			require.NoError(t, err)
+			calldata, err := rollupABI.Pack("commitBatch", bindings.IRollupBatchData{
+				Version:                version,
+				ParentBatchHeader:      parentBatchHeader,
+				Chunks:                 chunksBytes,
+				SkippedL1MessageBitmap: skippedBitmap,
+				Signature: bindings.IRollupBatchSignature{
+					Version:   big.NewInt(1),
+					Signers:   signers,
+					Signature: signature,
+				},
+			})
+			require.NoError(t, err)
+			// the size of the encoded chunks is exact in any version
+			require.Equal(t, len(calldata), CommitBatchCalldataSize(len(parentBatchHeader), EncodedChunksCalldataSize(chunksBytes), len(skippedBitmap), signerNum, len(signature)))
+			return len(calldata)
+		}
+		require.Equal(t, pack(BatchVersionV0), CommitBatchCalldataSize(len(parentBatchHeader), chunks.CalldataSize(BatchVersionV0), len(skippedBitmap), signerNum, len(signature)),
+			"chunks: %d, blocks: %d", chunks.ChunkNum(), chunks.BlockNum())
+		// the closed V1 chunks count exactly, only the last one counts the bound
+		chunksBytes, err := chunks.EncodeWithVersion(BatchVersionV1)
+		require.NoError(t, err)
+		lastChunk := chunks.Chunk(chunks.ChunkNum() - 1)
+		require.Equal(t, EncodedChunksCalldataSize(chunksBytes[:len(chunksBytes)-1]), chunks.CalldataSize(BatchVersionV1)-calldataChunkSize(lastChunk.maxEncodedSize(BatchVersionV1)))
+		// random transactions do not compress, V1 chunks are bounded by the worst case of the compression
+		v1Bound := CommitBatchCalldataSize(len(parentBatchHeader), chunks.CalldataSize(BatchVersionV1), len(skippedBitmap), signerNum, len(signature))
+		require.LessOrEqual(t, pack(BatchVersionV1), v1Bound)
+		require.Greater(t, v1Bound, pack(BatchVersionV0))
+	}
+}
+
+func TestChunksCalldataSizeCompressed(t *testing.T) {
+	chunks := NewChunks()
+	chunks.SetLimits(ChunkLimits{MaxBlocks: 2, MaxRowNumber: NormalizedRowLimit})
+	blockContext := make([]byte, 60)
+	txsPayload := make([]byte, 1000)
+	for i := 0; i < 6; i++ {
+		chunks.Append(blockContext, txsPayload, nil, nil)
+	}
+	require.Equal(t, 3, chunks.ChunkNum())
+
+	// the zeroed transactions compress well: the closed chunks count their compressed length
+	chunksBytes, err := chunks.EncodeWithVersion(BatchVersionV1)
+	require.NoError(t, err)
+	lastChunk := chunks.Chunk(2)
+	exact := EncodedChunksCalldataSize(chunksBytes)
+	require.Equal(t, exact-calldataChunkSize(len(chunksBytes[2]))+calldataChunkSize(lastChunk.maxEncodedSize(BatchVersionV1)), chunks.CalldataSize(BatchVersionV1))
+	require.Less(t, chunks.CalldataSize(BatchVersionV1), chunks.CalldataSize(BatchVersionV0))
+
+	// a block closing the last chunk counts it exactly too
+	require.True(t, chunks.IsChunksAppendedWithNewBlock(txsPayload, nil))
+	require.Equal(t, exact+calldataChunkSize(maxEncodedChunkSize(BatchVersionV1, len(blockContext), len(txsPayload))),
+		chunks.CalldataSizeWithBlock(BatchVersionV1, blockContext, txsPayload, nil))
+}
+
+func TestZstdCompressBound(t *testing.T) {
+	rng := rand.New(rand.NewSource(39))
+	for _, n := range []int{1, 2, 31, 32, 100, 1000, 4096, 100_000, 128 << 10, 200_000, 1 << 20} {
+		data := make([]byte, n)
+		rng.Read(data)
+		compressed := txsPayloadEncoder.EncodeAll(data, nil)
+		require.LessOrEqual(t, len(compressed), zstdCompressBound(n), "%d bytes", n)
+		require.Greater(t, len(compressed), n, "random data does not compress")
+	}
+}
+
+func TestCommitBatchCalldataSizeEmpty(t *testing.T) {
+	rollupABI, err := bindings.RollupMetaData.GetAbi()
+	require.NoError(t, err)
+	calldata, err := rollupABI.Pack("commitBatch", bindings.IRollupBatchData{
+		Signature: bindings.IRollupBatchSignature{Version: big.NewInt(0)},
+	})
+	require.NoError(t, err)
+	require.Equal(t, len(calldata), CommitBatchCalldataSize(0, NewChunks().CalldataSize(BatchVersionV1), 0, 0, 0))
+}
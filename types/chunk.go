@@ -14,74 +14,33 @@ const (
 )
 
 type Chunk struct {
-	blockContext  []byte
-	txsPayload    []byte
-	txHashes      []common.Hash
-	accumulatedRc types.RowConsumption
-	blockNum      int
+	blockContext []byte
+	txsPayload   []byte
+	txHashes     []common.Hash
+	rowUsage     *RowUsage
+	blockNum     int
+
+	// encodedSizes caches the length of the chunk encoded for each batch version, until a block is appended
+	encodedSizes map[uint8]int
 }
 
 func NewChunk(blockContext, txsPayload []byte, txHashes []common.Hash, rc types.RowConsumption) *Chunk {
 	return &Chunk{
-		blockContext:  blockContext,
-		txsPayload:    txsPayload,
-		txHashes:      txHashes,
-		accumulatedRc: rc,
-		blockNum:      1,
+		blockContext: blockContext,
+		txsPayload:   txsPayload,
+		txHashes:     txHashes,
+		rowUsage:     NewRowUsage(rc),
+		blockNum:     1,
 	}
 }
 
-func (ck *Chunk) append(blockContext, txsPayload []byte, txHashes []common.Hash, accRc types.RowConsumption) {
+func (ck *Chunk) append(blockContext, txsPayload []byte, txHashes []common.Hash, rowUsage *RowUsage) {
 	ck.blockContext = append(ck.blockContext, blockContext...)
 	ck.txsPayload = append(ck.txsPayload, txsPayload...)
 	ck.txHashes = append(ck.txHashes, txHashes...)
-	ck.accumulatedRc = accRc
+	ck.rowUsage = rowUsage
 	ck.blockNum++
-}
-
-func (ck *Chunk) accumulateRowUsages(rc types.RowConsumption) (accRc types.RowConsumption, max uint64) {
-	if len(ck.accumulatedRc) == 0 {
-		return rc, maxRowNumber(rc)
-	}
-	if len(rc) == 0 {
-		copy(accRc, ck.accumulatedRc)
-		max = maxRowNumber(ck.accumulatedRc)
-		return
-	}
-	accRowUsagesBefore := make(map[string]uint64)
-	for _, element := range ck.accumulatedRc {
-		accRowUsagesBefore[element.Name] = element.RowNumber
-	}
-	addRowUsage := make(map[string]uint64)
-	for _, element := range rc {
-		addRowUsage[element.Name] = element.RowNumber
-	}
-	accRowUsagesAfter := make(map[string]uint64)
-	for name, rowNumber := range accRowUsagesBefore {
-		add, ok := addRowUsage[name]
-		if ok {
-			accRowUsagesAfter[name] = rowNumber + add
-		} else {
-			accRowUsagesAfter[name] = rowNumber
-		}
-	}
-
-	for name, rowNumber := range accRowUsagesAfter {
-		accRc = append(accRc, types.SubCircuitRowUsage{Name: name, RowNumber: rowNumber})
-		if rowNumber > max {
-			max = rowNumber
-		}
-	}
-	return
-}
-
-func maxRowNumber(rc types.RowConsumption) (max uint64) {
-	for _, subRc := range rc {
-		if subRc.RowNumber > max {
-			max = subRc.RowNumber
-		}
-	}
-	return
+	ck.encodedSizes = nil
 }
 
 func (ck *Chunk) ResetBlockNum(blockNum int) {
@@ -128,6 +87,31 @@ func (ck *Chunk) Encode() ([]byte, error) {
 	return chunkBytes, nil
 }
 
+// maxEncodedSize is the length of the chunk encoded for the batch version, at most.
+func (ck *Chunk) maxEncodedSize(version uint8) int {
+	return maxEncodedChunkSize(version, len(ck.blockContext), len(ck.txsPayload))
+}
+
+// encodedSize is the length of the chunk encoded for the batch version. The V1 transactions are compressed
+// once for it, the length is kept until a block is appended.
+func (ck *Chunk) encodedSize(version uint8) int {
+	if version != BatchVersionV1 {
+		return ck.maxEncodedSize(version)
+	}
+	if size, ok := ck.encodedSizes[version]; ok {
+		return size
+	}
+	encoded, err := ck.EncodeWithVersion(version)
+	if err != nil {
+		return ck.maxEncodedSize(version)
+	}
+	if ck.encodedSizes == nil {
+		ck.encodedSizes = make(map[uint8]int)
+	}
+	ck.encodedSizes[version] = len(encoded)
+	return len(encoded)
+}
+
 func (ck *Chunk) Hash() common.Hash {
 	var bytes []byte
 	for i := 0; i < ck.blockNum; i++ {
@@ -142,6 +126,7 @@ func (ck *Chunk) Hash() common.Hash {
 type Chunks struct {
 	data     []*Chunk
 	blockNum int
+	limits   ChunkLimits
 
 	size int
 	hash *common.Hash
@@ -149,13 +134,46 @@ type Chunks struct {
 
 func NewChunks() *Chunks {
 	return &Chunks{
-		data: make([]*Chunk, 0),
+		data:   make([]*Chunk, 0),
+		limits: DefaultChunkLimits(),
+	}
+}
+
+// SetLimits sets the limits applying to the blocks appended from now on.
+func (cks *Chunks) SetLimits(limits ChunkLimits) {
+	cks.limits = limits
+}
+
+// rowUsageWith returns the row usage of the chunk with the block, deciding whether the block fits the chunk,
+// and the usage the chunk keeps if it does. They differ with the legacy accounting only.
+func (cks *Chunks) rowUsageWith(ck *Chunk, rc types.RowConsumption) (fit, kept *RowUsage) {
+	if cks.limits.legacyRowUsage {
+		return ck.rowUsage.legacyWith(rc)
+	}
+	fit = ck.rowUsage.With(rc)
+	return fit, fit
+}
+
+// closing tells whether appending a block to the chunk breaks the limits, and why. rowUsage is the usage of the chunk with the block.
+// It returns nil if the block fits the chunk.
+func (cks *Chunks) closing(ck *Chunk, txsPayload []byte, rowUsage *RowUsage) *ChunkClosing {
+	if ck.blockNum+1 > cks.limits.MaxBlocks {
+		return &ChunkClosing{Reason: ChunkCloseMaxBlocks, Value: uint64(ck.blockNum + 1), Limit: uint64(cks.limits.MaxBlocks), BlockNum: ck.blockNum}
+	}
+	if circuit, rows := rowUsage.Max(); rows > cks.limits.MaxRowNumber {
+		return &ChunkClosing{Reason: ChunkCloseMaxRowNumber, Circuit: circuit, Value: rows, Limit: cks.limits.MaxRowNumber, BlockNum: ck.blockNum}
 	}
+	if size := len(ck.txsPayload) + len(txsPayload); cks.limits.MaxTxsPayloadBytes > 0 && size > cks.limits.MaxTxsPayloadBytes {
+		return &ChunkClosing{Reason: ChunkCloseMaxTxsPayloadBytes, Value: uint64(size), Limit: uint64(cks.limits.MaxTxsPayloadBytes), BlockNum: ck.blockNum}
+	}
+	return nil
 }
 
-func (cks *Chunks) Append(blockContext, txsPayload []byte, txHashes []common.Hash, rc types.RowConsumption) {
+// Append appends the block to the last chunk, or to a new chunk if it breaks the limits of the last one.
+// In the latter case it returns why the last chunk was closed.
+func (cks *Chunks) Append(blockContext, txsPayload []byte, txHashes []common.Hash, rc types.RowConsumption) *ChunkClosing {
 	if cks == nil {
-		return
+		return nil
 	}
 	defer func() {
 		cks.size += len(blockContext) + len(txsPayload)
@@ -165,17 +183,17 @@ func (cks *Chunks) Append(blockContext, txsPayload []byte, txHashes []common.Has
 	if len(cks.data) == 0 {
 		cks.data = append(cks.data, NewChunk(blockContext, txsPayload, txHashes, rc))
 		cks.size += 1
-		return
+		return nil
 	}
 	lastChunk := cks.data[len(cks.data)-1]
-	accRc, max := lastChunk.accumulateRowUsages(rc)
-	if lastChunk.blockNum+1 > MaxBlocksPerChunk || max > NormalizedRowLimit { // add a new chunk
+	rowUsage, keptRowUsage := cks.rowUsageWith(lastChunk, rc)
+	if closing := cks.closing(lastChunk, txsPayload, rowUsage); closing != nil { // add a new chunk
 		cks.data = append(cks.data, NewChunk(blockContext, txsPayload, txHashes, rc))
 		cks.size += 1
-		return
+		return closing
 	}
-	lastChunk.append(blockContext, txsPayload, txHashes, accRc)
-	return
+	lastChunk.append(blockContext, txsPayload, txHashes, keptRowUsage)
+	return nil
 }
 
 func (cks *Chunks) Encode() ([][]byte, error) {
@@ -204,17 +222,66 @@ func (cks *Chunks) DataHash() common.Hash {
 	return hash
 }
 
+// Chunk returns the i-th chunk.
+func (cks *Chunks) Chunk(i int) *Chunk { return cks.data[i] }
+
 func (cks *Chunks) BlockNum() int { return cks.blockNum }
 func (cks *Chunks) ChunkNum() int { return len(cks.data) }
 func (cks *Chunks) Size() int     { return cks.size }
-func (cks *Chunks) IsChunksAppendedWithNewBlock(blockRc types.RowConsumption) bool {
+
+// CalldataSize returns the size of the chunks in the ABI encoded calldata of commitBatch, their length excluded,
+// in the encoding of the batch version. It is exact for V0. In V1 the closed chunks count their compressed length,
+// while the last chunk, which blocks are still appended to, counts the bound of maxEncodedChunkSize.
+func (cks *Chunks) CalldataSize(version uint8) int {
+	size := 0
+	for i, ck := range cks.data {
+		if i == len(cks.data)-1 {
+			size += calldataChunkSize(ck.maxEncodedSize(version))
+		} else {
+			size += calldataChunkSize(ck.encodedSize(version))
+		}
+	}
+	return size
+}
+
+// CalldataSizeWithBlock returns CalldataSize as if the block was appended, without appending it.
+func (cks *Chunks) CalldataSizeWithBlock(version uint8, blockContext, txsPayload []byte, blockRc types.RowConsumption) int {
 	if len(cks.data) == 0 {
-		return true
+		return calldataChunkSize(maxEncodedChunkSize(version, len(blockContext), len(txsPayload)))
 	}
 	lastChunk := cks.data[len(cks.data)-1]
-	if lastChunk.blockNum+1 > MaxBlocksPerChunk {
+	size := cks.CalldataSize(version) - calldataChunkSize(lastChunk.maxEncodedSize(version))
+	if cks.IsChunksAppendedWithNewBlock(txsPayload, blockRc) {
+		// the block closes the last chunk
+		return size + calldataChunkSize(lastChunk.encodedSize(version)) + calldataChunkSize(maxEncodedChunkSize(version, len(blockContext), len(txsPayload)))
+	}
+	grown := maxEncodedChunkSize(version, len(lastChunk.blockContext)+len(blockContext), len(lastChunk.txsPayload)+len(txsPayload))
+	return size + calldataChunkSize(grown)
+}
+
+// IsChunksAppendedWithNewBlock reports whether the block would open a new chunk.
+func (cks *Chunks) IsChunksAppendedWithNewBlock(txsPayload []byte, blockRc types.RowConsumption) bool {
+	if len(cks.data) == 0 {
 		return true
 	}
-	_, max := lastChunk.accumulateRowUsages(blockRc)
-	return max > NormalizedRowLimit
+	lastChunk := cks.data[len(cks.data)-1]
+	rowUsage, _ := cks.rowUsageWith(lastChunk, blockRc)
+	return cks.closing(lastChunk, txsPayload, rowUsage) != nil
+}
+
+// LastChunkStatus describes the chunk blocks are appended to, nil if there is none.
+func (cks *Chunks) LastChunkStatus() *ChunkStatus {
+	if len(cks.data) == 0 {
+		return nil
+	}
+	lastChunk := cks.data[len(cks.data)-1]
+	circuit, rows := lastChunk.rowUsage.Max()
+	return &ChunkStatus{
+		BlockNum:        lastChunk.blockNum,
+		TxsPayloadBytes: len(lastChunk.txsPayload),
+		RowConsumption:  lastChunk.rowUsage.RowConsumption(),
+		MaxCircuit:      circuit,
+		MaxRowNumber:    rows,
+		Limits:          cks.limits,
+	}
 }
@@ -0,0 +1,119 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/scroll-tech/go-ethereum/core/types"
+)
+
+// ChunkLimits are the rules sealing a chunk: a block that would break any of them opens a new chunk.
+type ChunkLimits struct {
+	// MaxBlocks is the maximum number of blocks in a chunk.
+	MaxBlocks int `json:"max_blocks"`
+	// MaxRowNumber is the maximum number of rows of every sub-circuit in a chunk.
+	MaxRowNumber uint64 `json:"max_row_number"`
+	// MaxTxsPayloadBytes is the maximum size of the L2 transactions payload of a chunk, 0 means no limit.
+	// A block exceeding it on its own gets a chunk of its own.
+	MaxTxsPayloadBytes int `json:"max_txs_payload_bytes"`
+
+	// legacyRowUsage accounts the rows of the chunk the way the heights before the first upgrade did,
+	// see RowUsage.legacyWith.
+	legacyRowUsage bool
+}
+
+func DefaultChunkLimits() ChunkLimits {
+	return ChunkLimits{
+		MaxBlocks:    MaxBlocksPerChunk,
+		MaxRowNumber: NormalizedRowLimit,
+	}
+}
+
+func legacyChunkLimits() ChunkLimits {
+	limits := DefaultChunkLimits()
+	limits.legacyRowUsage = true
+	return limits
+}
+
+func (l ChunkLimits) Validate() error {
+	if l.MaxBlocks <= 0 || l.MaxBlocks > 255 {
+		return fmt.Errorf("max blocks per chunk must be between 1 and 255, got %d", l.MaxBlocks)
+	}
+	if l.MaxRowNumber == 0 {
+		return errors.New("max row number per chunk must be above 0")
+	}
+	if l.MaxTxsPayloadBytes < 0 {
+		return fmt.Errorf("max txs payload bytes per chunk must not be negative, got %d", l.MaxTxsPayloadBytes)
+	}
+	return nil
+}
+
+// ChunkLimitsUpgrade activates the limits from the block Height on.
+type ChunkLimitsUpgrade struct {
+	Height uint64 `json:"height"`
+	ChunkLimits
+}
+
+// ChunkLimitsSchedule tells the limits of every height, so that all the sequencers chunk the blocks identically.
+// Heights before the first upgrade use DefaultChunkLimits, with the legacy accounting of the rows.
+// The rows of every sub-circuit count from the first upgrade on.
+type ChunkLimitsSchedule struct {
+	upgrades []ChunkLimitsUpgrade
+}
+
+func NewChunkLimitsSchedule(upgrades ...ChunkLimitsUpgrade) (*ChunkLimitsSchedule, error) {
+	sorted := append([]ChunkLimitsUpgrade{}, upgrades...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Height < sorted[j].Height })
+	for i, upgrade := range sorted {
+		if err := upgrade.Validate(); err != nil {
+			return nil, fmt.Errorf("chunk limits at height %d: %w", upgrade.Height, err)
+		}
+		if i > 0 && sorted[i-1].Height == upgrade.Height {
+			return nil, fmt.Errorf("duplicated chunk limits at height %d", upgrade.Height)
+		}
+	}
+	return &ChunkLimitsSchedule{upgrades: sorted}, nil
+}
+
+// At returns the limits applying to the block of the given height.
+func (s *ChunkLimitsSchedule) At(height uint64) ChunkLimits {
+	if s == nil {
+		return legacyChunkLimits()
+	}
+	i := sort.Search(len(s.upgrades), func(i int) bool { return s.upgrades[i].Height > height })
+	if i == 0 {
+		return legacyChunkLimits()
+	}
+	return s.upgrades[i-1].ChunkLimits
+}
+
+type ChunkCloseReason string
+
+const (
+	ChunkCloseMaxBlocks          ChunkCloseReason = "max_blocks"
+	ChunkCloseMaxRowNumber       ChunkCloseReason = "max_row_number"
+	ChunkCloseMaxTxsPayloadBytes ChunkCloseReason = "max_txs_payload_bytes"
+)
+
+// ChunkClosing tells why a chunk was closed: the next block broke one of its limits.
+type ChunkClosing struct {
+	Reason ChunkCloseReason `json:"reason"`
+	// Circuit is the limiting sub-circuit of a chunk closed by ChunkCloseMaxRowNumber.
+	Circuit string `json:"circuit,omitempty"`
+	// Value is what the next block would have brought the chunk to, over Limit.
+	Value uint64 `json:"value"`
+	Limit uint64 `json:"limit"`
+	// BlockNum is the number of blocks in the closed chunk.
+	BlockNum int `json:"blockNum"`
+}
+
+// ChunkStatus describes the usage of a chunk against its limits.
+type ChunkStatus struct {
+	BlockNum        int                  `json:"blockNum"`
+	TxsPayloadBytes int                  `json:"txsPayloadBytes"`
+	RowConsumption  types.RowConsumption `json:"rowConsumption"`
+	MaxCircuit      string               `json:"maxCircuit"`
+	MaxRowNumber    uint64               `json:"maxRowNumber"`
+	Limits          ChunkLimits          `json:"limits"`
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChunkLimitsSchedule(t *testing.T) {
+	var nilSchedule *ChunkLimitsSchedule
+	require.Equal(t, legacyChunkLimits(), nilSchedule.At(10))
+
+	limits20 := ChunkLimits{MaxBlocks: 20, MaxRowNumber: 500, MaxTxsPayloadBytes: 1000}
+	limits50 := ChunkLimits{MaxBlocks: 50, MaxRowNumber: 500}
+	schedule, err := NewChunkLimitsSchedule(
+		ChunkLimitsUpgrade{Height: 200, ChunkLimits: limits50},
+		ChunkLimitsUpgrade{Height: 100, ChunkLimits: limits20},
+	)
+	require.NoError(t, err)
+	// the heights before the first upgrade keep the legacy row accounting
+	require.Equal(t, legacyChunkLimits(), schedule.At(0))
+	require.Equal(t, legacyChunkLimits(), schedule.At(99))
+	require.Equal(t, limits20, schedule.At(100))
+	require.Equal(t, limits20, schedule.At(199))
+	require.Equal(t, limits50, schedule.At(200))
+	require.Equal(t, limits50, schedule.At(1<<40))
+
+	_, err = NewChunkLimitsSchedule(ChunkLimitsUpgrade{Height: 1, ChunkLimits: limits20}, ChunkLimitsUpgrade{Height: 1, ChunkLimits: limits50})
+	require.Error(t, err)
+	_, err = NewChunkLimitsSchedule(ChunkLimitsUpgrade{Height: 1, ChunkLimits: ChunkLimits{MaxBlocks: 256, MaxRowNumber: 1}})
+	require.Error(t, err)
+	_, err = NewChunkLimitsSchedule(ChunkLimitsUpgrade{Height: 1, ChunkLimits: ChunkLimits{MaxBlocks: 1}})
+	require.Error(t, err)
+}
+
+func TestChunksRowUsageUpgrade(t *testing.T) {
+	schedule, err := NewChunkLimitsSchedule(ChunkLimitsUpgrade{Height: 100, ChunkLimits: ChunkLimits{MaxBlocks: 10, MaxRowNumber: 100}})
+	require.NoError(t, err)
+	legacy, union := schedule.At(99), schedule.At(100)
+	legacy.MaxBlocks, legacy.MaxRowNumber = 10, 100
+
+	appendBlocks := func(limits ChunkLimits, rcs ...types.RowConsumption) *Chunks {
+		chunks := NewChunks()
+		chunks.SetLimits(limits)
+		for _, rc := range rcs {
+			chunks.Append(nil, nil, nil, rc)
+		}
+		return chunks
+	}
+
+	// a sub-circuit showing up in a later block is dropped before the upgrade, counted from it on
+	rcs := []types.RowConsumption{{{Name: "a", RowNumber: 10}}, {{Name: "b", RowNumber: 60}}, {{Name: "b", RowNumber: 60}}}
+	require.Equal(t, 1, appendBlocks(legacy, rcs...).ChunkNum())
+	chunks := appendBlocks(union, rcs...)
+	require.Equal(t, 2, chunks.ChunkNum())
+	require.EqualValues(t, 60, chunks.LastChunkStatus().MaxRowNumber)
+
+	// before the upgrade a block without row consumption empties the usage, after it fits on top of the usage so far
+	rcs = []types.RowConsumption{{{Name: "a", RowNumber: 60}}, {}, {{Name: "a", RowNumber: 60}}}
+	require.Equal(t, 1, appendBlocks(legacy, rcs...).ChunkNum())
+	require.Equal(t, 2, appendBlocks(union, rcs...).ChunkNum())
+	// the usage so far still decides whether the block without row consumption fits
+	rcs = []types.RowConsumption{{{Name: "a", RowNumber: 60}}, {{Name: "a", RowNumber: 60}}, {}}
+	require.Equal(t, 2, appendBlocks(legacy, rcs...).ChunkNum())
+	require.Equal(t, 2, appendBlocks(union, rcs...).ChunkNum())
+}
+
+func TestChunksLimits(t *testing.T) {
+	rc := func(rows uint64) types.RowConsumption {
+		return types.RowConsumption{{Name: "a", RowNumber: rows}}
+	}
+	payload := func(n int) []byte { return make([]byte, n) }
+
+	chunks := NewChunks()
+	chunks.SetLimits(ChunkLimits{MaxBlocks: 3, MaxRowNumber: 100, MaxTxsPayloadBytes: 50})
+	appendBlock := func(txsPayload []byte, blockRc types.RowConsumption) bool {
+		newChunk := chunks.IsChunksAppendedWithNewBlock(txsPayload, blockRc)
+		chunkNum := chunks.ChunkNum()
+		chunks.Append(testBlockContext(uint64(chunks.BlockNum())), txsPayload, nil, blockRc)
+		require.Equal(t, newChunk, chunks.ChunkNum() == chunkNum+1)
+		return newChunk
+	}
+
+	require.True(t, appendBlock(payload(10), rc(10)))
+	require.False(t, appendBlock(payload(10), rc(10)))
+	require.False(t, appendBlock(payload(10), rc(10)))
+	require.True(t, appendBlock(nil, rc(10)), "max blocks")
+	require.False(t, appendBlock(nil, rc(90)))
+	require.True(t, appendBlock(nil, rc(1)), "max row number")
+	require.False(t, appendBlock(payload(50), rc(1)))
+	require.True(t, appendBlock(payload(1), rc(1)), "max txs payload bytes")
+	require.True(t, appendBlock(payload(80), rc(1)), "a block over the payload limit gets its own chunk")
+	require.True(t, appendBlock(nil, rc(1)))
+
+	// the limits apply to the blocks appended after the change
+	chunks.SetLimits(DefaultChunkLimits())
+	require.False(t, appendBlock(payload(1000), rc(1000)))
+	require.False(t, appendBlock(nil, rc(1000)))
+	require.Equal(t, 6, chunks.ChunkNum())
+	require.Equal(t, 12, chunks.BlockNum())
+}
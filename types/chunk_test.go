@@ -11,7 +11,7 @@ import (
 
 func TestChunks_Append(t *testing.T) {
 	chunks := NewChunks()
-	require.True(t, chunks.IsChunksAppendedWithNewBlock(types.RowConsumption{{"a", 1}}))
+	require.True(t, chunks.IsChunksAppendedWithNewBlock(nil, types.RowConsumption{{"a", 1}}))
 
 	blockContext := []byte("123")
 	txPayloads := []byte("abc")
@@ -61,12 +61,12 @@ func TestChunks_Append(t *testing.T) {
 	}
 	// 99 blocks in 2nd chunk
 	require.EqualValues(t, 2, chunks.ChunkNum())
-	require.False(t, chunks.IsChunksAppendedWithNewBlock(types.RowConsumption{{"a", 1}}))
+	require.False(t, chunks.IsChunksAppendedWithNewBlock(nil, types.RowConsumption{{"a", 1}}))
 	// 100 blocks in 2nd chunk
 	chunks.Append([]byte("11"), nil, nil, types.RowConsumption{{"a", 1}})
 	require.EqualValues(t, 2, chunks.ChunkNum())
 
-	require.True(t, chunks.IsChunksAppendedWithNewBlock(types.RowConsumption{{"a", 1}}))
+	require.True(t, chunks.IsChunksAppendedWithNewBlock(nil, types.RowConsumption{{"a", 1}}))
 	// append chunk to 3 chunks totally
 	chunks.Append([]byte("11"), nil, nil, types.RowConsumption{{"a", 1}})
 	require.EqualValues(t, 3, chunks.ChunkNum())
@@ -76,15 +76,30 @@ func TestChunks_Append(t *testing.T) {
 func TestChunk_accumulateRowUsages(t *testing.T) {
 	chunk := new(Chunk)
 	rc := types.RowConsumption{{"a", 1}}
-	accRc, max := chunk.accumulateRowUsages(rc)
-	require.True(t, equalRc(rc, accRc))
+	accRc := chunk.rowUsage.With(rc)
+	require.True(t, equalRc(rc, accRc.RowConsumption()))
+	_, max := accRc.Max()
 	require.EqualValues(t, 1, max)
 
 	chunk = NewChunk(nil, nil, nil, types.RowConsumption{{"a", 1}, {"b", 2}})
 	rc = types.RowConsumption{{"a", 3}}
-	accRc, max = chunk.accumulateRowUsages(rc)
-	require.True(t, equalRc(types.RowConsumption{{"a", 4}, {"b", 2}}, accRc))
+	accRc = chunk.rowUsage.With(rc)
+	require.True(t, equalRc(types.RowConsumption{{"a", 4}, {"b", 2}}, accRc.RowConsumption()))
+	_, max = accRc.Max()
 	require.EqualValues(t, 4, max)
+	// the chunk keeps its usage
+	require.True(t, equalRc(types.RowConsumption{{"a", 1}, {"b", 2}}, chunk.rowUsage.RowConsumption()))
+
+	// a sub-circuit first used by a later block is accounted
+	accRc = chunk.rowUsage.With(types.RowConsumption{{"c", 5}})
+	require.True(t, equalRc(types.RowConsumption{{"a", 1}, {"b", 2}, {"c", 5}}, accRc.RowConsumption()))
+	name, max := accRc.Max()
+	require.Equal(t, "c", name)
+	require.EqualValues(t, 5, max)
+
+	// a block without row consumption keeps the usage
+	accRc = chunk.rowUsage.With(nil)
+	require.True(t, equalRc(types.RowConsumption{{"a", 1}, {"b", 2}}, accRc.RowConsumption()))
 }
 
 func equalRc(arg0, arg1 types.RowConsumption) bool {
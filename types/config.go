@@ -1,18 +1,108 @@
 package types
 
 import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/morph-l2/node/flags"
 	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/urfave/cli"
 )
 
 var DefaultHomeDir = ".morphnode"
 
+const (
+	// DefaultRetryInitialInterval is the first wait between two attempts of a failed L2 call.
+	DefaultRetryInitialInterval = 500 * time.Millisecond
+
+	// DefaultRetryMaxInterval caps the wait between two attempts of a failed L2 call.
+	DefaultRetryMaxInterval = 10 * time.Second
+
+	// DefaultRetryMaxElapsedTime is how long an L2 call keeps retrying before giving up.
+	DefaultRetryMaxElapsedTime = 5 * time.Minute
+
+	// DefaultBreakerThreshold is the number of consecutive transport failures which opens the circuit breaker.
+	DefaultBreakerThreshold = 5
+
+	// DefaultBreakerCooldown is how long an open circuit breaker rejects calls before probing geth again.
+	DefaultBreakerCooldown = 30 * time.Second
+)
+
+// L1Config lists the L1 providers. Logs, headers and receipts are only accepted
+// once Quorum of the providers agree, a Quorum of 0 means a majority.
 type L1Config struct {
-	Addr          string          `json:"addr"`
+	Addrs         []string        `json:"addrs"`
+	Quorum        int             `json:"quorum"`
 	Confirmations rpc.BlockNumber `json:"confirmations"`
 }
 
+// L2Config lists the geth nodes the node talks to. EthAddrs[i] and EngineAddrs[i] are
+// the eth and engine endpoints of the same geth, the first one is the primary.
 type L2Config struct {
-	EthAddr    string   `json:"eth"`
-	EngineAddr string   `json:"engine"`
-	JwtSecret  [32]byte `json:"jwt_secret"`
+	EthAddrs    []string    `json:"eth"`
+	EngineAddrs []string    `json:"engine"`
+	JwtSecret   [32]byte    `json:"jwt_secret"`
+	Retry       RetryConfig `json:"retry"`
+}
+
+// RetryConfig controls how RetryableClient retries failed calls against L2 geth.
+type RetryConfig struct {
+	InitialInterval time.Duration `json:"initial_interval"`
+	MaxInterval     time.Duration `json:"max_interval"`
+	// MaxElapsedTime of 0 retries until the call context is done.
+	MaxElapsedTime time.Duration `json:"max_elapsed_time"`
+	// BreakerThreshold of 0 disables the circuit breaker.
+	BreakerThreshold int           `json:"breaker_threshold"`
+	BreakerCooldown  time.Duration `json:"breaker_cooldown"`
+}
+
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		InitialInterval:  DefaultRetryInitialInterval,
+		MaxInterval:      DefaultRetryMaxInterval,
+		MaxElapsedTime:   DefaultRetryMaxElapsedTime,
+		BreakerThreshold: DefaultBreakerThreshold,
+		BreakerCooldown:  DefaultBreakerCooldown,
+	}
+}
+
+// SetCliContext overrides the retry config with the l2.retry and l2.breaker flags which are set.
+func (c *RetryConfig) SetCliContext(ctx *cli.Context) error {
+	if ctx.GlobalIsSet(flags.L2RetryMaxInterval.Name) {
+		c.MaxInterval = ctx.GlobalDuration(flags.L2RetryMaxInterval.Name)
+		if c.MaxInterval == 0 {
+			return errors.New("invalid l2 retry maxInterval")
+		}
+	}
+	if ctx.GlobalIsSet(flags.L2RetryMaxElapsedTime.Name) {
+		c.MaxElapsedTime = ctx.GlobalDuration(flags.L2RetryMaxElapsedTime.Name)
+	}
+	if ctx.GlobalIsSet(flags.L2BreakerThreshold.Name) {
+		c.BreakerThreshold = ctx.GlobalInt(flags.L2BreakerThreshold.Name)
+		if c.BreakerThreshold < 0 {
+			return errors.New("invalid l2 breaker threshold")
+		}
+	}
+	if ctx.GlobalIsSet(flags.L2BreakerCooldown.Name) {
+		c.BreakerCooldown = ctx.GlobalDuration(flags.L2BreakerCooldown.Name)
+	}
+	return nil
+}
+
+func DefaultL2Config() *L2Config {
+	return &L2Config{
+		Retry: DefaultRetryConfig(),
+	}
+}
+
+// SplitAddrs splits a comma separated list of endpoint addresses, dropping empty entries.
+func SplitAddrs(addrs string) []string {
+	var ret []string
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			ret = append(ret, addr)
+		}
+	}
+	return ret
 }
@@ -28,4 +28,8 @@ var (
 	ErrMemoryDBNotFound = errors.New("not found")
 
 	ErrNotFromCrossDomainMessenger = errors.New("the cross message is not sent by L1CrossDomainMessenger")
+
+	// ErrL2Unavailable is returned by RetryableClient when a call gave up while the circuit breakers were open,
+	// i.e. geth failed to answer too many times in a row and did not recover within the retry budget of the call.
+	ErrL2Unavailable = errors.New("l2 geth is unavailable")
 )
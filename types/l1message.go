@@ -8,6 +8,10 @@ import (
 type L1Message struct {
 	types.L1MessageTx
 	L1TxHash common.Hash
+	// L1BlockTime is the timestamp of the L1 block which emitted the message, if known.
+	// It is local bookkeeping, left out of the RLP encoding of the message, which is part of the block meta.
+	// The node database persists it next to the message through its own wrapper, db.storedL1Message.
+	L1BlockTime uint64 `rlp:"-" json:"-"`
 }
 
 type L1MessageReader interface {
@@ -15,3 +19,18 @@ type L1MessageReader interface {
 	ReadL1MessagesInRange(start, end uint64) []L1Message
 	LatestSynced() uint64
 }
+
+// QuarantinedL1Event is an L1 event which could not be turned into an L1 message,
+// kept with the raw log and the decode error so that operators can investigate it.
+type QuarantinedL1Event struct {
+	BlockNumber uint64         `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	LogIndex    uint64         `json:"logIndex"`
+	Address     common.Address `json:"address"`
+	Topics      []common.Hash  `json:"topics"`
+	Data        []byte         `json:"data"`
+	Error       string         `json:"error"`
+	// QuarantinedAt is the unix time the event was quarantined first.
+	QuarantinedAt uint64 `json:"quarantinedAt"`
+}
@@ -2,191 +2,494 @@ package types
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
 	"math/big"
-	"strings"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/scroll-tech/go-ethereum"
+	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
 	"github.com/scroll-tech/go-ethereum/common"
 	eth "github.com/scroll-tech/go-ethereum/core/types"
 	"github.com/scroll-tech/go-ethereum/eth/catalyst"
 	"github.com/scroll-tech/go-ethereum/ethclient"
 	"github.com/scroll-tech/go-ethereum/ethclient/authclient"
+	"github.com/scroll-tech/go-ethereum/ethclient/gethclient"
+	"github.com/scroll-tech/go-ethereum/rpc"
 	tmlog "github.com/tendermint/tendermint/libs/log"
 )
 
+// MinerClosed is the message geth answers with while its miner is shutting down or restarting.
+// It carries no error code, so it can only be recognized by its message.
+const MinerClosed = "miner closed"
+
+// ErrorClass tells RetryableClient what to do with an error returned by geth.
+type ErrorClass int
+
 const (
-	ConnectionRefused = "connection refused"
-	EOFError          = "EOF"
-	JWTStaleToken     = "stale token"
-	JWTExpiredToken   = "token is expired"
-	MinerClosed       = "miner closed"
+	// ErrorClassPermanent errors are returned to the caller as they are, retrying will not help.
+	ErrorClassPermanent ErrorClass = iota
+	// ErrorClassTransient errors come from a reachable geth which could not serve the call for now,
+	// e.g. a stale jwt token or a restarting miner. They are retried.
+	ErrorClassTransient
+	// ErrorClassUnreachable errors mean the call never made it to geth. They are retried and
+	// count against the circuit breaker.
+	ErrorClassUnreachable
 )
 
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorClassPermanent:
+		return "permanent"
+	case ErrorClassTransient:
+		return "transient"
+	case ErrorClassUnreachable:
+		return "unreachable"
+	}
+	return "unknown"
+}
+
+// ClassifyError inspects the error chain of err and returns its ErrorClass.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return ErrorClassPermanent
+	}
+	// the caller gave up, never retry on its behalf
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrorClassPermanent
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return ErrorClassUnreachable
+	}
+	var httpErr rpc.HTTPError
+	if errors.As(err, &httpErr) {
+		switch httpErr.StatusCode {
+		case http.StatusUnauthorized:
+			// jwt token is stale or expired, the next attempt is signed with a fresh one
+			return ErrorClassTransient
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return ErrorClassUnreachable
+		}
+		return ErrorClassPermanent
+	}
+	var rpcErr rpc.Error
+	if errors.As(err, &rpcErr) {
+		if rpcErr.Error() == MinerClosed {
+			return ErrorClassTransient
+		}
+		return ErrorClassPermanent
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrorClassUnreachable
+	}
+	return ErrorClassPermanent
+}
+
+type engineClient interface {
+	AssembleL2Block(ctx context.Context, number *big.Int, transactions eth.Transactions) (*catalyst.ExecutableL2Data, error)
+	ValidateL2Block(ctx context.Context, executableL2Data *catalyst.ExecutableL2Data, collectedL1Messages []eth.L1MessageTx) (bool, error)
+	NewL2Block(ctx context.Context, executableL2Data *catalyst.ExecutableL2Data, collectedL1Messages []eth.L1MessageTx, batchHash *common.Hash) error
+	NewSafeL2Block(ctx context.Context, safeL2Data *catalyst.SafeL2Data) (*eth.Header, error)
+	CommitBatch(ctx context.Context, batch *eth.RollupBatch, signatures []eth.BatchSignature) error
+	AppendBlsSignature(ctx context.Context, batchHash common.Hash, signature eth.BatchSignature) error
+}
+
+type ethReader interface {
+	bind.ContractCaller
+	BlockNumber(ctx context.Context) (uint64, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*eth.Header, error)
+}
+
+type proofReader interface {
+	GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error)
+}
+
+// L2Endpoint is one geth node, reached through its engine and eth namespaces.
+// GethClient serves the account proofs, it is optional.
+type L2Endpoint struct {
+	AuthClient *authclient.Client
+	EthClient  *ethclient.Client
+	GethClient *gethclient.Client
+}
+
+type l2Endpoint struct {
+	index       int
+	authClient  engineClient
+	ethClient   ethReader
+	proofClient proofReader
+	breaker     *circuitBreaker
+}
+
+// headInfo is the latest block the primary endpoint is known to have.
+type headInfo struct {
+	number uint64
+	hash   common.Hash
+}
+
 type RetryableClient struct {
-	authClient *authclient.Client
-	ethClient  *ethclient.Client
-	b          backoff.BackOff
-	logger     tmlog.Logger
+	endpoints []*l2Endpoint
+
+	mu       sync.Mutex
+	primary  int
+	lastHead *headInfo
+
+	cfg    RetryConfig
+	logger tmlog.Logger
+}
+
+// DialRetryableClient dials every geth node of cfg and wraps them into a RetryableClient.
+// The i-th engine address and the i-th eth address must belong to the same geth node,
+// the first pair is the primary endpoint.
+func DialRetryableClient(ctx context.Context, cfg *L2Config, logger tmlog.Logger) (*RetryableClient, error) {
+	if len(cfg.EngineAddrs) == 0 {
+		return nil, errors.New("no l2 engine address configured")
+	}
+	if len(cfg.EngineAddrs) != len(cfg.EthAddrs) {
+		return nil, fmt.Errorf("l2 engine addresses(%d) and eth addresses(%d) do not pair up", len(cfg.EngineAddrs), len(cfg.EthAddrs))
+	}
+	endpoints := make([]L2Endpoint, len(cfg.EngineAddrs))
+	for i := range cfg.EngineAddrs {
+		aClient, err := authclient.DialContext(ctx, cfg.EngineAddrs[i], cfg.JwtSecret)
+		if err != nil {
+			return nil, err
+		}
+		rpcClient, err := rpc.DialContext(ctx, cfg.EthAddrs[i])
+		if err != nil {
+			return nil, err
+		}
+		endpoints[i] = L2Endpoint{AuthClient: aClient, EthClient: ethclient.NewClient(rpcClient), GethClient: gethclient.New(rpcClient)}
+	}
+	return NewRetryableClient(endpoints, cfg.Retry, logger), nil
 }
 
 // NewRetryableClient make the client retryable
-// Will retry calling the api, if the connection is refused
-func NewRetryableClient(authClient *authclient.Client, ethClient *ethclient.Client, logger tmlog.Logger) *RetryableClient {
+// Will retry calling the api with an exponential backoff, if geth is unreachable or temporarily unable to serve.
+// Each call owns its backoff, which is bounded by cfg and by the context of the call.
+//
+// Engine calls stick to the primary endpoint, which is endpoints[0] at start. Once the circuit breaker
+// of the primary opens, a replica is promoted if it passes a health check and agrees with the
+// latest head known from the primary. Read calls fail over through all endpoints, primary first.
+//
+// An open circuit breaker does not fail the calls: the consensus cannot skip a block because geth restarts.
+// The calls wait within their own backoff instead, without reaching geth, until the breaker lets a probe
+// through or a replica is promoted. They fail with ErrL2Unavailable once their backoff gives up.
+func NewRetryableClient(endpoints []L2Endpoint, cfg RetryConfig, logger tmlog.Logger) *RetryableClient {
+	eps := make([]*l2Endpoint, len(endpoints))
+	for i, ep := range endpoints {
+		eps[i] = &l2Endpoint{authClient: ep.AuthClient, ethClient: ep.EthClient}
+		if ep.GethClient != nil {
+			eps[i].proofClient = ep.GethClient
+		}
+	}
+	return newRetryableClient(eps, cfg, logger)
+}
+
+func newRetryableClient(endpoints []*l2Endpoint, cfg RetryConfig, logger tmlog.Logger) *RetryableClient {
 	logger = logger.With("module", "retryClient")
+	if cfg.InitialInterval == 0 {
+		cfg.InitialInterval = DefaultRetryInitialInterval
+	}
+	if cfg.MaxInterval == 0 {
+		cfg.MaxInterval = DefaultRetryMaxInterval
+	}
+	for i, ep := range endpoints {
+		ep.index = i
+		ep.breaker = newCircuitBreaker(cfg.BreakerThreshold, cfg.BreakerCooldown)
+	}
 	return &RetryableClient{
-		authClient: authClient,
-		ethClient:  ethClient,
-		b:          backoff.NewExponentialBackOff(),
-		logger:     logger,
+		endpoints: endpoints,
+		cfg:       cfg,
+		logger:    logger,
 	}
 }
 
-func (rc *RetryableClient) AssembleL2Block(ctx context.Context, number *big.Int, transactions eth.Transactions) (ret *catalyst.ExecutableL2Data, err error) {
-	if retryErr := backoff.Retry(func() error {
-		resp, respErr := rc.authClient.AssembleL2Block(ctx, number, transactions)
-		if respErr != nil {
-			rc.logger.Info("failed to AssembleL2Block", "error", respErr)
-			if retryableError(respErr) {
-				return respErr
-			}
-			err = respErr // stop retrying and put this error to response error field, if the error is not connection related
+// Available reports whether geth is considered reachable, i.e. the circuit breaker of the primary endpoint is not open.
+func (rc *RetryableClient) Available() bool {
+	return rc.primaryEndpoint().breaker.currentState() != breakerOpen
+}
+
+// Health returns ErrL2Unavailable while the circuit breaker of the primary endpoint is open, nil otherwise.
+func (rc *RetryableClient) Health() error {
+	if !rc.Available() {
+		return ErrL2Unavailable
+	}
+	return nil
+}
+
+// Primary returns the index of the endpoint engine calls are currently sent to.
+func (rc *RetryableClient) Primary() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.primary
+}
+
+func (rc *RetryableClient) primaryEndpoint() *l2Endpoint {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.endpoints[rc.primary]
+}
+
+// recordHead remembers the latest block the primary endpoint is known to have,
+// it is the reference a replica has to agree with before being promoted.
+func (rc *RetryableClient) recordHead(ep *l2Endpoint, number uint64, hash common.Hash) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.endpoints[rc.primary] != ep {
+		return
+	}
+	if rc.lastHead == nil || number >= rc.lastHead.number {
+		rc.lastHead = &headInfo{number: number, hash: hash}
+	}
+}
+
+// promote replaces the failed primary with the first replica which is healthy and consistent with
+// the latest known head of the primary. It returns nil if no replica qualifies.
+// The replicas are probed without holding the lock, the other calls go on meanwhile.
+func (rc *RetryableClient) promote(ctx context.Context, failed *l2Endpoint) *l2Endpoint {
+	rc.mu.Lock()
+	if current := rc.endpoints[rc.primary]; current != failed {
+		rc.mu.Unlock()
+		// promoted by a concurrent call already
+		return current
+	}
+	if rc.lastHead == nil {
+		rc.mu.Unlock()
+		rc.logger.Error("primary l2 endpoint is unavailable, but no head is known to check replicas against", "primary", failed.index)
+		return nil
+	}
+	head := *rc.lastHead
+	rc.mu.Unlock()
+
+	for _, ep := range rc.endpoints {
+		if ep == failed || ep.breaker.currentState() == breakerOpen {
+			continue
+		}
+		if err := rc.checkReplica(ctx, ep, &head); err != nil {
+			rc.logger.Info("replica is not eligible for promotion", "endpoint", ep.index, "error", err)
+			continue
 		}
-		ret = resp
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		if current := rc.endpoints[rc.primary]; current != failed {
+			return current
+		}
+		if *rc.lastHead != head {
+			// the primary answered meanwhile, the replica is checked again by the next attempt of the call
+			return nil
+		}
+		rc.logger.Error("promoted l2 replica to primary", "previous", failed.index, "primary", ep.index,
+			"headNumber", head.number, "headHash", head.hash)
+		rc.primary = ep.index
+		return ep
+	}
+	return nil
+}
+
+// checkReplica runs the health check of a replica and makes sure it did not fork away from the primary:
+// the replica must have the latest known block of the primary, with the same hash.
+func (rc *RetryableClient) checkReplica(ctx context.Context, ep *l2Endpoint, head *headInfo) error {
+	number, err := ep.ethClient.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	if number < head.number {
+		return fmt.Errorf("replica is behind, head %d, want at least %d", number, head.number)
+	}
+	header, err := ep.ethClient.HeaderByNumber(ctx, new(big.Int).SetUint64(head.number))
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	if header.Hash() != head.hash {
+		return fmt.Errorf("replica is inconsistent at height %d, hash %s, want %s", head.number, header.Hash(), head.hash)
+	}
+	return nil
+}
+
+func (rc *RetryableClient) newBackOff(ctx context.Context) backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = rc.cfg.InitialInterval
+	b.MaxInterval = rc.cfg.MaxInterval
+	b.MaxElapsedTime = rc.cfg.MaxElapsedTime
+	b.Reset()
+	return backoff.WithContext(b, ctx)
+}
+
+// call runs op once against ep and feeds the outcome into the circuit breaker of ep.
+// The returned error is marked as permanent if retrying will not help.
+func (rc *RetryableClient) call(ctx context.Context, ep *l2Endpoint, method string, op func(ep *l2Endpoint) error) error {
+	err := op(ep)
+	if err == nil {
+		ep.breaker.onSuccess()
 		return nil
-	}, rc.b); retryErr != nil {
-		return nil, retryErr
 	}
-	return
+	rc.logger.Info(fmt.Sprintf("failed to call %s", method), "endpoint", ep.index, "error", err)
+	switch ClassifyError(err) {
+	case ErrorClassUnreachable:
+		if ep.breaker.onFailure() {
+			rc.logger.Error("circuit breaker opened, l2 geth marked as unavailable", "endpoint", ep.index, "method", method, "error", err)
+		}
+		return err
+	case ErrorClassTransient:
+		ep.breaker.onSuccess()
+		return err
+	default:
+		if ctx.Err() != nil {
+			ep.breaker.release()
+		} else {
+			ep.breaker.onSuccess()
+		}
+		return backoff.Permanent(err)
+	}
 }
 
-func (rc *RetryableClient) ValidateL2Block(ctx context.Context, executableL2Data *catalyst.ExecutableL2Data, l1Txs []eth.L1MessageTx) (ret bool, err error) {
-	if retryErr := backoff.Retry(func() error {
-		resp, respErr := rc.authClient.ValidateL2Block(ctx, executableL2Data, l1Txs)
-		if respErr != nil {
-			rc.logger.Info("failed to ValidateL2Block", "error", respErr)
-			if retryableError(respErr) {
-				return respErr
+// retryEngine calls op against the primary endpoint until it succeeds, fails with a permanent error,
+// the backoff gives up or ctx is done.
+func (rc *RetryableClient) retryEngine(ctx context.Context, method string, op func(ep *l2Endpoint) error) error {
+	return backoff.Retry(func() error {
+		ep := rc.primaryEndpoint()
+		if !ep.breaker.allow() {
+			if ep = rc.promote(ctx, ep); ep == nil || !ep.breaker.allow() {
+				// wait for the cooldown of the breaker, or for a replica to catch up
+				return fmt.Errorf("%s: %w", method, ErrL2Unavailable)
 			}
-			err = respErr
 		}
-		ret = resp
-		return nil
-	}, rc.b); retryErr != nil {
-		return false, retryErr
-	}
+		return rc.call(ctx, ep, method, op)
+	}, rc.newBackOff(ctx))
+}
+
+// retryRead is like retryEngine, but an attempt fails over through all endpoints, primary first.
+func (rc *RetryableClient) retryRead(ctx context.Context, method string, op func(ep *l2Endpoint) error) error {
+	return backoff.Retry(func() error {
+		rc.mu.Lock()
+		primary := rc.primary
+		rc.mu.Unlock()
+
+		var lastErr error
+		for i := range rc.endpoints {
+			ep := rc.endpoints[(primary+i)%len(rc.endpoints)]
+			if !ep.breaker.allow() {
+				continue
+			}
+			err := rc.call(ctx, ep, method, op)
+			var permanent *backoff.PermanentError
+			if err == nil || errors.As(err, &permanent) {
+				return err
+			}
+			lastErr = err
+		}
+		if lastErr == nil {
+			// every breaker is open, wait for the cooldown of one of them
+			return fmt.Errorf("%s: %w", method, ErrL2Unavailable)
+		}
+		return lastErr
+	}, rc.newBackOff(ctx))
+}
+
+func (rc *RetryableClient) AssembleL2Block(ctx context.Context, number *big.Int, transactions eth.Transactions) (ret *catalyst.ExecutableL2Data, err error) {
+	err = rc.retryEngine(ctx, "AssembleL2Block", func(ep *l2Endpoint) (respErr error) {
+		ret, respErr = ep.authClient.AssembleL2Block(ctx, number, transactions)
+		return
+	})
+	return
+}
+
+func (rc *RetryableClient) ValidateL2Block(ctx context.Context, executableL2Data *catalyst.ExecutableL2Data, l1Txs []eth.L1MessageTx) (ret bool, err error) {
+	err = rc.retryEngine(ctx, "ValidateL2Block", func(ep *l2Endpoint) (respErr error) {
+		ret, respErr = ep.authClient.ValidateL2Block(ctx, executableL2Data, l1Txs)
+		return
+	})
 	return
 }
 
 func (rc *RetryableClient) NewL2Block(ctx context.Context, executableL2Data *catalyst.ExecutableL2Data, batchHash *common.Hash, l1Txs []eth.L1MessageTx) (err error) {
-	if retryErr := backoff.Retry(func() error {
-		respErr := rc.authClient.NewL2Block(ctx, executableL2Data, l1Txs, batchHash)
-		if respErr != nil {
-			rc.logger.Info("failed to NewL2Block", "error", respErr)
-			if retryableError(respErr) {
-				return respErr
-			}
-			err = respErr
+	return rc.retryEngine(ctx, "NewL2Block", func(ep *l2Endpoint) error {
+		if err := ep.authClient.NewL2Block(ctx, executableL2Data, l1Txs, batchHash); err != nil {
+			return err
 		}
+		rc.recordHead(ep, executableL2Data.Number, executableL2Data.Hash)
 		return nil
-	}, rc.b); retryErr != nil {
-		return retryErr
-	}
-	return
+	})
 }
 
 func (rc *RetryableClient) NewSafeL2Block(ctx context.Context, safeL2Data *catalyst.SafeL2Data) (ret *eth.Header, err error) {
-	if retryErr := backoff.Retry(func() error {
-		resp, respErr := rc.authClient.NewSafeL2Block(ctx, safeL2Data)
-		if respErr != nil {
-			rc.logger.Info("failed to NewSafeL2Block", "error", respErr)
-			if retryableError(respErr) {
-				return respErr
-			}
-			err = respErr
+	err = rc.retryEngine(ctx, "NewSafeL2Block", func(ep *l2Endpoint) (respErr error) {
+		if ret, respErr = ep.authClient.NewSafeL2Block(ctx, safeL2Data); respErr == nil && ret != nil {
+			rc.recordHead(ep, ret.Number.Uint64(), ret.Hash())
 		}
-		ret = resp
-		return nil
-	}, rc.b); retryErr != nil {
-		return nil, retryErr
-	}
+		return
+	})
 	return
 }
 
 func (rc *RetryableClient) CommitBatch(ctx context.Context, batch *eth.RollupBatch, signatures []eth.BatchSignature) (err error) {
-	if retryErr := backoff.Retry(func() error {
-		respErr := rc.authClient.CommitBatch(ctx, batch, signatures)
-		if respErr != nil {
-			rc.logger.Info("failed to CommitBatch", "error", respErr)
-			if retryableError(respErr) {
-				return respErr
-			}
-			err = respErr
-		}
-		return nil
-	}, rc.b); retryErr != nil {
-		return retryErr
-	}
-	return
+	return rc.retryEngine(ctx, "CommitBatch", func(ep *l2Endpoint) error {
+		return ep.authClient.CommitBatch(ctx, batch, signatures)
+	})
 }
 
 func (rc *RetryableClient) AppendBlsSignature(ctx context.Context, batchHash common.Hash, signature eth.BatchSignature) (err error) {
-	if retryErr := backoff.Retry(func() error {
-		respErr := rc.authClient.AppendBlsSignature(ctx, batchHash, signature)
-		if respErr != nil {
-			rc.logger.Info("failed to call AppendBlsSignature", "error", respErr)
-			if retryableError(respErr) {
-				return respErr
-			}
-			err = respErr
-		}
-		return nil
-	}, rc.b); retryErr != nil {
-		return retryErr
-	}
+	return rc.retryEngine(ctx, "AppendBlsSignature", func(ep *l2Endpoint) error {
+		return ep.authClient.AppendBlsSignature(ctx, batchHash, signature)
+	})
+}
+
+// CodeAt and CallContract make the client a bind.ContractCaller: the contract reads fail over like the other reads.
+func (rc *RetryableClient) CodeAt(ctx context.Context, contract common.Address, blockNumber *big.Int) (ret []byte, err error) {
+	err = rc.retryRead(ctx, "CodeAt", func(ep *l2Endpoint) (respErr error) {
+		ret, respErr = ep.ethClient.CodeAt(ctx, contract, blockNumber)
+		return
+	})
 	return
 }
 
-func (rc *RetryableClient) BlockNumber(ctx context.Context) (ret uint64, err error) {
-	if retryErr := backoff.Retry(func() error {
-		resp, respErr := rc.ethClient.BlockNumber(ctx)
-		if respErr != nil {
-			rc.logger.Info("failed to call BlockNumber", "error", respErr)
-			if retryableError(respErr) {
-				return respErr
-			}
-			err = respErr
-		}
-		ret = resp
-		return nil
-	}, rc.b); retryErr != nil {
-		return 0, retryErr
-	}
+func (rc *RetryableClient) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) (ret []byte, err error) {
+	err = rc.retryRead(ctx, "CallContract", func(ep *l2Endpoint) (respErr error) {
+		ret, respErr = ep.ethClient.CallContract(ctx, call, blockNumber)
+		return
+	})
 	return
 }
 
-func (rc *RetryableClient) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (ret *eth.Header, err error) {
-	if retryErr := backoff.Retry(func() error {
-		resp, respErr := rc.ethClient.HeaderByNumber(ctx, blockNumber)
+// StorageRoot returns the storage root of the account at the block, from the account proof.
+func (rc *RetryableClient) StorageRoot(ctx context.Context, account common.Address, blockNumber *big.Int) (ret common.Hash, err error) {
+	err = rc.retryRead(ctx, "StorageRoot", func(ep *l2Endpoint) error {
+		if ep.proofClient == nil {
+			return errors.New("the endpoint serves no account proofs")
+		}
+		proof, respErr := ep.proofClient.GetProof(ctx, account, nil, blockNumber)
 		if respErr != nil {
-			rc.logger.Info("failed to call BlockNumber", "error", respErr)
-			if retryableError(respErr) {
-				return respErr
-			}
-			err = respErr
+			return respErr
 		}
-		ret = resp
+		ret = proof.StorageHash
 		return nil
-	}, rc.b); retryErr != nil {
-		return nil, retryErr
-	}
+	})
 	return
 }
 
-func retryableError(err error) bool {
-	return strings.Contains(err.Error(), ConnectionRefused) ||
-		strings.Contains(err.Error(), EOFError) ||
-		strings.Contains(err.Error(), JWTStaleToken) ||
-		strings.Contains(err.Error(), JWTExpiredToken) ||
-		strings.Contains(err.Error(), MinerClosed)
+func (rc *RetryableClient) BlockNumber(ctx context.Context) (ret uint64, err error) {
+	err = rc.retryRead(ctx, "BlockNumber", func(ep *l2Endpoint) (respErr error) {
+		ret, respErr = ep.ethClient.BlockNumber(ctx)
+		return
+	})
+	return
+}
 
+func (rc *RetryableClient) HeaderByNumber(ctx context.Context, blockNumber *big.Int) (ret *eth.Header, err error) {
+	err = rc.retryRead(ctx, "HeaderByNumber", func(ep *l2Endpoint) (respErr error) {
+		if ret, respErr = ep.ethClient.HeaderByNumber(ctx, blockNumber); respErr == nil && ret != nil && blockNumber == nil {
+			rc.recordHead(ep, ret.Number.Uint64(), ret.Hash())
+		}
+		return
+	})
+	return
 }
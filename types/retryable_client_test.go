@@ -0,0 +1,387 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/scroll-tech/go-ethereum"
+	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
+	"github.com/scroll-tech/go-ethereum/common"
+	eth "github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/scroll-tech/go-ethereum/eth/catalyst"
+	"github.com/scroll-tech/go-ethereum/ethclient/gethclient"
+	"github.com/scroll-tech/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+	tmlog "github.com/tendermint/tendermint/libs/log"
+)
+
+type testRPCError struct {
+	msg  string
+	code int
+}
+
+func (e testRPCError) Error() string  { return e.msg }
+func (e testRPCError) ErrorCode() int { return e.code }
+
+func testRetryableClient(threshold int, endpoints ...*l2Endpoint) *RetryableClient {
+	if len(endpoints) == 0 {
+		endpoints = []*l2Endpoint{{}}
+	}
+	return newRetryableClient(endpoints, RetryConfig{
+		InitialInterval:  time.Millisecond,
+		MaxInterval:      time.Millisecond,
+		MaxElapsedTime:   time.Second,
+		BreakerThreshold: threshold,
+		BreakerCooldown:  time.Hour,
+	}, tmlog.NewNopLogger())
+}
+
+func TestClassifyError(t *testing.T) {
+	for _, tc := range []struct {
+		err   error
+		class ErrorClass
+	}{
+		{fmt.Errorf("dial: %w", syscall.ECONNREFUSED), ErrorClassUnreachable},
+		{fmt.Errorf("read: %w", io.EOF), ErrorClassUnreachable},
+		{rpc.HTTPError{StatusCode: http.StatusServiceUnavailable}, ErrorClassUnreachable},
+		{rpc.HTTPError{StatusCode: http.StatusUnauthorized, Body: []byte("stale token")}, ErrorClassTransient},
+		{testRPCError{msg: MinerClosed, code: -32000}, ErrorClassTransient},
+		{testRPCError{msg: "invalid block", code: -32000}, ErrorClassPermanent},
+		{fmt.Errorf("call: %w", context.Canceled), ErrorClassPermanent},
+		{errors.New("something else"), ErrorClassPermanent},
+	} {
+		require.Equal(t, tc.class, ClassifyError(tc.err), tc.err.Error())
+	}
+}
+
+func TestRetryableClient_Retry(t *testing.T) {
+	rc := testRetryableClient(0)
+
+	calls := 0
+	err := rc.retryEngine(context.Background(), "test", func(*l2Endpoint) error {
+		calls++
+		if calls < 3 {
+			return syscall.ECONNREFUSED
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, calls)
+
+	calls = 0
+	permanent := testRPCError{msg: "invalid block", code: -32000}
+	err = rc.retryEngine(context.Background(), "test", func(*l2Endpoint) error {
+		calls++
+		return permanent
+	})
+	require.Equal(t, permanent, err)
+	require.Equal(t, 1, calls)
+}
+
+func TestRetryableClient_RetryContextCancelled(t *testing.T) {
+	rc := testRetryableClient(0)
+	rc.cfg.MaxElapsedTime = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := rc.retryEngine(ctx, "test", func(*l2Endpoint) error {
+		calls++
+		if calls == 2 {
+			cancel()
+		}
+		return syscall.ECONNREFUSED
+	})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRetryableClient_CircuitBreaker(t *testing.T) {
+	rc := testRetryableClient(3)
+	rc.cfg.MaxElapsedTime = 20 * time.Millisecond
+	now := time.Now()
+	rc.endpoints[0].breaker.now = func() time.Time { return now }
+
+	calls := 0
+	err := rc.retryEngine(context.Background(), "test", func(*l2Endpoint) error {
+		calls++
+		return syscall.ECONNREFUSED
+	})
+	require.ErrorIs(t, err, ErrL2Unavailable)
+	require.Equal(t, 3, calls)
+	require.False(t, rc.Available())
+	require.ErrorIs(t, rc.Health(), ErrL2Unavailable)
+
+	// held back without calling geth while open, until the retry budget runs out
+	err = rc.retryEngine(context.Background(), "test", func(*l2Endpoint) error {
+		calls++
+		return nil
+	})
+	require.ErrorIs(t, err, ErrL2Unavailable)
+	require.Equal(t, 3, calls)
+
+	// a failing probe after the cooldown opens the breaker again
+	now = now.Add(time.Hour)
+	err = rc.retryEngine(context.Background(), "test", func(*l2Endpoint) error {
+		calls++
+		return syscall.ECONNREFUSED
+	})
+	require.ErrorIs(t, err, ErrL2Unavailable)
+	require.Equal(t, 4, calls)
+	require.False(t, rc.Available())
+
+	// a successful probe closes it
+	now = now.Add(time.Hour)
+	err = rc.retryEngine(context.Background(), "test", func(*l2Endpoint) error {
+		calls++
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, rc.Available())
+	require.NoError(t, rc.Health())
+}
+
+func TestRetryableClient_BreakerHoldsCalls(t *testing.T) {
+	geth := newFakeGeth(testHeader(10, "a"))
+	rc := testRetryableClient(2, geth.endpoint())
+	rc.endpoints[0].breaker.cooldown = 20 * time.Millisecond
+
+	// geth restarts: the breaker opens, and the held back calls go through once it is back
+	geth.upAt = time.Now().Add(50 * time.Millisecond)
+	require.NoError(t, rc.NewL2Block(context.Background(), &catalyst.ExecutableL2Data{Number: 11}, nil, nil))
+	require.Equal(t, 1, geth.newL2Blocks)
+	require.True(t, rc.Available())
+
+	// so do the reads, when every breaker is open
+	geth.upAt = time.Now().Add(50 * time.Millisecond)
+	number, err := rc.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 10, number)
+
+	// the calls give up with the retry budget
+	rc.cfg.MaxElapsedTime = 20 * time.Millisecond
+	geth.down = true
+	_, err = rc.BlockNumber(context.Background())
+	require.ErrorIs(t, err, ErrL2Unavailable)
+	require.False(t, rc.Available())
+}
+
+// fakeGeth serves the eth reads and NewL2Block of a geth node.
+type fakeGeth struct {
+	engineClient
+	bind.ContractCaller
+	down bool
+	// the node is down until upAt as well
+	upAt        time.Time
+	headers     map[uint64]*eth.Header
+	newL2Blocks int
+	// code is the code of every contract, and the result of every contract call
+	code []byte
+	// storageRoot is the storage root of every account
+	storageRoot common.Hash
+	// onBlockNumber is called before BlockNumber answers, if set
+	onBlockNumber func()
+}
+
+func newFakeGeth(headers ...*eth.Header) *fakeGeth {
+	g := &fakeGeth{headers: make(map[uint64]*eth.Header)}
+	for _, h := range headers {
+		g.headers[h.Number.Uint64()] = h
+	}
+	return g
+}
+
+func (g *fakeGeth) isDown() bool {
+	return g.down || time.Now().Before(g.upAt)
+}
+
+func (g *fakeGeth) endpoint() *l2Endpoint {
+	return &l2Endpoint{authClient: g, ethClient: g, proofClient: g}
+}
+
+func (g *fakeGeth) head() uint64 {
+	var head uint64
+	for number := range g.headers {
+		if number > head {
+			head = number
+		}
+	}
+	return head
+}
+
+func (g *fakeGeth) NewL2Block(context.Context, *catalyst.ExecutableL2Data, []eth.L1MessageTx, *common.Hash) error {
+	if g.isDown() {
+		return syscall.ECONNREFUSED
+	}
+	g.newL2Blocks++
+	return nil
+}
+
+func (g *fakeGeth) BlockNumber(context.Context) (uint64, error) {
+	if g.onBlockNumber != nil {
+		g.onBlockNumber()
+	}
+	if g.isDown() {
+		return 0, syscall.ECONNREFUSED
+	}
+	return g.head(), nil
+}
+
+func (g *fakeGeth) CallContract(context.Context, ethereum.CallMsg, *big.Int) ([]byte, error) {
+	if g.isDown() {
+		return nil, syscall.ECONNREFUSED
+	}
+	return g.code, nil
+}
+
+func (g *fakeGeth) GetProof(_ context.Context, account common.Address, _ []string, _ *big.Int) (*gethclient.AccountResult, error) {
+	if g.isDown() {
+		return nil, syscall.ECONNREFUSED
+	}
+	return &gethclient.AccountResult{Address: account, StorageHash: g.storageRoot}, nil
+}
+
+func (g *fakeGeth) HeaderByNumber(_ context.Context, number *big.Int) (*eth.Header, error) {
+	if g.isDown() {
+		return nil, syscall.ECONNREFUSED
+	}
+	if number == nil {
+		return g.headers[g.head()], nil
+	}
+	header, ok := g.headers[number.Uint64()]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return header, nil
+}
+
+func testHeader(number uint64, extra string) *eth.Header {
+	return &eth.Header{Number: new(big.Int).SetUint64(number), Extra: []byte(extra), Difficulty: common.Big0}
+}
+
+func TestRetryableClient_ReadFailover(t *testing.T) {
+	primary, replica := newFakeGeth(testHeader(10, "a")), newFakeGeth(testHeader(10, "a"), testHeader(11, "a"))
+	rc := testRetryableClient(2, primary.endpoint(), replica.endpoint())
+
+	number, err := rc.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 10, number)
+
+	primary.down = true
+	number, err = rc.BlockNumber(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, 11, number)
+	header, err := rc.HeaderByNumber(context.Background(), big.NewInt(11))
+	require.NoError(t, err)
+	require.EqualValues(t, 11, header.Number.Uint64())
+	// reads never promote a replica
+	require.Equal(t, 0, rc.Primary())
+}
+
+func TestRetryableClient_ContractCallFailover(t *testing.T) {
+	primary, replica := newFakeGeth(testHeader(10, "a")), newFakeGeth(testHeader(10, "a"))
+	primary.code, replica.code = []byte{1}, []byte{2}
+	rc := testRetryableClient(2, primary.endpoint(), replica.endpoint())
+
+	ret, err := rc.CallContract(context.Background(), ethereum.CallMsg{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{1}, ret)
+	primary.down = true
+	ret, err = rc.CallContract(context.Background(), ethereum.CallMsg{}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte{2}, ret)
+}
+
+func TestRetryableClient_StorageRoot(t *testing.T) {
+	primary, replica := newFakeGeth(testHeader(10, "a")), newFakeGeth(testHeader(10, "a"))
+	primary.storageRoot, replica.storageRoot = common.Hash{1}, common.Hash{2}
+	rc := testRetryableClient(2, primary.endpoint(), replica.endpoint())
+
+	root, err := rc.StorageRoot(context.Background(), common.Address{1}, big.NewInt(10))
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{1}, root)
+	primary.down = true
+	root, err = rc.StorageRoot(context.Background(), common.Address{1}, big.NewInt(10))
+	require.NoError(t, err)
+	require.Equal(t, common.Hash{2}, root)
+
+	// an endpoint without the proofs
+	rc = testRetryableClient(2, &l2Endpoint{authClient: replica, ethClient: replica})
+	_, err = rc.StorageRoot(context.Background(), common.Address{1}, big.NewInt(10))
+	require.Error(t, err)
+}
+
+func TestRetryableClient_EnginePromotion(t *testing.T) {
+	head := testHeader(10, "a")
+	primary, replica := newFakeGeth(head), newFakeGeth(head, testHeader(11, "a"))
+	rc := testRetryableClient(2, primary.endpoint(), replica.endpoint())
+
+	block := &catalyst.ExecutableL2Data{Number: 10, Hash: head.Hash()}
+	require.NoError(t, rc.NewL2Block(context.Background(), block, nil, nil))
+	require.Equal(t, 1, primary.newL2Blocks)
+
+	primary.down = true
+	require.NoError(t, rc.NewL2Block(context.Background(), block, nil, nil))
+	require.Equal(t, 1, rc.Primary())
+	require.Equal(t, 1, replica.newL2Blocks)
+	require.True(t, rc.Available())
+}
+
+func TestRetryableClient_PromotionDoesNotBlock(t *testing.T) {
+	head := testHeader(10, "a")
+	primary, replica := newFakeGeth(head), newFakeGeth(head)
+	rc := testRetryableClient(1, primary.endpoint(), replica.endpoint())
+	rc.lastHead = &headInfo{number: 10, hash: head.Hash()}
+
+	// the replica is slow to answer its health check
+	checking, release := make(chan struct{}), make(chan struct{})
+	replica.onBlockNumber = func() {
+		close(checking)
+		<-release
+	}
+	primary.down = true
+	promoted := make(chan error)
+	go func() {
+		promoted <- rc.NewL2Block(context.Background(), &catalyst.ExecutableL2Data{Number: 10, Hash: head.Hash()}, nil, nil)
+	}()
+	<-checking
+	// the other callers are not held back by the health check of the replica
+	current := make(chan int)
+	go func() { current <- rc.Primary() }()
+	select {
+	case p := <-current:
+		require.Equal(t, 0, p)
+	case <-time.After(time.Second):
+		t.Fatal("Primary is blocked by the promotion")
+	}
+	close(release)
+	require.NoError(t, <-promoted)
+	require.Equal(t, 1, rc.Primary())
+}
+
+func TestRetryableClient_EnginePromotionRejected(t *testing.T) {
+	head := testHeader(10, "a")
+	forked := newFakeGeth(testHeader(10, "b"))
+	behind := newFakeGeth(testHeader(9, "a"))
+	primary := newFakeGeth(head)
+	rc := testRetryableClient(2, primary.endpoint(), forked.endpoint(), behind.endpoint())
+	rc.cfg.MaxElapsedTime = 50 * time.Millisecond
+
+	// no head known from the primary yet, nothing to check replicas against
+	primary.down = true
+	err := rc.NewL2Block(context.Background(), &catalyst.ExecutableL2Data{Number: 10, Hash: head.Hash()}, nil, nil)
+	require.ErrorIs(t, err, ErrL2Unavailable)
+	require.Equal(t, 0, rc.Primary())
+
+	rc.lastHead = &headInfo{number: 10, hash: head.Hash()}
+	err = rc.NewL2Block(context.Background(), &catalyst.ExecutableL2Data{Number: 10, Hash: head.Hash()}, nil, nil)
+	require.ErrorIs(t, err, ErrL2Unavailable)
+	require.Equal(t, 0, rc.Primary())
+	require.Zero(t, forked.newL2Blocks)
+	require.Zero(t, behind.newL2Blocks)
+}
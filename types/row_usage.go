@@ -0,0 +1,110 @@
+package types
+
+import (
+	"math"
+	"sort"
+
+	"github.com/scroll-tech/go-ethereum/core/types"
+)
+
+// RowUsage accumulates the rows used by a sequence of blocks, for every sub-circuit any of them uses.
+// A nil RowUsage is empty.
+type RowUsage struct {
+	rows map[string]uint64
+}
+
+func NewRowUsage(rc types.RowConsumption) *RowUsage {
+	u := &RowUsage{rows: make(map[string]uint64, len(rc))}
+	u.Add(rc)
+	return u
+}
+
+// Add adds the rows used by a block. A sub-circuit unknown so far joins the usage.
+func (u *RowUsage) Add(rc types.RowConsumption) {
+	if u.rows == nil {
+		u.rows = make(map[string]uint64, len(rc))
+	}
+	for _, subRc := range rc {
+		rows := u.rows[subRc.Name]
+		if rows > math.MaxUint64-subRc.RowNumber {
+			u.rows[subRc.Name] = math.MaxUint64
+		} else {
+			u.rows[subRc.Name] = rows + subRc.RowNumber
+		}
+	}
+}
+
+// With returns the usage with the rows of a block added, the usage itself is left as is.
+func (u *RowUsage) With(rc types.RowConsumption) *RowUsage {
+	sum := u.Copy()
+	sum.Add(rc)
+	return sum
+}
+
+// legacyWith adds the rows of a block the way chunks were accounted before the first chunk limits upgrade,
+// so that the chunks of those heights keep their boundaries. Sub-circuits unknown to a non-empty usage are dropped,
+// and a block without row consumption leaves an empty usage behind, although the usage so far decides whether
+// the block fits. It returns the usage deciding whether the block fits, and the one kept if it does.
+func (u *RowUsage) legacyWith(rc types.RowConsumption) (fit, kept *RowUsage) {
+	if u == nil || len(u.rows) == 0 {
+		sum := NewRowUsage(rc)
+		return sum, sum
+	}
+	if len(rc) == 0 {
+		return u.Copy(), &RowUsage{}
+	}
+	sum := u.Copy()
+	for _, subRc := range rc {
+		if _, ok := sum.rows[subRc.Name]; ok {
+			sum.Add(types.RowConsumption{subRc})
+		}
+	}
+	return sum, sum
+}
+
+func (u *RowUsage) Copy() *RowUsage {
+	cpy := &RowUsage{}
+	if u == nil {
+		return cpy
+	}
+	cpy.rows = make(map[string]uint64, len(u.rows))
+	for name, rows := range u.rows {
+		cpy.rows[name] = rows
+	}
+	return cpy
+}
+
+// Rows returns the rows used by the sub-circuit.
+func (u *RowUsage) Rows(name string) uint64 {
+	if u == nil {
+		return 0
+	}
+	return u.rows[name]
+}
+
+// Max returns the sub-circuit using the most rows, the limiting one.
+// Ties go to the first name in lexical order, so that all the nodes report the same circuit.
+func (u *RowUsage) Max() (name string, rows uint64) {
+	if u == nil {
+		return "", 0
+	}
+	for n, r := range u.rows {
+		if r > rows || (r == rows && (name == "" || n < name)) {
+			name, rows = n, r
+		}
+	}
+	return
+}
+
+// RowConsumption returns the rows of every sub-circuit, sorted by name.
+func (u *RowUsage) RowConsumption() types.RowConsumption {
+	if u == nil || len(u.rows) == 0 {
+		return types.RowConsumption{}
+	}
+	rc := make(types.RowConsumption, 0, len(u.rows))
+	for name, rows := range u.rows {
+		rc = append(rc, types.SubCircuitRowUsage{Name: name, RowNumber: rows})
+	}
+	sort.Slice(rc, func(i, j int) bool { return rc[i].Name < rc[j].Name })
+	return rc
+}
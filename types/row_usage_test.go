@@ -0,0 +1,69 @@
+package types
+
+import (
+	"math"
+	"testing"
+
+	"github.com/scroll-tech/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowUsage(t *testing.T) {
+	var empty *RowUsage
+	name, rows := empty.Max()
+	require.Equal(t, "", name)
+	require.Zero(t, rows)
+	require.Equal(t, types.RowConsumption{}, empty.RowConsumption())
+	require.Zero(t, empty.Rows("a"))
+
+	usage := empty.With(types.RowConsumption{{Name: "b", RowNumber: 5}, {Name: "a", RowNumber: 5}})
+	name, rows = usage.Max()
+	require.Equal(t, "a", name, "ties go to the first name")
+	require.EqualValues(t, 5, rows)
+
+	usage.Add(types.RowConsumption{{Name: "c", RowNumber: 2}, {Name: "b", RowNumber: 1}})
+	require.Equal(t, types.RowConsumption{
+		{Name: "a", RowNumber: 5},
+		{Name: "b", RowNumber: 6},
+		{Name: "c", RowNumber: 2},
+	}, usage.RowConsumption())
+	name, rows = usage.Max()
+	require.Equal(t, "b", name)
+	require.EqualValues(t, 6, rows)
+
+	cpy := usage.Copy()
+	cpy.Add(types.RowConsumption{{Name: "a", RowNumber: 10}})
+	require.EqualValues(t, 15, cpy.Rows("a"))
+	require.EqualValues(t, 5, usage.Rows("a"))
+
+	usage.Add(types.RowConsumption{{Name: "a", RowNumber: math.MaxUint64}})
+	require.EqualValues(t, uint64(math.MaxUint64), usage.Rows("a"), "saturates instead of wrapping")
+}
+
+func TestChunksClosing(t *testing.T) {
+	chunks := NewChunks()
+	chunks.SetLimits(ChunkLimits{MaxBlocks: 2, MaxRowNumber: 100, MaxTxsPayloadBytes: 10})
+	require.Nil(t, chunks.LastChunkStatus())
+
+	require.Nil(t, chunks.Append(nil, nil, nil, types.RowConsumption{{Name: "a", RowNumber: 60}}))
+	// the sub-circuit only used by the 2nd block closes the chunk
+	closing := chunks.Append(nil, nil, nil, types.RowConsumption{{Name: "b", RowNumber: 101}})
+	require.Equal(t, &ChunkClosing{Reason: ChunkCloseMaxRowNumber, Circuit: "b", Value: 101, Limit: 100, BlockNum: 1}, closing)
+
+	status := chunks.LastChunkStatus()
+	require.Equal(t, 1, status.BlockNum)
+	require.Equal(t, "b", status.MaxCircuit)
+	require.EqualValues(t, 101, status.MaxRowNumber)
+	// the block over the row limit on its own is left alone in its chunk
+	closing = chunks.Append(nil, make([]byte, 5), nil, types.RowConsumption{{Name: "a", RowNumber: 1}})
+	require.Equal(t, ChunkCloseMaxRowNumber, closing.Reason)
+	status = chunks.LastChunkStatus()
+	require.Equal(t, types.RowConsumption{{Name: "a", RowNumber: 1}}, status.RowConsumption)
+	require.Equal(t, 5, status.TxsPayloadBytes)
+
+	closing = chunks.Append(nil, make([]byte, 6), nil, nil)
+	require.Equal(t, &ChunkClosing{Reason: ChunkCloseMaxTxsPayloadBytes, Value: 11, Limit: 10, BlockNum: 1}, closing)
+	require.Nil(t, chunks.Append(nil, nil, nil, nil))
+	closing = chunks.Append(nil, nil, nil, nil)
+	require.Equal(t, &ChunkClosing{Reason: ChunkCloseMaxBlocks, Value: 3, Limit: 2, BlockNum: 2}, closing)
+}
@@ -0,0 +1,18 @@
+package types
+
+import "github.com/scroll-tech/go-ethereum/common"
+
+// SequencerInfo is a sequencer as listed by the L2Sequencer contract.
+type SequencerInfo struct {
+	Addr   common.Address
+	TmKey  [32]byte
+	BlsKey []byte
+}
+
+// SequencerSet is a version of the sequencer set of the L2Sequencer contract,
+// taking part in the consensus from the L2 height StartHeight on.
+type SequencerSet struct {
+	Version     uint64
+	StartHeight uint64
+	Sequencers  []SequencerInfo
+}
@@ -0,0 +1,107 @@
+package types
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// skippedBitmapWordSize is the size of one word of the encoded bitmap, a uint256 of the Rollup contract.
+const skippedBitmapWordSize = 32
+
+// SkippedBitmap marks the L1 messages of a batch which were skipped rather than executed.
+// Bit i stands for the message at queue index TotalL1MessagePopped of the parent batch + i.
+//
+// It is kept in the encoding the Rollup contract expects: an array of 256-bit words, each one big endian,
+// word k holding bits 256k to 256k+255. The encoding covers every message popped by the batch,
+// so it may end with words which are all zero.
+// The zero value is an empty bitmap.
+type SkippedBitmap struct {
+	data []byte
+}
+
+// DecodeSkippedBitmap decodes the bitmap of a batch header or a commitBatch call.
+func DecodeSkippedBitmap(data []byte) (*SkippedBitmap, error) {
+	if len(data)%skippedBitmapWordSize != 0 {
+		return nil, fmt.Errorf("invalid skipped L1 message bitmap length %d, expected a multiple of %d", len(data), skippedBitmapWordSize)
+	}
+	return &SkippedBitmap{data: append([]byte{}, data...)}, nil
+}
+
+// Encode returns the bitmap in the encoding of the Rollup contract.
+func (b *SkippedBitmap) Encode() []byte {
+	if b == nil {
+		return []byte{}
+	}
+	return append([]byte{}, b.data...)
+}
+
+// Words returns the number of 256-bit words of the encoded bitmap.
+func (b *SkippedBitmap) Words() int {
+	if b == nil {
+		return 0
+	}
+	return len(b.data) / skippedBitmapWordSize
+}
+
+// Extend grows the bitmap to cover bit i, without setting it.
+func (b *SkippedBitmap) Extend(i uint64) {
+	words := int(i/256) + 1
+	if missing := words - b.Words(); missing > 0 {
+		b.data = append(b.data, make([]byte, missing*skippedBitmapWordSize)...)
+	}
+}
+
+// Set marks bit i, growing the bitmap if needed.
+func (b *SkippedBitmap) Set(i uint64) {
+	b.Extend(i)
+	pos, mask := b.position(i)
+	b.data[pos] |= mask
+}
+
+// Test reports whether bit i is set. Bits beyond the bitmap are not set.
+func (b *SkippedBitmap) Test(i uint64) bool {
+	if b == nil || i/256 >= uint64(b.Words()) {
+		return false
+	}
+	pos, mask := b.position(i)
+	return b.data[pos]&mask != 0
+}
+
+// Count returns the number of set bits, i.e. the number of skipped messages.
+func (b *SkippedBitmap) Count() int {
+	if b == nil {
+		return 0
+	}
+	count := 0
+	for _, bt := range b.data {
+		count += bits.OnesCount8(bt)
+	}
+	return count
+}
+
+// Merge sets every bit set in other, e.g. to accumulate the bitmaps of the blocks of a batch.
+func (b *SkippedBitmap) Merge(other *SkippedBitmap) {
+	if other == nil {
+		return
+	}
+	if missing := len(other.data) - len(b.data); missing > 0 {
+		b.data = append(b.data, make([]byte, missing)...)
+	}
+	for i, bt := range other.data {
+		b.data[i] |= bt
+	}
+}
+
+// Copy returns a bitmap which can be modified without affecting b.
+func (b *SkippedBitmap) Copy() *SkippedBitmap {
+	if b == nil {
+		return &SkippedBitmap{}
+	}
+	return &SkippedBitmap{data: append([]byte{}, b.data...)}
+}
+
+// position returns the byte holding bit i and the mask of the bit in that byte.
+func (b *SkippedBitmap) position(i uint64) (int, byte) {
+	word, bit := i/256, i%256
+	return int(word)*skippedBitmapWordSize + skippedBitmapWordSize - 1 - int(bit/8), 1 << (bit % 8)
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// referenceEncoding encodes bits the way the Rollup contract reads them: one uint256 per 256 messages.
+func referenceEncoding(set map[uint64]bool, words int) []byte {
+	out := make([]byte, words*32)
+	for w := 0; w < words; w++ {
+		word := new(big.Int)
+		for i := 0; i < 256; i++ {
+			if set[uint64(w*256+i)] {
+				word.SetBit(word, i, 1)
+			}
+		}
+		word.FillBytes(out[w*32 : (w+1)*32])
+	}
+	return out
+}
+
+func randomBitmap(r *rand.Rand, maxBit int) (*SkippedBitmap, map[uint64]bool) {
+	bitmap := new(SkippedBitmap)
+	set := make(map[uint64]bool)
+	for n := r.Intn(64); n > 0; n-- {
+		i := uint64(r.Intn(maxBit))
+		bitmap.Set(i)
+		set[i] = true
+	}
+	return bitmap, set
+}
+
+func TestSkippedBitmapProperties(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for round := 0; round < 500; round++ {
+		maxBit := 1 + r.Intn(1200)
+		bitmap, set := randomBitmap(r, maxBit)
+
+		// set/test/count agree with the model, including bits above 64 in every word
+		for i := uint64(0); i < uint64(maxBit)+256; i++ {
+			require.Equal(t, set[i], bitmap.Test(i), "bit %d", i)
+		}
+		require.Equal(t, len(set), bitmap.Count())
+
+		// the encoding is the one of the contract and round trips
+		encoded := bitmap.Encode()
+		require.Zero(t, len(encoded)%32)
+		require.Equal(t, referenceEncoding(set, bitmap.Words()), encoded)
+		decoded, err := DecodeSkippedBitmap(encoded)
+		require.NoError(t, err)
+		require.Equal(t, encoded, decoded.Encode())
+		require.Equal(t, bitmap.Count(), decoded.Count())
+
+		// merge is the union, and covers the longer of the two
+		other, otherSet := randomBitmap(r, maxBit)
+		merged := bitmap.Copy()
+		merged.Merge(other)
+		union := make(map[uint64]bool)
+		for i := range set {
+			union[i] = true
+		}
+		for i := range otherSet {
+			union[i] = true
+		}
+		require.Equal(t, len(union), merged.Count())
+		for i := range union {
+			require.True(t, merged.Test(i))
+		}
+		words := bitmap.Words()
+		if other.Words() > words {
+			words = other.Words()
+		}
+		require.Equal(t, words, merged.Words())
+		// the copy is independent
+		require.Equal(t, encoded, bitmap.Encode())
+	}
+}
+
+func TestSkippedBitmapExtend(t *testing.T) {
+	var bitmap SkippedBitmap
+	require.Empty(t, bitmap.Encode())
+	bitmap.Extend(0)
+	require.Equal(t, 1, bitmap.Words())
+	bitmap.Extend(255)
+	require.Equal(t, 1, bitmap.Words())
+	bitmap.Extend(256)
+	require.Equal(t, 2, bitmap.Words())
+	require.Zero(t, bitmap.Count())
+	require.Equal(t, make([]byte, 64), bitmap.Encode())
+
+	_, err := DecodeSkippedBitmap(make([]byte, 33))
+	require.Error(t, err)
+
+	var nilBitmap *SkippedBitmap
+	require.False(t, nilBitmap.Test(0))
+	require.Zero(t, nilBitmap.Count())
+	require.Zero(t, nilBitmap.Words())
+}
@@ -6,13 +6,15 @@ import (
 	"strings"
 
 	"github.com/morph-l2/node/flags"
+	"github.com/morph-l2/node/types"
 	"github.com/scroll-tech/go-ethereum/common"
 	"github.com/scroll-tech/go-ethereum/crypto"
 	"github.com/urfave/cli"
 )
 
 type Config struct {
-	l1RPC           string
+	l1Addrs         []string
+	l1Quorum        int
 	PrivateKey      *ecdsa.PrivateKey
 	L1ChainID       *big.Int
 	rollupContract  common.Address
@@ -35,7 +37,8 @@ func (c *Config) SetCliContext(ctx *cli.Context) error {
 	c.challengeEnable = ctx.GlobalIsSet(flags.ValidatorEnable.Name)
 	addrHex := ctx.GlobalString(flags.RollupContractAddress.Name)
 	rollupContract := common.HexToAddress(addrHex)
-	c.l1RPC = l1NodeAddr
+	c.l1Addrs = types.SplitAddrs(l1NodeAddr)
+	c.l1Quorum = ctx.GlobalInt(flags.L1Quorum.Name)
 	c.L1ChainID = big.NewInt(int64(l1ChainID))
 	c.PrivateKey = privateKey
 	c.rollupContract = rollupContract
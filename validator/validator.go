@@ -9,10 +9,10 @@ import (
 	"time"
 
 	"github.com/morph-l2/bindings/bindings"
+	nodecommon "github.com/morph-l2/node/common"
 	"github.com/scroll-tech/go-ethereum"
 	"github.com/scroll-tech/go-ethereum/accounts/abi/bind"
 	ethtypes "github.com/scroll-tech/go-ethereum/core/types"
-	"github.com/scroll-tech/go-ethereum/ethclient"
 	"github.com/scroll-tech/go-ethereum/log"
 	tmlog "github.com/tendermint/tendermint/libs/log"
 )
@@ -32,7 +32,7 @@ type DeployContractBackend interface {
 }
 
 func NewValidator(cfg *Config, rollup *bindings.Rollup, logger tmlog.Logger) (*Validator, error) {
-	cli, err := ethclient.Dial(cfg.l1RPC)
+	cli, err := nodecommon.DialQuorumClient(cfg.l1Addrs, cfg.l1Quorum, logger)
 	if err != nil {
 		return nil, fmt.Errorf("dial l1 node error:%v", err)
 	}